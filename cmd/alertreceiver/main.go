@@ -0,0 +1,54 @@
+// Command alertreceiver is a local webhook receiver for testing alert rules.
+// It verifies signatures with ALERT_SECRET, the secret returned when the rule
+// was created, and prints every payload received.
+//
+// The server only sends webhooks to public hosts by default, so allow the
+// receiver's host before creating a rule pointing at it, e.g. start the
+// server with ALERT_WEBHOOK_ALLOWLIST=localhost and use
+// http://localhost:9090/ as the rule's webhook_url. A receiver elsewhere on
+// the internal network, such as a team chat bridge, can be allowed by its
+// host name, address or range ("10.0.0.0/8"), or every host with
+// ALERT_ALLOW_PRIVATE_WEBHOOKS=true.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/mswatii/cs2-arbitrage/internal/alerts"
+	"github.com/valyala/fasthttp"
+)
+
+func main() {
+	secret := os.Getenv("ALERT_SECRET")
+	addr := os.Getenv("ALERT_RECEIVER_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		body := ctx.PostBody()
+		timestamp := string(ctx.Request.Header.Peek(alerts.TimestampHeader))
+		signature := string(ctx.Request.Header.Peek(alerts.SignatureHeader))
+
+		if secret != "" && !alerts.VerifySignature(secret, timestamp, signature, body) {
+			log.Printf("Rejected webhook with invalid signature from %s", ctx.RemoteAddr())
+			ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+			return
+		}
+
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err != nil {
+			pretty.Write(body)
+		}
+		log.Printf("Received %s webhook:\n%s", ctx.Request.Header.Peek(alerts.EventHeader), pretty.String())
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}
+
+	log.Printf("Listening for alert webhooks on %s", addr)
+	if err := fasthttp.ListenAndServe(addr, handler); err != nil {
+		log.Fatalf("Error starting receiver: %v", err)
+	}
+}
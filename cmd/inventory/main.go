@@ -0,0 +1,88 @@
+// Command inventory values a Steam inventory against the scraped prices.
+//
+//	inventory -file inventory.json   # saved /inventory/<steamid>/730/2 JSON
+//	inventory -steamid 7656119...    # fetched from a public profile
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/joho/godotenv"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/portfolio"
+	"github.com/mswatii/cs2-arbitrage/internal/steam"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
+)
+
+func main() {
+	file := flag.String("file", "", "path to a saved public inventory JSON page")
+	steamID := flag.String("steamid", "", "SteamID64 of a public profile to fetch")
+	asJSON := flag.Bool("json", false, "print the valuation as JSON")
+	flag.Parse()
+
+	if (*file == "") == (*steamID == "") {
+		fmt.Fprintln(os.Stderr, "Specify exactly one of -file or -steamid")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: .env file not found or cannot be loaded")
+	}
+
+	var assets []steam.InventoryAsset
+	var err error
+	if *file != "" {
+		data, readErr := os.ReadFile(*file)
+		if readErr != nil {
+			log.Fatalf("Failed to read inventory file: %v", readErr)
+		}
+		assets, err = steam.ParseInventory(data)
+	} else {
+		baseURL := config.String("STEAM_COMMUNITY_URL", steam.DefaultCommunityURL)
+		assets, err = steam.FetchInventory(httputil.DefaultClient, baseURL, *steamID)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load inventory: %v", err)
+	}
+
+	db, err := database.NewDatabase()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	valuation, err := portfolio.ValueInventory(db, assets)
+	if err != nil {
+		log.Fatalf("Failed to value inventory: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(valuation)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ITEM\tQTY\tBEST VENUE\tNET/UNIT\tTOTAL")
+	for _, item := range valuation.Items {
+		venue := item.BestVenue
+		if venue == "" {
+			venue = "-"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t$%.2f\t$%.2f\n", item.MarketHashName, item.Quantity, venue, item.BestNetUSD, item.TotalValueUSD)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d assets, %d items matched, %d unmatched, %d without prices\n",
+		valuation.AssetCount, valuation.MatchedCount, valuation.UnmatchedCount, valuation.UnpricedCount)
+	fmt.Printf("Total: $%.2f (%.0f IRR at %.0f IRR/USDT)\n",
+		valuation.TotalValueUSD, valuation.TotalValueIRR, valuation.ExchangeRateIRR)
+}
@@ -1,13 +1,25 @@
 package main
 
 import (
+	"errors"
 	"github.com/joho/godotenv"
+	"github.com/mswatii/cs2-arbitrage/internal/alerts"
 	"github.com/mswatii/cs2-arbitrage/internal/api"
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/auth"
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
 	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/notifier"
 	"github.com/mswatii/cs2-arbitrage/internal/scraper"
+	"github.com/mswatii/cs2-arbitrage/internal/steam"
+	"github.com/mswatii/cs2-arbitrage/internal/stream"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
 	"github.com/valyala/fasthttp"
 	"log"
 	"os"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -28,8 +40,57 @@ func main() {
 		log.Fatalf("Failed to create tables: %v", err)
 	}
 
+	// Seed the sticker catalogue with the bundled reference prices
+	if stickerPrices, err := catalog.BundledStickerPrices(); err != nil {
+		log.Printf("Warning: %v", err)
+	} else if err := db.SeedStickerPrices(stickerPrices); err != nil {
+		log.Printf("Warning: Failed to seed sticker prices: %v", err)
+	}
+
+	// Start the alert engine delivering webhooks for matching new listings.
+	// Users choose the URLs, so only public hosts are reached unless
+	// ALERT_ALLOW_PRIVATE_WEBHOOKS or ALERT_WEBHOOK_ALLOWLIST say otherwise.
+	webhookPolicy := alerts.WebhookPolicyFromEnv()
+	alertEngine := alerts.NewEngine(db, webhookPolicy.NewClient())
+	alertEngine.Start(config.Int("ALERT_WORKERS", 2))
+	observers := []scraper.Observer{alertEngine}
+
+	// Announce opportunities on Telegram when a bot is configured
+	telegram, err := notifier.TelegramFromEnv(db, httputil.DefaultClient)
+	if err != nil {
+		log.Printf("Warning: Telegram notifications disabled: %v", err)
+	} else if telegram != nil {
+		telegram.Start()
+		observers = append(observers, telegram)
+	}
+
+	// Record when opportunities open and close
+	trackMinProfit := config.Float("TRACK_MIN_PROFIT_PERCENT", 10)
+	observers = append(observers, arbitrage.NewTracker(db, trackMinProfit))
+
+	// Push opportunity changes to /api/stream subscribers. The hub is seeded
+	// from the tracker's open opportunities, so it can't stream below its
+	// threshold without announcing known listings as added after a restart.
+	streamMinProfit := config.Float("STREAM_MIN_PROFIT_PERCENT", trackMinProfit)
+	if streamMinProfit < trackMinProfit {
+		log.Printf("Warning: STREAM_MIN_PROFIT_PERCENT %.2f is below TRACK_MIN_PROFIT_PERCENT, streaming from %.2f", streamMinProfit, trackMinProfit)
+		streamMinProfit = trackMinProfit
+	}
+	hub := stream.NewHub(db, streamMinProfit)
+	observers = append(observers, hub)
+
+	// Authenticate API calls with hashed API keys and web UI sessions
+	authenticator := auth.NewAuthenticator(db)
+	authenticator.StartCleanup(time.Hour)
+
 	// Initialize API handler
-	handler := api.NewHandler(db)
+	handler := api.NewHandler(db, authenticator, alertEngine, webhookPolicy, hub, observers...)
+
+	// Keep Steam volume and listing figures fresh for liquidity scoring
+	if config.Bool("STEAM_STATS_ENABLED", true) {
+		steamClient := steam.NewMarketClient(config.String("STEAM_MARKET_URL", steam.DefaultMarketURL), httputil.DefaultClient)
+		steam.NewRefresher(db, steamClient).Start()
+	}
 
 	// Initialize exchange rate (this will cache the first value)
 	exchangeRate := scraper.GetUSDTtoIRRRate()
@@ -38,13 +99,16 @@ func main() {
 	// Run the scraper on startup if SKIP_INITIAL_SCRAPE is not set
 	if os.Getenv("SKIP_INITIAL_SCRAPE") != "true" {
 		log.Println("Starting initial data scrape...")
-		csgoSkinScraper, err := scraper.NewCSGOSkinScraper(db)
+		csgoSkinScraper, err := scraper.NewCSGOSkinScraper(db, observers...)
 		if err != nil {
 			log.Printf("Warning: Failed to initialize scraper: %v", err)
 		} else {
 			// Run the scraper in a goroutine so it doesn't block server startup
 			go func() {
-				if err := csgoSkinScraper.FetchItems(); err != nil {
+				err := csgoSkinScraper.FetchItems()
+				if errors.Is(err, scraper.ErrScrapeRunning) {
+					log.Println("Skipping initial data scrape, a refresh is already running")
+				} else if err != nil {
 					log.Printf("Error during initial data scrape: %v", err)
 				} else {
 					log.Println("Initial data scrape completed successfully")
@@ -61,8 +125,24 @@ func main() {
 		port = "8080"
 	}
 
+	// Slow or idle clients are cut off so they can't hold connections open.
+	// Responses must be written within HTTP_WRITE_TIMEOUT, except the event
+	// stream, which stays open for as long as its client listens.
+	writeTimeout := config.Duration("HTTP_WRITE_TIMEOUT", time.Minute)
+	server := &fasthttp.Server{
+		Handler:     handler.HandleRequest,
+		ReadTimeout: config.Duration("HTTP_READ_TIMEOUT", 30*time.Second),
+		IdleTimeout: config.Duration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		HeaderReceived: func(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+			if path, _, _ := strings.Cut(string(header.RequestURI()), "?"); path == "/api/stream" {
+				return fasthttp.RequestConfig{}
+			}
+			return fasthttp.RequestConfig{WriteTimeout: writeTimeout}
+		},
+	}
+
 	log.Printf("Starting server on port %s", port)
-	if err := fasthttp.ListenAndServe(":"+port, handler.HandleRequest); err != nil {
+	if err := server.ListenAndServe(":" + port); err != nil {
 		log.Fatalf("Error starting server: %v", err)
 	}
 }
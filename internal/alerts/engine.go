@@ -0,0 +1,260 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
+	"github.com/valyala/fasthttp"
+)
+
+// rulesCacheTTL is how long enabled rules are cached between scraped items
+const rulesCacheTTL = time.Minute
+
+// delivery is a queued webhook for one rule and listing
+type delivery struct {
+	rule models.AlertRule
+	opp  arbitrage.Opportunity
+}
+
+// Store is the storage the engine needs. *database.Database satisfies it,
+// and tests supply their own.
+type Store interface {
+	GetAlertRules(enabledOnly bool, userID string) ([]models.AlertRule, error)
+	ClaimAlertDelivery(ruleID, itemID string) (bool, error)
+	UpdateAlertDelivery(ruleID, itemID, status string, attempts int, lastError string) error
+	GetUser(id string) (*models.User, error)
+}
+
+// Engine matches newly scraped listings against alert rules and delivers
+// signed webhooks with retries. It implements scraper.Observer.
+type Engine struct {
+	db          Store
+	client      httputil.Doer
+	queue       chan delivery
+	maxAttempts int
+	retryDelay  time.Duration
+
+	rulesMu     sync.Mutex
+	rules       []models.AlertRule
+	ownerFees   map[string]arbitrage.Filter // Fee settings of rule owners by user ID
+	rulesLoaded time.Time
+}
+
+// NewEngine creates an alert engine sending webhooks through client. Retries
+// are configured with ALERT_MAX_ATTEMPTS and ALERT_RETRY_DELAY (doubled after
+// each failed attempt).
+func NewEngine(db Store, client httputil.Doer) *Engine {
+	return &Engine{
+		db:          db,
+		client:      client,
+		queue:       make(chan delivery, config.Int("ALERT_QUEUE_SIZE", 1000)),
+		maxAttempts: config.Int("ALERT_MAX_ATTEMPTS", 5),
+		retryDelay:  config.Duration("ALERT_RETRY_DELAY", 2*time.Second),
+	}
+}
+
+// WebhookPolicyFromEnv builds the policy deciding which hosts webhooks may
+// reach. Only public hosts are allowed unless ALERT_ALLOW_PRIVATE_WEBHOOKS
+// is true, or the host, its address or a range containing it is listed in
+// ALERT_WEBHOOK_ALLOWLIST (comma separated, e.g. "localhost,10.0.0.0/8").
+// An invalid allowlist is ignored.
+func WebhookPolicyFromEnv() httputil.HostPolicy {
+	allowPrivate := config.Bool("ALERT_ALLOW_PRIVATE_WEBHOOKS", false)
+	policy, err := httputil.ParseHostPolicy(allowPrivate, config.String("ALERT_WEBHOOK_ALLOWLIST", ""))
+	if err != nil {
+		log.Printf("Warning: Invalid ALERT_WEBHOOK_ALLOWLIST, ignoring it: %v", err)
+		return httputil.HostPolicy{AllowPrivate: allowPrivate}
+	}
+	return policy
+}
+
+// Start launches the workers delivering queued webhooks
+func (e *Engine) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go e.worker()
+	}
+}
+
+// ItemProcessed checks a stored listing against the enabled rules and queues
+// a webhook for every rule it matches that hasn't alerted it yet. Failed
+// deliveries, including those dropped on a full queue, are tried again when
+// a later scrape sees the listing.
+func (e *Engine) ItemProcessed(item *models.Item, opp *arbitrage.Opportunity) {
+	if opp == nil {
+		return
+	}
+
+	rules, ownerFees, err := e.enabledRules()
+	if err != nil {
+		log.Printf("Error loading alert rules: %v", err)
+		return
+	}
+
+	for _, rule := range rules {
+		// Rules are matched at their owner's fees, like the owner's own
+		// opportunity listing
+		ruleOpp := *opp
+		if rule.UserID != nil {
+			if fees, ok := ownerFees[*rule.UserID]; ok {
+				ruleOpp = fees.Reprice(ruleOpp)
+			}
+		}
+		if !Matches(&rule, &ruleOpp) {
+			continue
+		}
+
+		claimed, err := e.db.ClaimAlertDelivery(rule.ID, item.ID)
+		if err != nil {
+			log.Printf("Error recording alert for rule %s: %v", rule.ID, err)
+			continue
+		}
+		if !claimed {
+			continue // Already alerted for this listing
+		}
+
+		select {
+		case e.queue <- delivery{rule: rule, opp: ruleOpp}:
+		default:
+			log.Printf("Alert queue full, dropping alert for rule %s and item %s", rule.ID, item.ID)
+			e.db.UpdateAlertDelivery(rule.ID, item.ID, database.AlertDeliveryFailed, 0, "alert queue full")
+		}
+	}
+}
+
+// ScrapeStarted is a no-op; rules are loaded on the first processed item
+func (e *Engine) ScrapeStarted(marketplaceID string) {}
+
+// ScrapeCompleted drops the cached rules and owner settings so edits apply
+// to the next scrape
+func (e *Engine) ScrapeCompleted(summary *models.ScrapeSummary) {
+	e.rulesMu.Lock()
+	e.rules = nil
+	e.rulesMu.Unlock()
+}
+
+// SendTest delivers a test payload to a rule's webhook once, without retries
+func (e *Engine) SendTest(rule *models.AlertRule) error {
+	payload := Payload{
+		Event:    EventTest,
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		SentAt:   time.Now().UTC(),
+	}
+	return e.send(rule, payload)
+}
+
+// enabledRules returns the enabled rules and the fee settings of their
+// owners, cached for rulesCacheTTL
+func (e *Engine) enabledRules() ([]models.AlertRule, map[string]arbitrage.Filter, error) {
+	e.rulesMu.Lock()
+	defer e.rulesMu.Unlock()
+
+	if e.rules != nil && time.Since(e.rulesLoaded) < rulesCacheTTL {
+		return e.rules, e.ownerFees, nil
+	}
+
+	rules, err := e.db.GetAlertRules(true, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if rules == nil {
+		rules = []models.AlertRule{}
+	}
+
+	// Owners whose settings can't be loaded are valued at the default fees
+	ownerFees := make(map[string]arbitrage.Filter)
+	for _, rule := range rules {
+		if rule.UserID == nil {
+			continue
+		}
+		if _, ok := ownerFees[*rule.UserID]; ok {
+			continue
+		}
+		user, err := e.db.GetUser(*rule.UserID)
+		if err != nil {
+			log.Printf("Warning: Failed to load settings of alert rule owner %s: %v", *rule.UserID, err)
+			continue
+		}
+		if user == nil {
+			continue
+		}
+		ownerFees[user.ID] = arbitrage.Filter{
+			SteamSaleFeePercent:       user.Settings.SteamSaleFeePercent,
+			MarketplaceSaleFeePercent: user.Settings.MarketplaceFeePercent,
+		}
+	}
+
+	e.rules = rules
+	e.ownerFees = ownerFees
+	e.rulesLoaded = time.Now()
+	return rules, ownerFees, nil
+}
+
+// worker delivers queued webhooks, retrying failures with exponential backoff
+func (e *Engine) worker() {
+	for d := range e.queue {
+		payload := Payload{
+			Event:       EventOpportunityMatched,
+			RuleID:      d.rule.ID,
+			RuleName:    d.rule.Name,
+			Opportunity: &d.opp,
+		}
+
+		delay := e.retryDelay
+		var lastErr error
+		attempts := 0
+		for attempts < e.maxAttempts {
+			attempts++
+			payload.SentAt = time.Now().UTC()
+			if lastErr = e.send(&d.rule, payload); lastErr == nil {
+				break
+			}
+			if attempts < e.maxAttempts {
+				time.Sleep(delay)
+				delay *= 2
+			}
+		}
+
+		status, errText := database.AlertDeliveryDelivered, ""
+		if lastErr != nil {
+			status, errText = database.AlertDeliveryFailed, lastErr.Error()
+			log.Printf("Alert for rule %s and item %s failed after %d attempts: %v", d.rule.ID, d.opp.ItemID, attempts, lastErr)
+		}
+		if err := e.db.UpdateAlertDelivery(d.rule.ID, d.opp.ItemID, status, attempts, errText); err != nil {
+			log.Printf("Error recording alert delivery: %v", err)
+		}
+	}
+}
+
+// send signs and POSTs a payload to the rule's webhook URL
+func (e *Engine) send(rule *models.AlertRule, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+
+	timestamp := payload.SentAt.Unix()
+	headers := map[string]string{
+		EventHeader:     payload.Event,
+		TimestampHeader: strconv.FormatInt(timestamp, 10),
+		SignatureHeader: Sign(rule.Secret, timestamp, body),
+	}
+
+	status, _, err := httputil.PostBody(e.client, rule.WebhookURL, "application/json", body, headers)
+	if err != nil {
+		return err
+	}
+	if status < fasthttp.StatusOK || status >= fasthttp.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned status code %d", status)
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package alerts
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// fakeStore keeps rules and deliveries in memory, claiming each rule and
+// listing once like the alert_deliveries unique constraint, or again after
+// a failed delivery
+type fakeStore struct {
+	mu       sync.Mutex
+	rules    []models.AlertRule
+	users    map[string]*models.User
+	claimed  map[string]string // Delivery status by rule and item
+	outcomes chan outcome
+}
+
+// outcome is a recorded delivery result
+type outcome struct {
+	ruleID, itemID, status string
+	attempts               int
+}
+
+func newFakeStore(rules ...models.AlertRule) *fakeStore {
+	return &fakeStore{rules: rules, users: map[string]*models.User{}, claimed: map[string]string{}, outcomes: make(chan outcome, 10)}
+}
+
+func (s *fakeStore) GetAlertRules(enabledOnly bool, userID string) ([]models.AlertRule, error) {
+	return s.rules, nil
+}
+
+func (s *fakeStore) GetUser(id string) (*models.User, error) {
+	return s.users[id], nil
+}
+
+func (s *fakeStore) ClaimAlertDelivery(ruleID, itemID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := ruleID + "/" + itemID
+	if status, ok := s.claimed[key]; ok && status != database.AlertDeliveryFailed {
+		return false, nil
+	}
+	s.claimed[key] = database.AlertDeliveryPending
+	return true, nil
+}
+
+func (s *fakeStore) UpdateAlertDelivery(ruleID, itemID, status string, attempts int, lastError string) error {
+	s.mu.Lock()
+	s.claimed[ruleID+"/"+itemID] = status
+	s.mu.Unlock()
+	s.outcomes <- outcome{ruleID: ruleID, itemID: itemID, status: status, attempts: attempts}
+	return nil
+}
+
+// receiver is a local webhook endpoint answering with scripted statuses
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int // Answer for each request in turn, then 200
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	at        time.Time
+	body      []byte
+	timestamp string
+	signature string
+	event     string
+}
+
+func (r *receiver) handle(ctx *fasthttp.RequestCtx) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests = append(r.requests, receivedRequest{
+		at:        time.Now(),
+		body:      append([]byte(nil), ctx.PostBody()...),
+		timestamp: string(ctx.Request.Header.Peek(TimestampHeader)),
+		signature: string(ctx.Request.Header.Peek(SignatureHeader)),
+		event:     string(ctx.Request.Header.Peek(EventHeader)),
+	})
+
+	status := fasthttp.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	ctx.SetStatusCode(status)
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+// startReceiver serves a receiver on an in-memory listener and returns a
+// client dialling it
+func startReceiver(t *testing.T, r *receiver) *fasthttp.Client {
+	ln := fasthttputil.NewInmemoryListener()
+	go fasthttp.Serve(ln, r.handle)
+	t.Cleanup(func() { ln.Close() })
+
+	return &fasthttp.Client{
+		Dial: func(addr string) (net.Conn, error) { return ln.Dial() },
+	}
+}
+
+// newTestEngine creates an engine retrying quickly
+func newTestEngine(store Store, client *fasthttp.Client, maxAttempts int, retryDelay time.Duration) *Engine {
+	e := NewEngine(store, client)
+	e.maxAttempts = maxAttempts
+	e.retryDelay = retryDelay
+	e.Start(1)
+	return e
+}
+
+func testRule() models.AlertRule {
+	return models.AlertRule{
+		ID:              "rule-1",
+		Name:            "Cheap knives",
+		WebhookURL:      "http://hooks.example.com/alerts",
+		Secret:          "s3cret",
+		MinNetProfitUSD: 5,
+		Categories:      []string{"Knife"},
+		Enabled:         true,
+	}
+}
+
+func testOpportunity(itemID string) *arbitrage.Opportunity {
+	return &arbitrage.Opportunity{
+		ItemID:         itemID,
+		MarketHashName: "★ Karambit | Doppler (Factory New)",
+		Category:       "Knife",
+		BuyPriceUSD:    500,
+		SellPriceUSD:   600,
+		ProfitPercent:  20,
+		NetProfitUSD:   22,
+	}
+}
+
+func waitOutcome(t *testing.T, store *fakeStore) outcome {
+	t.Helper()
+	select {
+	case o := <-store.outcomes:
+		return o
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery recorded")
+		return outcome{}
+	}
+}
+
+func TestEngineSignsWebhooks(t *testing.T) {
+	r := &receiver{}
+	store := newFakeStore(testRule())
+	e := newTestEngine(store, startReceiver(t, r), 1, time.Millisecond)
+
+	item := &models.Item{ID: "item-1"}
+	e.ItemProcessed(item, testOpportunity(item.ID))
+
+	if o := waitOutcome(t, store); o.status != database.AlertDeliveryDelivered || o.attempts != 1 {
+		t.Fatalf("delivery = %+v, want delivered on the first attempt", o)
+	}
+
+	requests := r.received()
+	if len(requests) != 1 {
+		t.Fatalf("received %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if req.event != EventOpportunityMatched {
+		t.Errorf("event header = %q, want %q", req.event, EventOpportunityMatched)
+	}
+	if !VerifySignature("s3cret", req.timestamp, req.signature, req.body) {
+		t.Errorf("signature %q does not verify", req.signature)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.RuleID != "rule-1" || payload.Opportunity == nil || payload.Opportunity.ItemID != "item-1" {
+		t.Errorf("payload = %+v, want rule-1 and item-1", payload)
+	}
+}
+
+func TestEngineRetriesWithBackoff(t *testing.T) {
+	r := &receiver{statuses: []int{fasthttp.StatusInternalServerError, fasthttp.StatusBadGateway}}
+	store := newFakeStore(testRule())
+	delay := 50 * time.Millisecond
+	e := newTestEngine(store, startReceiver(t, r), 5, delay)
+
+	e.ItemProcessed(&models.Item{ID: "item-1"}, testOpportunity("item-1"))
+
+	if o := waitOutcome(t, store); o.status != database.AlertDeliveryDelivered || o.attempts != 3 {
+		t.Fatalf("delivery = %+v, want delivered on the third attempt", o)
+	}
+
+	requests := r.received()
+	if len(requests) != 3 {
+		t.Fatalf("received %d requests, want 3", len(requests))
+	}
+	// The delay doubles after each failure
+	if gap := requests[1].at.Sub(requests[0].at); gap < delay {
+		t.Errorf("first retry after %s, want at least %s", gap, delay)
+	}
+	if gap := requests[2].at.Sub(requests[1].at); gap < 2*delay {
+		t.Errorf("second retry after %s, want at least %s", gap, 2*delay)
+	}
+}
+
+func TestEngineGivesUpAfterMaxAttempts(t *testing.T) {
+	r := &receiver{statuses: []int{500, 500, 500}}
+	store := newFakeStore(testRule())
+	e := newTestEngine(store, startReceiver(t, r), 3, time.Millisecond)
+
+	e.ItemProcessed(&models.Item{ID: "item-1"}, testOpportunity("item-1"))
+
+	if o := waitOutcome(t, store); o.status != database.AlertDeliveryFailed || o.attempts != 3 {
+		t.Fatalf("delivery = %+v, want failed after 3 attempts", o)
+	}
+	if n := len(r.received()); n != 3 {
+		t.Errorf("received %d requests, want 3", n)
+	}
+
+	// The next scrape seeing the listing tries again
+	e.ItemProcessed(&models.Item{ID: "item-1"}, testOpportunity("item-1"))
+
+	if o := waitOutcome(t, store); o.status != database.AlertDeliveryDelivered || o.attempts != 1 {
+		t.Fatalf("retried delivery = %+v, want delivered on its first attempt", o)
+	}
+	if n := len(r.received()); n != 4 {
+		t.Errorf("received %d requests, want 4", n)
+	}
+}
+
+func TestEngineRetriesDroppedAlerts(t *testing.T) {
+	r := &receiver{}
+	store := newFakeStore(testRule())
+	e := NewEngine(store, startReceiver(t, r))
+	e.maxAttempts = 1
+
+	// Fill the queue before any worker runs
+	e.queue = make(chan delivery, 1)
+	e.queue <- delivery{rule: testRule(), opp: *testOpportunity("item-0")}
+
+	e.ItemProcessed(&models.Item{ID: "item-1"}, testOpportunity("item-1"))
+	if o := waitOutcome(t, store); o.itemID != "item-1" || o.status != database.AlertDeliveryFailed || o.attempts != 0 {
+		t.Fatalf("delivery = %+v, want item-1 failed without attempts", o)
+	}
+
+	e.Start(1)
+	if o := waitOutcome(t, store); o.itemID != "item-0" {
+		t.Fatalf("delivery = %+v, want the queued item-0", o)
+	}
+	e.ItemProcessed(&models.Item{ID: "item-1"}, testOpportunity("item-1"))
+	if o := waitOutcome(t, store); o.status != database.AlertDeliveryDelivered {
+		t.Fatalf("delivery = %+v, want delivered once the queue has room", o)
+	}
+}
+
+func TestEngineAlertsOncePerListing(t *testing.T) {
+	r := &receiver{}
+	store := newFakeStore(testRule())
+	e := newTestEngine(store, startReceiver(t, r), 1, time.Millisecond)
+
+	// The same listing seen again in later scrapes, then a new listing
+	e.ItemProcessed(&models.Item{ID: "item-1"}, testOpportunity("item-1"))
+	e.ItemProcessed(&models.Item{ID: "item-1"}, testOpportunity("item-1"))
+	e.ItemProcessed(&models.Item{ID: "item-2"}, testOpportunity("item-2"))
+
+	delivered := map[string]int{}
+	for i := 0; i < 2; i++ {
+		delivered[waitOutcome(t, store).itemID]++
+	}
+	select {
+	case o := <-store.outcomes:
+		t.Fatalf("unexpected extra delivery %+v", o)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if delivered["item-1"] != 1 || delivered["item-2"] != 1 {
+		t.Errorf("deliveries = %v, want one per listing", delivered)
+	}
+	if n := len(r.received()); n != 2 {
+		t.Errorf("received %d requests, want 2", n)
+	}
+}
+
+func TestEngineSkipsNonMatching(t *testing.T) {
+	r := &receiver{}
+	store := newFakeStore(testRule())
+	e := newTestEngine(store, startReceiver(t, r), 1, time.Millisecond)
+
+	rifle := testOpportunity("item-1")
+	rifle.Category = "Rifle"
+	e.ItemProcessed(&models.Item{ID: "item-1"}, rifle)
+	e.ItemProcessed(&models.Item{ID: "item-2"}, nil)
+
+	select {
+	case o := <-store.outcomes:
+		t.Fatalf("unexpected delivery %+v", o)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if len(store.claimed) != 0 {
+		t.Errorf("claimed %v, want nothing", store.claimed)
+	}
+}
+
+func TestEngineAppliesOwnerFees(t *testing.T) {
+	owner := "user-1"
+	rule := testRule()
+	rule.UserID = &owner
+	rule.MinNetProfitUSD = 30
+
+	// Net of the default 13% fee the listing makes $22, under the rule's
+	// minimum, but the owner sells at a 5% fee
+	fee := 5.0
+	store := newFakeStore(rule)
+	store.users[owner] = &models.User{ID: owner, Settings: models.UserSettings{SteamSaleFeePercent: &fee}}
+	r := &receiver{}
+	e := newTestEngine(store, startReceiver(t, r), 1, time.Millisecond)
+
+	opp := testOpportunity("item-1")
+	opp.Direction = arbitrage.DirectionForward
+	e.ItemProcessed(&models.Item{ID: "item-1"}, opp)
+
+	if o := waitOutcome(t, store); o.status != database.AlertDeliveryDelivered {
+		t.Fatalf("delivery = %+v, want delivered", o)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(r.received()[0].body, &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if got := payload.Opportunity.SaleFeeUSD; got != 30 {
+		t.Errorf("sale fee = %.2f, want 30.00 at the owner's 5%%", got)
+	}
+	if got := payload.Opportunity.NetProfitUSD; got != 70 {
+		t.Errorf("net profit = %.2f, want 70.00", got)
+	}
+}
@@ -0,0 +1,61 @@
+package alerts
+
+import (
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// Matches reports whether an opportunity satisfies every condition of a rule
+func Matches(rule *models.AlertRule, opp *arbitrage.Opportunity) bool {
+	if opp.NetProfitUSD < rule.MinNetProfitUSD {
+		return false
+	}
+	if opp.ProfitPercent < rule.MinProfitPercent && opp.ProfitWithStickersPercent < rule.MinProfitPercent {
+		return false
+	}
+	if rule.MaxBuyPriceUSD > 0 && opp.BuyPriceUSD > rule.MaxBuyPriceUSD {
+		return false
+	}
+
+	if len(rule.Categories) > 0 && !containsFold(rule.Categories, opp.Category) {
+		return false
+	}
+
+	if rule.MinFloat != nil || rule.MaxFloat != nil {
+		if opp.Float == nil {
+			return false
+		}
+		if rule.MinFloat != nil && *opp.Float < *rule.MinFloat {
+			return false
+		}
+		if rule.MaxFloat != nil && *opp.Float > *rule.MaxFloat {
+			return false
+		}
+	}
+
+	if len(rule.Skins) > 0 {
+		name := catalog.ParseMarketHashName(opp.MarketHashName)
+		baseName := name.Weapon
+		if name.Finish != "" {
+			baseName += " | " + name.Finish
+		}
+		if !containsFold(rule.Skins, opp.MarketHashName) && !containsFold(rule.Skins, baseName) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsFold checks if a string is in a slice, ignoring case
+func containsFold(slice []string, s string) bool {
+	for _, item := range slice {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package alerts
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+)
+
+// Headers set on every webhook request
+const (
+	SignatureHeader = "X-Signature-256"
+	TimestampHeader = "X-Alert-Timestamp"
+	EventHeader     = "X-Alert-Event"
+)
+
+// Webhook events
+const (
+	EventOpportunityMatched = "opportunity.matched"
+	EventTest               = "test"
+)
+
+// Payload is the JSON body POSTed to a rule's webhook URL
+type Payload struct {
+	Event       string                 `json:"event"`
+	RuleID      string                 `json:"rule_id"`
+	RuleName    string                 `json:"rule_name"`
+	Opportunity *arbitrage.Opportunity `json:"opportunity,omitempty"`
+	SentAt      time.Time              `json:"sent_at"`
+}
+
+// GenerateSecret returns a random secret to sign a rule's webhooks with: 32
+// random bytes in hex
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign computes the signature of a webhook body: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the rule secret, prefixed with "sha256="
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a received signature header against the body and
+// timestamp header, as a receiver of our webhooks would
+func VerifySignature(secret, timestampHeader, signatureHeader string, body []byte) bool {
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return false
+	}
+	expected := Sign(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signatureHeader))
+}
@@ -0,0 +1,46 @@
+package alerts
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"event":"test"}`)
+	timestamp := int64(1700000000)
+
+	signature := Sign("s3cret", timestamp, body)
+	// HMAC-SHA256 of `1700000000.{"event":"test"}` keyed with "s3cret"
+	want := "sha256=1c5b24400e91c3c2a54a5fc594c52fc115eb8e1d8d6b200dc40d90fa6ef6e273"
+	if signature != want {
+		t.Fatalf("Sign() = %q, want %q", signature, want)
+	}
+
+	ts := strconv.FormatInt(timestamp, 10)
+	if !VerifySignature("s3cret", ts, signature, body) {
+		t.Errorf("VerifySignature rejected a valid signature")
+	}
+	if VerifySignature("other", ts, signature, body) {
+		t.Errorf("VerifySignature accepted the wrong secret")
+	}
+	if VerifySignature("s3cret", ts, signature, []byte(`{"event":"tampered"}`)) {
+		t.Errorf("VerifySignature accepted a tampered body")
+	}
+	if VerifySignature("s3cret", "1700000001", signature, body) {
+		t.Errorf("VerifySignature accepted a different timestamp")
+	}
+	if VerifySignature("s3cret", "not-a-number", signature, body) {
+		t.Errorf("VerifySignature accepted an invalid timestamp")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if len(a) != 64 || a == b {
+		t.Errorf("GenerateSecret() = %q then %q, want distinct 64 character secrets", a, b)
+	}
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/mswatii/cs2-arbitrage/internal/alerts"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/internal/router"
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
+	"github.com/valyala/fasthttp"
+)
+
+// handleAlertRules lists the alert rules the request may manage
+func (h *Handler) handleAlertRules(ctx *fasthttp.RequestCtx) {
+	userID, ok := ownerID(ctx)
+	if !ok {
+		return
+	}
+
+	rules, err := h.db.GetAlertRules(false, userID)
+	if err != nil {
+		internalError(ctx, "Failed to get alert rules", err)
+		return
+	}
+
+	// Secrets are write-only
+	for i := range rules {
+		rules[i].Secret = ""
+	}
+
+	response := map[string]interface{}{
+		"rules": rules,
+		"count": len(rules),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleCreateAlertRule creates an alert rule from a JSON body, owned by the
+// signed in user or shared when created by an admin. Without a secret in the
+// body one is generated; either way it's returned once, here.
+func (h *Handler) handleCreateAlertRule(ctx *fasthttp.RequestCtx) {
+	userID, ok := ownerID(ctx)
+	if !ok {
+		return
+	}
+
+	var rule models.AlertRule
+	rule.Enabled = true
+	if err := json.Unmarshal(ctx.PostBody(), &rule); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid alert rule: %v", err))
+		return
+	}
+	if err := validateAlertRule(&rule, h.webhooks); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, err.Error())
+		return
+	}
+
+	if userID != "" {
+		rule.UserID = &userID
+	}
+	if rule.Secret == "" {
+		secret, err := alerts.GenerateSecret()
+		if err != nil {
+			internalError(ctx, "Failed to create alert rule", err)
+			return
+		}
+		rule.Secret = secret
+	}
+
+	id, err := h.db.InsertAlertRule(&rule)
+	if err != nil {
+		internalError(ctx, "Failed to create alert rule", err)
+		return
+	}
+	rule.ID = id // The secret is only ever shown here
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(rule)
+}
+
+// handleDeleteAlertRule deletes the alert rule given by /{id}
+func (h *Handler) handleDeleteAlertRule(ctx *fasthttp.RequestCtx) {
+	userID, ok := ownerID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.db.DeleteAlertRule(router.Param(ctx, "id"), userID); err != nil {
+		writeDBError(ctx, "Failed to delete alert rule", err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// handleAlertDeliveries lists recent webhook deliveries, optionally for a
+// single rule given by ?rule_id=, up to ?limit= (default 100, at most 1000)
+func (h *Handler) handleAlertDeliveries(ctx *fasthttp.RequestCtx) {
+	ruleID := string(ctx.QueryArgs().Peek("rule_id"))
+	limit := 100
+	if limitStr := string(ctx.QueryArgs().Peek("limit")); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	deliveries, err := h.db.GetAlertDeliveries(ruleID, limit)
+	if err != nil {
+		internalError(ctx, "Failed to get alert deliveries", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleAlertTest sends a test payload to the webhook of the rule given by
+// /{id}
+func (h *Handler) handleAlertTest(ctx *fasthttp.RequestCtx) {
+	userID, ok := ownerID(ctx)
+	if !ok {
+		return
+	}
+
+	id := router.Param(ctx, "id")
+	rules, err := h.db.GetAlertRules(false, userID)
+	if err != nil {
+		internalError(ctx, "Failed to get alert rules", err)
+		return
+	}
+
+	var rule *models.AlertRule
+	for i := range rules {
+		if rules[i].ID == id {
+			rule = &rules[i]
+			break
+		}
+	}
+	if rule == nil {
+		writeError(ctx, fasthttp.StatusNotFound, "Alert rule not found")
+		return
+	}
+
+	// The receiver's error may describe hosts the caller shouldn't learn about
+	if err := h.alerts.SendTest(rule); err != nil {
+		logf(ctx, "Test webhook for rule %s failed: %v", rule.ID, err)
+		writeError(ctx, fasthttp.StatusBadGateway, "Test webhook failed")
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(map[string]interface{}{"message": "Test webhook delivered"})
+}
+
+// validateAlertRule checks the fields a rule needs before it is stored,
+// including that its webhook points at a host the policy allows
+func validateAlertRule(rule *models.AlertRule, webhooks httputil.HostPolicy) error {
+	if rule.Name == "" {
+		return fmt.Errorf("Alert rule needs a name")
+	}
+	parsed, err := url.Parse(rule.WebhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("Alert rule needs an http(s) webhook_url")
+	}
+	// Webhooks are sent from the server, so they must not reach internal
+	// services unless allowed; the alert engine's client checks again on
+	// every connection
+	if _, err := webhooks.Resolve(parsed.Hostname()); err != nil {
+		return fmt.Errorf("webhook_url must point to a public or allowed host")
+	}
+	if rule.MinFloat != nil && rule.MaxFloat != nil && *rule.MinFloat > *rule.MaxFloat {
+		return fmt.Errorf("min_float must not exceed max_float")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/valyala/fasthttp"
+)
+
+// handleAnomalies returns listings priced far below their skin's recent
+// prices on the same marketplace. Query params: min_confidence (0 to 1),
+// typos (true to only return likely dropped-zero typos) and limit.
+func (h *Handler) handleAnomalies(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+
+	filter := arbitrage.AnomalyFilter{
+		MinConfidence: parseFloatArg(ctx, "min_confidence", 0),
+		TyposOnly:     string(args.Peek("typos")) == "true",
+	}
+	if filter.MinConfidence < 0 || filter.MinConfidence > 1 {
+		writeError(ctx, fasthttp.StatusBadRequest, "min_confidence must be between 0 and 1")
+		return
+	}
+	if limit, err := strconv.Atoi(string(args.Peek("limit"))); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+
+	anomalies, err := arbitrage.FindAnomalies(h.db, filter)
+	if err != nil {
+		internalError(ctx, "Failed to find anomalies", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"anomalies": anomalies,
+		"count":     len(anomalies),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
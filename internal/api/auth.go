@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/internal/auth"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/internal/router"
+	"github.com/valyala/fasthttp"
+)
+
+// principalKey is the user value holding the request's principal
+const principalKey = "principal"
+
+// requireScope returns middleware rejecting requests without credentials
+// granting a scope with a 401 or 403 response, and storing the principal of
+// those with them after charging it the route's rate limit
+func (h *Handler) requireScope(scope string) router.Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			principal, err := h.auth.Authenticate(ctx)
+			if err != nil {
+				internalError(ctx, "Failed to authenticate", err)
+				return
+			}
+			if principal == nil {
+				ctx.Response.Header.Set("WWW-Authenticate", `Bearer realm="cs2-arbitrage"`)
+				writeError(ctx, fasthttp.StatusUnauthorized, "Missing or invalid credentials")
+				return
+			}
+			if !principal.Has(scope) {
+				writeError(ctx, fasthttp.StatusForbidden, fmt.Sprintf("Requires the %s scope", scope))
+				return
+			}
+			if !h.allow(ctx, principal.Key()) {
+				return
+			}
+
+			ctx.SetUserValue(principalKey, principal)
+			next(ctx)
+		}
+	}
+}
+
+// principal returns the authenticated principal of a request, or nil
+func principal(ctx *fasthttp.RequestCtx) *auth.Principal {
+	p, _ := ctx.UserValue(principalKey).(*auth.Principal)
+	return p
+}
+
+// handleLogin exchanges a username and password, or an API key, for a web
+// UI session cookie
+func (h *Handler) handleLogin(ctx *fasthttp.RequestCtx) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		APIKey   string `json:"api_key"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil || (req.APIKey == "" && req.Username == "") {
+		writeError(ctx, fasthttp.StatusBadRequest, "Missing username and password or api_key")
+		return
+	}
+
+	var p *auth.Principal
+	var err error
+	if req.Username != "" {
+		p, err = h.auth.LoginPassword(ctx, req.Username, req.Password)
+	} else {
+		p, err = h.auth.Login(ctx, req.APIKey)
+	}
+	if err == auth.ErrInvalidKey || err == auth.ErrInvalidPassword {
+		writeError(ctx, fasthttp.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		internalError(ctx, "Failed to sign in", err)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(p)
+}
+
+// handleLogout ends the web UI session
+func (h *Handler) handleLogout(ctx *fasthttp.RequestCtx) {
+	if err := h.auth.Logout(ctx); err != nil {
+		internalError(ctx, "Failed to sign out", err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// handleMe returns who the request is authenticated as
+func (h *Handler) handleMe(ctx *fasthttp.RequestCtx) {
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(principal(ctx))
+}
+
+// handleAPIKeys lists API keys
+func (h *Handler) handleAPIKeys(ctx *fasthttp.RequestCtx) {
+	keys, err := h.db.GetAPIKeys()
+	if err != nil {
+		internalError(ctx, "Failed to get API keys", err)
+		return
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+
+	response := map[string]interface{}{
+		"keys":  keys,
+		"count": len(keys),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleCreateAPIKey creates an API key from {"name", "scopes", "user_id"},
+// returning the key once. A key with a user_id acts for that user.
+func (h *Handler) handleCreateAPIKey(ctx *fasthttp.RequestCtx) {
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+		UserID *string  `json:"user_id"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid API key request: %v", err))
+		return
+	}
+	if req.Name == "" || !auth.ValidScopes(req.Scopes) {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("name is required and scopes must be some of %s", strings.Join(auth.Scopes, ", ")))
+		return
+	}
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		internalError(ctx, "Failed to create API key", err)
+		return
+	}
+	prefix := key[:len(auth.KeyPrefix)+6]
+	id, err := h.db.InsertAPIKey(req.UserID, req.Name, auth.HashToken(key), prefix, req.Scopes)
+	if err != nil {
+		internalError(ctx, "Failed to create API key", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"id":      id,
+		"name":    req.Name,
+		"prefix":  prefix,
+		"scopes":  req.Scopes,
+		"user_id": req.UserID,
+		"key":     key, // Only ever shown here
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleRevokeAPIKey revokes the API key given by /{id}
+func (h *Handler) handleRevokeAPIKey(ctx *fasthttp.RequestCtx) {
+	if err := h.db.RevokeAPIKey(router.Param(ctx, "id")); err != nil {
+		writeDBError(ctx, "Failed to revoke API key", err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
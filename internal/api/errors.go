@@ -0,0 +1,142 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"runtime/debug"
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/valyala/fasthttp"
+)
+
+// Error codes sent in error responses, one per status
+var errorCodes = map[int]string{
+	fasthttp.StatusBadRequest:          "bad_request",
+	fasthttp.StatusUnauthorized:        "unauthorized",
+	fasthttp.StatusForbidden:           "forbidden",
+	fasthttp.StatusNotFound:            "not_found",
+	fasthttp.StatusMethodNotAllowed:    "method_not_allowed",
+	fasthttp.StatusConflict:            "conflict",
+	fasthttp.StatusTooManyRequests:     "rate_limited",
+	fasthttp.StatusInternalServerError: "internal_error",
+	fasthttp.StatusBadGateway:          "upstream_error",
+	fasthttp.StatusServiceUnavailable:  "unavailable",
+}
+
+// RequestIDHeader carries the ID of a request, sent back with every response
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the user value holding the request's ID
+const requestIDKey = "request_id"
+
+// validRequestID matches request IDs accepted from clients
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// errorResponse is the body of every error response
+type errorResponse struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	RequestID string      `json:"request_id"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// writeError writes an error response with a message safe to show clients
+func writeError(ctx *fasthttp.RequestCtx, status int, message string) {
+	writeErrorDetails(ctx, status, message, nil)
+}
+
+// writeErrorDetails writes an error response with structured details, such
+// as which fields were invalid
+func writeErrorDetails(ctx *fasthttp.RequestCtx, status int, message string, details interface{}) {
+	code, ok := errorCodes[status]
+	if !ok {
+		code = "error"
+	}
+
+	ctx.ResetBody()
+	ctx.SetStatusCode(status)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(errorResponse{
+		Code:      code,
+		Message:   message,
+		RequestID: requestID(ctx),
+		Details:   details,
+	})
+}
+
+// internalError logs an unexpected error under the request's ID and writes
+// a 500 response carrying only the message, so internals don't leak
+func internalError(ctx *fasthttp.RequestCtx, message string, err error) {
+	logf(ctx, "%s: %v", message, err)
+	writeError(ctx, fasthttp.StatusInternalServerError, message)
+}
+
+// writeDBError writes a 404 response when a record doesn't exist and a 500
+// response otherwise, logging the error under the request's ID either way
+func writeDBError(ctx *fasthttp.RequestCtx, message string, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		logf(ctx, "%s: %v", message, err)
+		writeError(ctx, fasthttp.StatusNotFound, message)
+		return
+	}
+	internalError(ctx, message, err)
+}
+
+// invalidMessage returns message followed by the reason a validation error
+// gives, when err wraps sentinel as "sentinel: reason". Other errors may
+// carry internals, so only message is returned for them.
+func invalidMessage(message string, err, sentinel error) string {
+	if reason, ok := strings.CutPrefix(err.Error(), sentinel.Error()+": "); ok && reason != "" {
+		return message + ": " + reason
+	}
+	return message
+}
+
+// logf logs a message prefixed with the request's ID
+func logf(ctx *fasthttp.RequestCtx, format string, args ...interface{}) {
+	log.Printf("[%s] %s", requestID(ctx), fmt.Sprintf(format, args...))
+}
+
+// requestID returns the ID of a request
+func requestID(ctx *fasthttp.RequestCtx) string {
+	id, _ := ctx.UserValue(requestIDKey).(string)
+	return id
+}
+
+// withRequestID is middleware giving every request an ID, taken from the
+// X-Request-ID header when the client sent a usable one, and returning it in
+// the response header
+func withRequestID(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		id := string(ctx.Request.Header.Peek(RequestIDHeader))
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+
+		ctx.SetUserValue(requestIDKey, id)
+		ctx.Response.Header.Set(RequestIDHeader, id)
+		next(ctx)
+	}
+}
+
+// recoverPanic is middleware turning a panicking handler into a logged JSON
+// 500 response rather than a dropped connection
+func recoverPanic(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		defer func() {
+			if r := recover(); r != nil {
+				logf(ctx, "Panic serving %s %s: %v\n%s", ctx.Method(), ctx.Path(), r, debug.Stack())
+				writeError(ctx, fasthttp.StatusInternalServerError, "Internal server error")
+			}
+		}()
+		next(ctx)
+	}
+}
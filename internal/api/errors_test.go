@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mswatii/cs2-arbitrage/internal/auth"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/portfolio"
+	"github.com/valyala/fasthttp"
+)
+
+// decodeError decodes an error response body
+func decodeError(t *testing.T, ctx *fasthttp.RequestCtx) errorResponse {
+	t.Helper()
+	if ct := string(ctx.Response.Header.ContentType()); ct != "application/json" {
+		t.Errorf("content type = %q, want application/json", ct)
+	}
+	var body errorResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &body); err != nil {
+		t.Fatalf("error body %s: %v", ctx.Response.Body(), err)
+	}
+	return body
+}
+
+func TestWithRequestID(t *testing.T) {
+	tests := []struct {
+		header string
+		keep   bool
+	}{
+		{"client-id.1_A", true},
+		{"", false},
+		{"has spaces", false},
+		{"<script>", false},
+		{strings.Repeat("a", 65), false},
+	}
+
+	for _, tt := range tests {
+		var seen string
+		handler := withRequestID(func(ctx *fasthttp.RequestCtx) { seen = requestID(ctx) })
+
+		ctx := &fasthttp.RequestCtx{}
+		if tt.header != "" {
+			ctx.Request.Header.Set(RequestIDHeader, tt.header)
+		}
+		handler(ctx)
+
+		sent := string(ctx.Response.Header.Peek(RequestIDHeader))
+		if seen == "" || sent != seen {
+			t.Errorf("%q: handler saw %q, response carries %q", tt.header, seen, sent)
+		}
+		if (seen == tt.header) != tt.keep {
+			t.Errorf("%q: request ID = %q, want kept %v", tt.header, seen, tt.keep)
+		}
+		if !tt.keep && len(seen) != 16 {
+			t.Errorf("%q: generated ID %q, want 16 hex characters", tt.header, seen)
+		}
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	handler := withRequestID(recoverPanic(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyString("partial")
+		panic("boom")
+	}))
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(RequestIDHeader, "req-1")
+	handler(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", ctx.Response.StatusCode())
+	}
+	body := decodeError(t, ctx)
+	if body.Code != "internal_error" || body.RequestID != "req-1" || strings.Contains(body.Message, "boom") {
+		t.Errorf("body = %+v", body)
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		write   func(ctx *fasthttp.RequestCtx)
+		status  int
+		code    string
+		message string
+	}{
+		{"client error", func(ctx *fasthttp.RequestCtx) {
+			writeError(ctx, fasthttp.StatusBadRequest, "Missing name parameter")
+		}, 400, "bad_request", "Missing name parameter"},
+		{"unknown status", func(ctx *fasthttp.RequestCtx) {
+			writeError(ctx, fasthttp.StatusTeapot, "Short and stout")
+		}, 418, "error", "Short and stout"},
+		{"internal error", func(ctx *fasthttp.RequestCtx) {
+			internalError(ctx, "Failed to load", errors.New("connection refused to 10.0.0.5"))
+		}, 500, "internal_error", "Failed to load"},
+		{"missing record", func(ctx *fasthttp.RequestCtx) {
+			writeDBError(ctx, "Failed to revoke API key", fmt.Errorf("API key 1 %w", database.ErrNotFound))
+		}, 404, "not_found", "Failed to revoke API key"},
+		{"database failure", func(ctx *fasthttp.RequestCtx) {
+			writeDBError(ctx, "Failed to revoke API key", errors.New("deadlock detected"))
+		}, 500, "internal_error", "Failed to revoke API key"},
+		{"invalid portfolio request", func(ctx *fasthttp.RequestCtx) {
+			writePortfolioError(ctx, "Failed to record sale", fmt.Errorf("%w: item is already sold", portfolio.ErrInvalid))
+		}, 400, "bad_request", "Failed to record sale: item is already sold"},
+		{"missing portfolio item", func(ctx *fasthttp.RequestCtx) {
+			writePortfolioError(ctx, "Failed to record sale", fmt.Errorf("loading item 7: %w", portfolio.ErrNotFound))
+		}, 404, "not_found", "Failed to record sale"},
+		{"wrapped invalid portfolio request", func(ctx *fasthttp.RequestCtx) {
+			writePortfolioError(ctx, "Failed to record sale", fmt.Errorf("query at 10.0.0.5: %w", portfolio.ErrInvalid))
+		}, 400, "bad_request", "Failed to record sale"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.SetUserValue(requestIDKey, "req-1")
+			ctx.SetBodyString("earlier output")
+			tt.write(ctx)
+
+			if ctx.Response.StatusCode() != tt.status {
+				t.Errorf("status = %d, want %d", ctx.Response.StatusCode(), tt.status)
+			}
+			body := decodeError(t, ctx)
+			if body.Code != tt.code || body.Message != tt.message || body.RequestID != "req-1" {
+				t.Errorf("body = %+v, want %s %q", body, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestUnmatchedRoutes(t *testing.T) {
+	h := newTestHandler(t)
+
+	ctx := serve(h, "GET", "/api/missing", "", "")
+	if body := decodeError(t, ctx); ctx.Response.StatusCode() != 404 || body.Code != "not_found" || body.RequestID == "" {
+		t.Errorf("unknown route = %d %+v", ctx.Response.StatusCode(), body)
+	}
+
+	ctx = serve(h, "DELETE", "/api/health", "", "")
+	body := decodeError(t, ctx)
+	if ctx.Response.StatusCode() != 405 || body.Code != "method_not_allowed" || !strings.Contains(body.Message, "GET, HEAD, OPTIONS") {
+		t.Errorf("wrong method = %d %+v", ctx.Response.StatusCode(), body)
+	}
+
+	// Deletes name their resource in the path only
+	ctx = serve(h, "DELETE", "/api/users?id=1", auth.ScopeAdmin, "")
+	body = decodeError(t, ctx)
+	if ctx.Response.StatusCode() != 405 || !strings.Contains(body.Message, "GET, HEAD, OPTIONS, POST") {
+		t.Errorf("delete without a path ID = %d %+v", ctx.Response.StatusCode(), body)
+	}
+}
+
+func TestMalformedPathIDs(t *testing.T) {
+	h := newTestHandler(t)
+
+	// IDs that aren't UUIDs match no route, so they never reach the database
+	for _, tt := range []struct{ method, uri string }{
+		{"GET", "/api/physical-items/abc"},
+		{"DELETE", "/api/me/presets/abc"},
+		{"DELETE", "/api/me/watchlist/1"},
+		{"DELETE", "/api/alerts/rules/abc"},
+		{"POST", "/api/alerts/rules/abc/test"},
+		{"DELETE", "/api/portfolio/abc"},
+		{"DELETE", "/api/auth/keys/abc"},
+		{"DELETE", "/api/users/abc"},
+	} {
+		ctx := serve(h, tt.method, tt.uri, auth.ScopeAdmin, "")
+		if body := decodeError(t, ctx); ctx.Response.StatusCode() != 404 || body.Code != "not_found" {
+			t.Errorf("%s %s = %d %+v, want 404", tt.method, tt.uri, ctx.Response.StatusCode(), body)
+		}
+	}
+}
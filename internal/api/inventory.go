@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mswatii/cs2-arbitrage/internal/portfolio"
+	"github.com/mswatii/cs2-arbitrage/internal/steam"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
+	"github.com/valyala/fasthttp"
+)
+
+// handleInventoryValue values the inventory of a public profile given by
+// ?steamid=
+func (h *Handler) handleInventoryValue(ctx *fasthttp.RequestCtx) {
+	steamID := string(ctx.QueryArgs().Peek("steamid"))
+	if steamID == "" {
+		writeError(ctx, fasthttp.StatusBadRequest, "Missing steamid parameter, or POST the inventory JSON")
+		return
+	}
+	if !steam.ValidSteamID64(steamID) {
+		writeError(ctx, fasthttp.StatusBadRequest, "steamid must be a 17-digit SteamID64")
+		return
+	}
+	baseURL := config.String("STEAM_COMMUNITY_URL", steam.DefaultCommunityURL)
+	assets, err := steam.FetchInventory(httputil.DefaultClient, baseURL, steamID)
+	if err != nil {
+		logf(ctx, "Failed to fetch inventory of %s: %v", steamID, err)
+		writeError(ctx, fasthttp.StatusBadGateway, "Failed to fetch inventory from Steam")
+		return
+	}
+
+	h.writeInventoryValue(ctx, assets)
+}
+
+// handlePostedInventoryValue values a Steam inventory POSTed as public
+// inventory JSON
+func (h *Handler) handlePostedInventoryValue(ctx *fasthttp.RequestCtx) {
+	assets, err := steam.ParseInventory(ctx.PostBody())
+	if err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid inventory: %v", err))
+		return
+	}
+
+	h.writeInventoryValue(ctx, assets)
+}
+
+// writeInventoryValue values inventory assets against current prices
+func (h *Handler) writeInventoryValue(ctx *fasthttp.RequestCtx, assets []steam.InventoryAsset) {
+	valuation, err := portfolio.ValueInventory(h.db, assets)
+	if err != nil {
+		internalError(ctx, "Failed to value inventory", err)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(valuation)
+}
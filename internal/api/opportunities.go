@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/valyala/fasthttp"
+)
+
+// handleOpportunityHistory lists stored opportunities with their lifecycle.
+// Query params: status (open or closed), name, days and limit.
+func (h *Handler) handleOpportunityHistory(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+
+	filter := database.OpportunityHistoryFilter{
+		Status:         string(args.Peek("status")),
+		MarketHashName: string(args.Peek("name")),
+		Limit:          200,
+	}
+	if filter.Status != "" && filter.Status != "open" && filter.Status != "closed" {
+		writeError(ctx, fasthttp.StatusBadRequest, "status must be open or closed")
+		return
+	}
+	if limit, err := strconv.Atoi(string(args.Peek("limit"))); err == nil && limit > 0 && limit <= 1000 {
+		filter.Limit = limit
+	}
+	days := parseFloatArg(ctx, "days", 30)
+	filter.Since = time.Now().Add(-time.Duration(days * float64(24*time.Hour)))
+
+	records, err := h.db.GetOpportunityHistory(filter)
+	if err != nil {
+		internalError(ctx, "Failed to get opportunity history", err)
+		return
+	}
+
+	// Summarise how closed opportunities ended and how long they lasted
+	closeReasons := make(map[string]int)
+	var closedCount int
+	var totalDuration float64
+	for _, rec := range records {
+		if rec.ClosedAt == nil {
+			continue
+		}
+		closedCount++
+		closeReasons[rec.CloseReason]++
+		totalDuration += rec.DurationSeconds
+	}
+	var avgDuration float64
+	if closedCount > 0 {
+		avgDuration = totalDuration / float64(closedCount)
+	}
+
+	if records == nil {
+		records = []models.OpportunityRecord{}
+	}
+
+	response := map[string]interface{}{
+		"opportunities":            records,
+		"count":                    len(records),
+		"closed_count":             closedCount,
+		"close_reasons":            closeReasons,
+		"avg_closed_duration_secs": avgDuration,
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/relisting"
+	"github.com/mswatii/cs2-arbitrage/internal/router"
+	"github.com/valyala/fasthttp"
+)
+
+// handlePhysicalItems lists recently relisted items, up to limit (default
+// 50), or with ?item_id= returns the physical item behind that listing
+func (h *Handler) handlePhysicalItems(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	if itemID := string(args.Peek("item_id")); itemID != "" {
+		h.writePhysicalItem(ctx, "", itemID)
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(string(args.Peek("limit"))); err == nil && l > 0 {
+		limit = l
+	}
+
+	items, err := h.db.GetRelistedItems(limit)
+	if err != nil {
+		internalError(ctx, "Failed to get relisted items", err)
+		return
+	}
+	if items == nil {
+		items = []database.RelistedItem{}
+	}
+
+	response := map[string]interface{}{
+		"items": items,
+		"count": len(items),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handlePhysicalItem returns the physical item given by /{id}
+func (h *Handler) handlePhysicalItem(ctx *fasthttp.RequestCtx) {
+	h.writePhysicalItem(ctx, router.Param(ctx, "id"), "")
+}
+
+// writePhysicalItem writes the listings and price history of a physical
+// item, given by its ID or any of its listings, linking relistings under
+// new IDs
+func (h *Handler) writePhysicalItem(ctx *fasthttp.RequestCtx, id, itemID string) {
+	rec, err := h.db.GetPhysicalItem(id, itemID)
+	if err != nil {
+		internalError(ctx, "Failed to get physical item", err)
+		return
+	}
+	if rec == nil {
+		writeError(ctx, fasthttp.StatusNotFound, "Physical item not found")
+		return
+	}
+
+	response := map[string]interface{}{
+		"item":    rec,
+		"summary": relisting.Summarize(rec, time.Now()),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mswatii/cs2-arbitrage/internal/portfolio"
+	"github.com/mswatii/cs2-arbitrage/internal/router"
+	"github.com/valyala/fasthttp"
+)
+
+// handlePortfolio lists the portfolio with P&L, optionally filtered by
+// ?status=
+func (h *Handler) handlePortfolio(ctx *fasthttp.RequestCtx) {
+	items, err := portfolio.Items(h.db, string(ctx.QueryArgs().Peek("status")))
+	if err != nil {
+		internalError(ctx, "Failed to get portfolio", err)
+		return
+	}
+
+	summary, err := portfolio.Summarize(h.db, items)
+	if err != nil {
+		internalError(ctx, "Failed to value portfolio", err)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(summary)
+}
+
+// handleRecordPurchase records a purchase into the portfolio
+func (h *Handler) handleRecordPurchase(ctx *fasthttp.RequestCtx) {
+	var purchase portfolio.Purchase
+	if err := json.Unmarshal(ctx.PostBody(), &purchase); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid purchase: %v", err))
+		return
+	}
+
+	item, err := portfolio.RecordPurchase(h.db, purchase)
+	if err != nil {
+		writePortfolioError(ctx, "Failed to record purchase", err)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(item)
+}
+
+// handleDeletePortfolioItem deletes the portfolio item given by /{id}
+func (h *Handler) handleDeletePortfolioItem(ctx *fasthttp.RequestCtx) {
+	if err := h.db.DeletePortfolioItem(router.Param(ctx, "id")); err != nil {
+		writeDBError(ctx, "Failed to delete portfolio item", err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// handlePortfolioList marks a portfolio item as listed from a JSON body
+// {"id": ..., "price_usd": ...}
+func (h *Handler) handlePortfolioList(ctx *fasthttp.RequestCtx) {
+	var req struct {
+		ID       string  `json:"id"`
+		PriceUSD float64 `json:"price_usd"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid listing: %v", err))
+		return
+	}
+
+	item, err := portfolio.MarkListed(h.db, req.ID, req.PriceUSD)
+	if err != nil {
+		writePortfolioError(ctx, "Failed to mark item as listed", err)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(item)
+}
+
+// handlePortfolioSell records a sale from a JSON body
+// {"id": ..., "sale_price_usd": ..., "sale_fee_usd": ...}; the fee defaults
+// to the Steam sale fee
+func (h *Handler) handlePortfolioSell(ctx *fasthttp.RequestCtx) {
+	var req struct {
+		ID           string   `json:"id"`
+		SalePriceUSD float64  `json:"sale_price_usd"`
+		SaleFeeUSD   *float64 `json:"sale_fee_usd"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid sale: %v", err))
+		return
+	}
+
+	item, err := portfolio.MarkSold(h.db, req.ID, req.SalePriceUSD, req.SaleFeeUSD)
+	if err != nil {
+		writePortfolioError(ctx, "Failed to record sale", err)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(item)
+}
+
+// writePortfolioError maps portfolio errors to status codes, logging them
+// and telling clients only what was invalid about their request
+func writePortfolioError(ctx *fasthttp.RequestCtx, message string, err error) {
+	switch {
+	case errors.Is(err, portfolio.ErrNotFound):
+		logf(ctx, "%s: %v", message, err)
+		writeError(ctx, fasthttp.StatusNotFound, message)
+	case errors.Is(err, portfolio.ErrInvalid):
+		logf(ctx, "%s: %v", message, err)
+		writeError(ctx, fasthttp.StatusBadRequest, invalidMessage(message, err, portfolio.ErrInvalid))
+	default:
+		internalError(ctx, message, err)
+	}
+}
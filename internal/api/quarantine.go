@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/valyala/fasthttp"
+)
+
+// handleQuarantine lists scraped listings that failed validation, with a
+// count per rule. Query params: rule (e.g. wear_mismatch) and limit
+// (default 100).
+func (h *Handler) handleQuarantine(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+
+	limit := 100
+	if l, err := strconv.Atoi(string(args.Peek("limit"))); err == nil && l > 0 {
+		limit = l
+	}
+
+	items, err := h.db.GetQuarantinedItems(string(args.Peek("rule")), limit)
+	if err != nil {
+		internalError(ctx, "Failed to get quarantined items", err)
+		return
+	}
+	if items == nil {
+		items = []models.QuarantinedItem{}
+	}
+
+	counts, err := h.db.GetQuarantineCounts()
+	if err != nil {
+		internalError(ctx, "Failed to count quarantined items", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"items":   items,
+		"count":   len(items),
+		"by_rule": counts,
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
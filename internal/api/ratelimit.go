@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/ratelimit"
+	"github.com/mswatii/cs2-arbitrage/internal/router"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+	"github.com/valyala/fasthttp"
+)
+
+// defaultRouteLimits protect the routes that are expensive to serve or that
+// reach external sites. Refreshing scrapes csgoskin.ir, which blocks clients
+// hitting it too often.
+var defaultRouteLimits = map[string]ratelimit.Limit{
+	"/api/refresh":         {Requests: 2, Period: 10 * time.Minute},
+	"/api/catalog/refresh": {Requests: 6, Period: time.Hour},
+	"/api/arbitrage":       {Requests: 30, Period: time.Minute},
+	"/api/tradeups":        {Requests: 20, Period: time.Minute},
+	"/api/anomalies":       {Requests: 20, Period: time.Minute},
+	"/api/auth/login":      {Requests: 10, Period: 5 * time.Minute},
+}
+
+// rateLimits applies per-route request limits to each IP address and
+// authenticated principal
+type rateLimits struct {
+	limiter      *ratelimit.Limiter
+	enabled      bool
+	defaultLimit ratelimit.Limit
+	routes       map[string]ratelimit.Limit
+}
+
+// newRateLimits configures rate limits from RATE_LIMIT_ENABLED,
+// RATE_LIMIT_DEFAULT (e.g. "300/1m") and RATE_LIMIT_ROUTES, a comma
+// separated list of route=limit overrides such as "/api/refresh=1/15m"
+func newRateLimits() *rateLimits {
+	r := &rateLimits{
+		limiter:      ratelimit.NewLimiter(),
+		enabled:      config.Bool("RATE_LIMIT_ENABLED", true),
+		defaultLimit: ratelimit.Limit{Requests: 300, Period: time.Minute},
+		routes:       make(map[string]ratelimit.Limit, len(defaultRouteLimits)),
+	}
+	for route, limit := range defaultRouteLimits {
+		r.routes[route] = limit
+	}
+
+	if spec := config.String("RATE_LIMIT_DEFAULT", ""); spec != "" {
+		if limit, err := ratelimit.ParseLimit(spec); err != nil {
+			log.Printf("Warning: %v, using default %s", err, r.defaultLimit)
+		} else {
+			r.defaultLimit = limit
+		}
+	}
+
+	for _, entry := range strings.Split(config.String("RATE_LIMIT_ROUTES", ""), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			log.Printf("Warning: Invalid RATE_LIMIT_ROUTES entry %q, expected route=limit", entry)
+			continue
+		}
+		limit, err := ratelimit.ParseLimit(spec)
+		if err != nil {
+			log.Printf("Warning: %v for %s", err, route)
+			continue
+		}
+		r.routes[strings.TrimSpace(route)] = limit
+	}
+
+	if r.enabled {
+		r.limiter.StartCleanup(10*time.Minute, r.longestPeriod())
+	} else {
+		log.Printf("Warning: API rate limiting is disabled (RATE_LIMIT_ENABLED=false)")
+	}
+	return r
+}
+
+// longestPeriod returns the longest period of any configured limit. A bucket
+// refills completely within its limit's period, so one unused for that long
+// can be forgotten whatever route it belongs to.
+func (r *rateLimits) longestPeriod() time.Duration {
+	longest := r.defaultLimit.Period
+	for _, limit := range r.routes {
+		longest = max(longest, limit.Period)
+	}
+	return longest
+}
+
+// rateLimit is middleware taking each request from its IP address's
+// allowance for the route. Unverified credentials can't pick the bucket a
+// request counts against; requireScope charges a second bucket for the
+// principal once its credentials are checked.
+func (h *Handler) rateLimit(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !h.allow(ctx, "ip:"+ctx.RemoteIP().String()) {
+			return
+		}
+		next(ctx)
+	}
+}
+
+// allow takes a request from a client's allowance for its route, answering
+// 429 with Retry-After and returning false when it is used up. Routes are
+// limited by pattern; those without their own limit share the default. The
+// rate limit headers report the tightest of the buckets charged.
+func (h *Handler) allow(ctx *fasthttp.RequestCtx, client string) bool {
+	if !h.limits.enabled {
+		return true
+	}
+
+	route := router.Pattern(ctx)
+	limit, ok := h.limits.routes[route]
+	if !ok {
+		limit, route = h.limits.defaultLimit, "*"
+	}
+
+	result := h.limits.limiter.Allow(route+" "+client, limit)
+	remaining, err := strconv.Atoi(string(ctx.Response.Header.Peek("X-RateLimit-Remaining")))
+	if err != nil || result.Remaining < remaining {
+		ctx.Response.Header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Response.Header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	}
+	if !result.Allowed {
+		retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+		ctx.Response.Header.Set("Retry-After", strconv.Itoa(retryAfter))
+		writeErrorDetails(ctx, fasthttp.StatusTooManyRequests,
+			fmt.Sprintf("Too many requests, retry in %d seconds", retryAfter),
+			map[string]interface{}{"limit": limit.String(), "retry_after": retryAfter})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLongestPeriod(t *testing.T) {
+	t.Setenv("RATE_LIMIT_ENABLED", "false")
+	if got := newRateLimits().longestPeriod(); got != time.Hour {
+		t.Errorf("longest default period = %s, want 1h", got)
+	}
+
+	t.Setenv("RATE_LIMIT_ROUTES", "/api/refresh=1/6h")
+	if got := newRateLimits().longestPeriod(); got != 6*time.Hour {
+		t.Errorf("longest period with an override = %s, want 6h", got)
+	}
+
+	t.Setenv("RATE_LIMIT_DEFAULT", "1000/24h")
+	if got := newRateLimits().longestPeriod(); got != 24*time.Hour {
+		t.Errorf("longest period with a default override = %s, want 24h", got)
+	}
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	h := newTestHandler(t)
+	t.Setenv("RATE_LIMIT_ENABLED", "true")
+	t.Setenv("RATE_LIMIT_ROUTES", "/api/health=2/1h")
+	h.limits = newRateLimits()
+
+	for i, remaining := range []string{"1", "0"} {
+		ctx := serve(h, "GET", "/api/health", "", "")
+		if ctx.Response.StatusCode() != 200 || string(ctx.Response.Header.Peek("X-RateLimit-Remaining")) != remaining {
+			t.Fatalf("request %d = %d with %q remaining", i+1, ctx.Response.StatusCode(), ctx.Response.Header.Peek("X-RateLimit-Remaining"))
+		}
+	}
+
+	// The next token comes half an hour after the bucket ran dry
+	ctx := serve(h, "GET", "/api/health", "", "")
+	if ctx.Response.StatusCode() != 429 || string(ctx.Response.Header.Peek("Retry-After")) != "1800" {
+		t.Fatalf("limited request = %d, Retry-After %q, want 429 after 1800", ctx.Response.StatusCode(), ctx.Response.Header.Peek("Retry-After"))
+	}
+	var body errorResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &body); err != nil || body.Code != "rate_limited" {
+		t.Errorf("limited response = %s, %v", ctx.Response.Body(), err)
+	}
+
+	// Other routes have their own allowance
+	if ctx := serve(h, "GET", "/api/auth/me", "read", ""); ctx.Response.StatusCode() != 200 {
+		t.Errorf("other route = %d, want 200", ctx.Response.StatusCode())
+	}
+}
@@ -2,57 +2,149 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"log"
 	"time"
 
+	"github.com/mswatii/cs2-arbitrage/internal/alerts"
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/auth"
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
 	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/internal/router"
 	"github.com/mswatii/cs2-arbitrage/internal/scraper"
+	"github.com/mswatii/cs2-arbitrage/internal/stream"
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
 	"github.com/valyala/fasthttp"
 )
 
 // Handler represents the API handler
 type Handler struct {
-	db *database.Database
+	db        *database.Database
+	auth      *auth.Authenticator
+	alerts    *alerts.Engine
+	webhooks  httputil.HostPolicy // Hosts alert rule webhooks may point at
+	stream    *stream.Hub
+	observers []scraper.Observer
+	limits    *rateLimits
+	serve     fasthttp.RequestHandler
 }
 
-// NewHandler creates a new API handler
-func NewHandler(db *database.Database) *Handler {
-	return &Handler{
-		db: db,
+// NewHandler creates a new API handler. Alert rule webhooks are checked
+// against webhooks, which should be the policy of alertEngine's client. The
+// observers are attached to every scrape started through the API.
+func NewHandler(db *database.Database, authenticator *auth.Authenticator, alertEngine *alerts.Engine, webhooks httputil.HostPolicy, hub *stream.Hub, observers ...scraper.Observer) *Handler {
+	h := &Handler{
+		db:        db,
+		auth:      authenticator,
+		alerts:    alertEngine,
+		webhooks:  webhooks,
+		stream:    hub,
+		observers: observers,
+		limits:    newRateLimits(),
 	}
+	h.serve = h.routes().Handler()
+	return h
 }
 
-// Update your HandleRequest function in routes.go
+// HandleRequest dispatches a request to its route
 func (h *Handler) HandleRequest(ctx *fasthttp.RequestCtx) {
-	path := string(ctx.Path())
+	h.serve(ctx)
+}
 
-	// Handle web routes first
-	if path == "/" || path == "/index.html" {
-		h.handleIndex(ctx)
-		return
-	}
+// routes registers every route. API requests are rate limited by IP address,
+// and by principal too once authenticated, and every API route other than
+// health and sign in needs credentials with a scope: reads need read, changes
+// to a user's own settings and alert rules need write, scrape triggers need
+// refresh and managing shared state needs admin.
+func (h *Handler) routes() *router.Router {
+	r := router.New(
+		func(ctx *fasthttp.RequestCtx) {
+			writeError(ctx, fasthttp.StatusNotFound, "Not Found")
+		},
+		func(ctx *fasthttp.RequestCtx) {
+			writeError(ctx, fasthttp.StatusMethodNotAllowed, fmt.Sprintf("Method %s not allowed, use %s", ctx.Method(), ctx.Response.Header.Peek("Allow")))
+		},
+	)
+	// Every request gets an ID, used in error responses and logs, and a
+	// panicking handler answers with a 500 like any other error
+	r.Use(withRequestID, recoverPanic)
 
-	// Handle static files
-	if strings.HasPrefix(path, "/static/") {
-		h.handleStatic(ctx)
-		return
-	}
+	// Web routes
+	r.GET("/", h.handleIndex)
+	r.GET("/index.html", h.handleIndex)
+	r.GET("/login", h.handleLoginPage)
+	r.GET("/static/{path...}", h.handleStatic)
 
-	// Handle API routes
-	switch {
-	case path == "/api/health":
-		h.handleHealth(ctx)
-	case path == "/api/refresh":
-		h.handleRefresh(ctx)
-	case path == "/api/exchange-rate":
-		h.handleExchangeRate(ctx)
-	case path == "/api/arbitrage":
-		h.handleArbitrage(ctx)
-	default:
-		ctx.SetStatusCode(fasthttp.StatusNotFound)
-		ctx.SetBodyString("Not Found")
-	}
+	api := r.Group("/api", h.rateLimit)
+	api.GET("/health", h.handleHealth)
+	api.POST("/auth/login", h.handleLogin)
+	api.POST("/auth/logout", h.handleLogout)
+
+	read := api.Group("", h.requireScope(auth.ScopeRead))
+	read.GET("/exchange-rate", h.handleExchangeRate)
+	read.GET("/arbitrage", h.handleArbitrage)
+	read.GET("/anomalies", h.handleAnomalies)
+	read.GET("/physical-items", h.handlePhysicalItems)
+	read.GET("/physical-items/{id:uuid}", h.handlePhysicalItem)
+	read.GET("/opportunities/history", h.handleOpportunityHistory)
+	read.GET("/spreads", h.handleSpreads)
+	read.GET("/stream", h.handleStream)
+	read.GET("/stickers", h.handleStickers)
+	read.GET("/phase-prices", h.handlePhasePrices)
+	read.GET("/skins/variants", h.handleSkinVariants)
+	read.GET("/catalog/resolve", h.handleCatalogResolve)
+	read.GET("/auth/me", h.handleMe)
+
+	// These take a POST body but only compute
+	read.GET("/inventory/value", h.handleInventoryValue)
+	read.POST("/inventory/value", h.handlePostedInventoryValue)
+	read.GET("/tradeups", h.handleTradeUps)
+	read.POST("/tradeups", h.handleEvaluateTradeUp)
+
+	// Users see their own settings, lists and alert rules, and admins every
+	// user's alert rules; the handlers scope their queries to the user
+	read.GET("/me/settings", h.handleSettings)
+	read.GET("/me/presets", h.handlePresets)
+	read.GET("/me/watchlist", h.handleWatchlist)
+	read.GET("/alerts/rules", h.handleAlertRules)
+
+	// Changing them needs the write scope, which read-only keys acting for a
+	// user lack
+	write := api.Group("", h.requireScope(auth.ScopeWrite))
+	write.PUT("/me/settings", h.handleUpdateSettings)
+	write.POST("/me/presets", h.handleSavePreset)
+	write.DELETE("/me/presets/{id:uuid}", h.handleDeletePreset)
+	write.POST("/me/watchlist", h.handleAddWatchlistItem)
+	write.DELETE("/me/watchlist/{id:uuid}", h.handleDeleteWatchlistItem)
+	write.POST("/alerts/rules", h.handleCreateAlertRule)
+	write.DELETE("/alerts/rules/{id:uuid}", h.handleDeleteAlertRule)
+	write.POST("/alerts/rules/{id:uuid}/test", h.handleAlertTest)
+
+	refresh := api.Group("", h.requireScope(auth.ScopeRefresh))
+	refresh.POST("/refresh", h.handleRefresh)
+	refresh.POST("/catalog/refresh", h.handleCatalogRefresh)
+
+	admin := api.Group("", h.requireScope(auth.ScopeAdmin))
+	admin.GET("/quarantine", h.handleQuarantine)
+	admin.POST("/stickers", h.handleUpdateStickers)
+	admin.POST("/phase-prices", h.handleUpdatePhasePrices)
+	admin.GET("/portfolio", h.handlePortfolio)
+	admin.POST("/portfolio", h.handleRecordPurchase)
+	admin.DELETE("/portfolio/{id:uuid}", h.handleDeletePortfolioItem)
+	admin.POST("/portfolio/list", h.handlePortfolioList)
+	admin.POST("/portfolio/sell", h.handlePortfolioSell)
+	admin.GET("/alerts/deliveries", h.handleAlertDeliveries)
+	admin.GET("/auth/keys", h.handleAPIKeys)
+	admin.POST("/auth/keys", h.handleCreateAPIKey)
+	admin.DELETE("/auth/keys/{id:uuid}", h.handleRevokeAPIKey)
+	admin.GET("/users", h.handleUsers)
+	admin.POST("/users", h.handleCreateUser)
+	admin.DELETE("/users/{id:uuid}", h.handleDeleteUser)
+
+	return r
 }
 
 // handleHealth handles the health check endpoint
@@ -63,28 +155,42 @@ func (h *Handler) handleHealth(ctx *fasthttp.RequestCtx) {
 		"time":   time.Now().Format(time.RFC3339),
 	}
 
+	ctx.SetContentType("application/json")
 	json.NewEncoder(ctx).Encode(response)
 }
 
-// handleRefresh handles the data refresh endpoint
+// handleRefresh starts a scrape in the background, answering 202 right away
+// since a full scrape takes minutes. Results reach clients through
+// /api/stream and the opportunity endpoints as listings are processed.
 func (h *Handler) handleRefresh(ctx *fasthttp.RequestCtx) {
+	start := time.Now()
+	logf(ctx, "Refresh requested by %s", principal(ctx).Name)
+
 	// Create a scraper and fetch data
-	csgoSkinScraper, err := scraper.NewCSGOSkinScraper(h.db)
+	csgoSkinScraper, err := scraper.NewCSGOSkinScraper(h.db, h.observers...)
 	if err != nil {
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		ctx.SetBodyString(fmt.Sprintf("Failed to initialize scraper: %v", err))
+		internalError(ctx, "Failed to initialize scraper", err)
 		return
 	}
 
-	err = csgoSkinScraper.FetchItems()
-	if err != nil {
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		ctx.SetBodyString(fmt.Sprintf("Failed to fetch items: %v", err))
+	// The request context is reused once the handler returns, so the scrape
+	// logs under the request's ID without holding on to it
+	id := requestID(ctx)
+	err = csgoSkinScraper.StartFetchItems(func(err error) {
+		if err != nil {
+			log.Printf("[%s] Refresh failed after %s: %v", id, time.Since(start).Round(time.Second), err)
+			return
+		}
+		log.Printf("[%s] Refresh completed in %s", id, time.Since(start).Round(time.Second))
+	})
+	if errors.Is(err, scraper.ErrScrapeRunning) {
+		writeError(ctx, fasthttp.StatusConflict, "A refresh is already running")
 		return
 	}
 
-	ctx.SetStatusCode(fasthttp.StatusOK)
-	ctx.SetBodyString("Data refreshed successfully")
+	ctx.SetStatusCode(fasthttp.StatusAccepted)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(map[string]interface{}{"message": "Refresh started", "request_id": id})
 }
 
 // handleExchangeRate handles the exchange rate endpoint
@@ -104,30 +210,125 @@ func (h *Handler) handleExchangeRate(ctx *fasthttp.RequestCtx) {
 	json.NewEncoder(ctx).Encode(response)
 }
 
-// handleArbitrage handles the arbitrage opportunities endpoint
+// handleArbitrage handles the arbitrage opportunities endpoint. Requests made
+// by a user get their preset and settings applied to whatever they leave out.
 func (h *Handler) handleArbitrage(ctx *fasthttp.RequestCtx) {
+	settings := models.UserSettings{DisplayCurrency: models.CurrencyUSD}
+	var user *models.User
+	var presetName string
+	if p := principal(ctx); p != nil && p.UserID != nil {
+		var ok bool
+		if user, ok = h.currentUser(ctx); !ok {
+			return
+		}
+		if presetName, ok = h.applyPreset(ctx, user.ID); !ok {
+			return
+		}
+		settings = user.Settings
+	}
+
 	// Parse min profit percentage from query params (default 10%)
 	minProfitStr := string(ctx.QueryArgs().Peek("min_profit"))
 	minProfit := 10.0 // default
+	if settings.DefaultMinProfitPercent != nil {
+		minProfit = *settings.DefaultMinProfitPercent
+	}
 	if minProfitStr != "" {
 		if parsedProfit, err := json.Number(minProfitStr).Float64(); err == nil {
 			minProfit = parsedProfit
 		}
 	}
 
-	// Use the FindArbitrageOpportunities function directly
-	// since we can't access db.pool directly
-	opportunities, err := findArbitrageOpportunities(h.db, minProfit)
-	if err != nil {
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		ctx.SetBodyString(fmt.Sprintf("Failed to find arbitrage opportunities: %v", err))
+	filter := arbitrage.Filter{
+		MinProfitPercent:   minProfit,
+		MinStickerValueUSD: parseFloatArg(ctx, "min_sticker_value", 0),
+		MinDailyVolume:     int(parseFloatArg(ctx, "min_daily_volume", 0)),
+
+		MaxDaysUntilSellable: parseFloatArg(ctx, "max_hold_days", 0),
+
+		SteamSaleFeePercent:       settings.SteamSaleFeePercent,
+		MarketplaceSaleFeePercent: settings.MarketplaceFeePercent,
+	}
+
+	maxBuyPrice := 0.0
+	if settings.MaxBuyPriceUSD != nil {
+		maxBuyPrice = *settings.MaxBuyPriceUSD
+	}
+	filter.MaxBuyPriceUSD = parseFloatArg(ctx, "max_buy_price", maxBuyPrice)
+
+	// Only the user's watched skins, each under its target price
+	if string(ctx.QueryArgs().Peek("watchlist")) == "true" {
+		if user == nil {
+			writeError(ctx, fasthttp.StatusBadRequest, "watchlist requires signing in as a user")
+			return
+		}
+		watchlist, err := h.db.GetWatchlist(user.ID)
+		if err != nil {
+			internalError(ctx, "Failed to get watchlist", err)
+			return
+		}
+		filter.MarketHashNames = make(map[string]float64, len(watchlist))
+		for _, w := range watchlist {
+			filter.MarketHashNames[w.MarketHashName] = 0
+			if w.MaxPriceUSD != nil {
+				filter.MarketHashNames[w.MarketHashName] = *w.MaxPriceUSD
+			}
+		}
+	}
+
+	// Forward buys listings to sell on Steam, reverse buys on Steam to sell
+	// on a marketplace
+	direction := string(ctx.QueryArgs().Peek("direction"))
+	if direction == "" {
+		direction = arbitrage.DirectionForward
+	}
+	if direction != arbitrage.DirectionForward && direction != arbitrage.DirectionReverse && direction != "both" {
+		writeError(ctx, fasthttp.StatusBadRequest, "direction must be forward, reverse or both")
 		return
 	}
 
+	var opportunities []arbitrage.Opportunity
+	if direction != arbitrage.DirectionReverse {
+		forward, err := arbitrage.Find(h.db, filter)
+		if err != nil {
+			internalError(ctx, "Failed to find arbitrage opportunities", err)
+			return
+		}
+		opportunities = append(opportunities, forward...)
+	}
+	if direction != arbitrage.DirectionForward {
+		reverse, err := arbitrage.FindReverse(h.db, filter)
+		if err != nil {
+			internalError(ctx, "Failed to find reverse arbitrage opportunities", err)
+			return
+		}
+		opportunities = append(opportunities, reverse...)
+		arbitrage.SortByProfit(opportunities)
+	}
+
+	// Users displaying Rial get each price converted as well
+	var usdToIRR float64
+	if settings.DisplayCurrency == models.CurrencyIRR {
+		usdToIRR = scraper.GetUSDTtoIRRRate()
+		for i := range opportunities {
+			opportunities[i].SetIRRPrices(usdToIRR)
+		}
+	}
+
 	response := map[string]interface{}{
 		"opportunities":      opportunities,
 		"count":              len(opportunities),
 		"min_profit_percent": minProfit,
+		"min_sticker_value":  filter.MinStickerValueUSD,
+		"min_daily_volume":   filter.MinDailyVolume,
+		"max_hold_days":      filter.MaxDaysUntilSellable,
+		"max_buy_price":      filter.MaxBuyPriceUSD,
+		"direction":          direction,
+		"preset":             presetName,
+		"display_currency":   settings.DisplayCurrency,
+	}
+	if usdToIRR > 0 {
+		response["usd_to_irr"] = usdToIRR
 	}
 
 	ctx.SetStatusCode(fasthttp.StatusOK)
@@ -135,82 +336,168 @@ func (h *Handler) handleArbitrage(ctx *fasthttp.RequestCtx) {
 	json.NewEncoder(ctx).Encode(response)
 }
 
-// ArbitrageOpportunity represents a potential arbitrage opportunity
-type ArbitrageOpportunity struct {
-	MarketHashName string   `json:"market_hash_name"`
-	BuyPriceUSD    float64  `json:"buy_price_usd"`
-	SellPriceUSD   float64  `json:"sell_price_usd"`
-	ProfitUSD      float64  `json:"profit_usd"`
-	ProfitPercent  float64  `json:"profit_percent"`
-	Marketplace    string   `json:"marketplace"`
-	Float          float64  `json:"float"`
-	Quality        string   `json:"quality"`
-	IconURL        string   `json:"icon_url"`
-	Category       string   `json:"category"`
-	IsStatTrak     bool     `json:"is_stattrak"`
-	Stickers       []string `json:"stickers"`
-}
-
-// findArbitrageOpportunities finds arbitrage opportunities using the database struct
-func findArbitrageOpportunities(db *database.Database, minProfitPercent float64) ([]ArbitrageOpportunity, error) {
-	// Update the query to include icon_url from skins table
-	query := `
-        SELECT 
-            s.market_hash_name, 
-            i.price_usd as buy_price_usd, 
-            i.steam_price_usd as sell_price_usd,
-            (i.steam_price_usd - i.price_usd) AS profit_usd,
-            (i.steam_price_usd - i.price_usd) / i.price_usd * 100 AS profit_percent,
-            m.name AS marketplace,
-            i.float,
-            s.quality,
-            s.icon_url, 
-            s.category,
-            s.is_stattrak,
-            i.stickers
-        FROM 
-            items i
-        JOIN 
-            skins s ON i.skin_id = s.id
-        JOIN 
-            marketplaces m ON i.marketplace_id = m.id
-        WHERE 
-            i.steam_price_usd > 0
-            AND i.price_usd > 0
-            AND (i.steam_price_usd - i.price_usd) / i.price_usd * 100 >= $1
-        ORDER BY 
-            profit_percent DESC
-    `
-
-	// We'll need to add a method to your database struct
-	// But for now we can add this helper method here
-
-	// Let's use the existing QueryRow method that should be available
-	rows, err := db.ExecuteQuery(query, minProfitPercent)
+// handleStickers lists the sticker catalogue
+func (h *Handler) handleStickers(ctx *fasthttp.RequestCtx) {
+	prices, err := h.db.GetStickerPrices()
 	if err != nil {
-		return nil, fmt.Errorf("error querying arbitrage opportunities: %v", err)
-	}
-
-	var opportunities []ArbitrageOpportunity
-
-	for _, row := range rows {
-		opp := ArbitrageOpportunity{
-			MarketHashName: row.MarketHashName,
-			BuyPriceUSD:    row.BuyPriceUSD,
-			SellPriceUSD:   row.SellPriceUSD,
-			ProfitUSD:      row.ProfitUSD,
-			ProfitPercent:  row.ProfitPercent,
-			Marketplace:    row.Marketplace,
-			Float:          row.Float,
-			Quality:        row.Quality,
-			IconURL:        row.IconURL,
-			Category:       row.Category,
-			IsStatTrak:     row.IsStatTrak,
-			Stickers:       row.Stickers,
+		internalError(ctx, "Failed to load sticker prices", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"stickers": prices,
+		"count":    len(prices),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleUpdateStickers upserts sticker reference prices from a JSON array
+// and lists the updated catalogue
+func (h *Handler) handleUpdateStickers(ctx *fasthttp.RequestCtx) {
+	var prices []models.StickerPrice
+	if err := json.Unmarshal(ctx.PostBody(), &prices); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid sticker prices: %v", err))
+		return
+	}
+
+	for _, price := range prices {
+		if price.Name == "" || price.PriceUSD < 0 {
+			writeError(ctx, fasthttp.StatusBadRequest, "Each sticker needs a name and a non-negative price_usd")
+			return
+		}
+		if _, err := h.db.UpsertStickerPrice(&price); err != nil {
+			internalError(ctx, "Failed to update sticker prices", err)
+			return
 		}
+	}
+
+	h.handleStickers(ctx)
+}
+
+// handlePhasePrices lists Doppler phase reference prices
+func (h *Handler) handlePhasePrices(ctx *fasthttp.RequestCtx) {
+	prices, err := h.db.GetPhasePrices()
+	if err != nil {
+		internalError(ctx, "Failed to load phase prices", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"phase_prices": prices,
+		"count":        len(prices),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleUpdatePhasePrices upserts Doppler phase reference prices from a JSON
+// array and lists the updated prices
+func (h *Handler) handleUpdatePhasePrices(ctx *fasthttp.RequestCtx) {
+	var prices []models.PhasePrice
+	if err := json.Unmarshal(ctx.PostBody(), &prices); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid phase prices: %v", err))
+		return
+	}
+
+	for _, price := range prices {
+		if price.MarketHashName == "" || price.Phase == "" || price.PriceUSD <= 0 {
+			writeError(ctx, fasthttp.StatusBadRequest, "Each phase price needs a market_hash_name, a phase and a positive price_usd")
+			return
+		}
+		if _, err := h.db.UpsertPhasePrice(&price); err != nil {
+			internalError(ctx, "Failed to update phase prices", err)
+			return
+		}
+	}
+
+	h.handlePhasePrices(ctx)
+}
+
+// handleSkinVariants compares current prices across every variant of a base
+// skin, given by name ("AK-47 | Redline") or by any of its market hash names
+func (h *Handler) handleSkinVariants(ctx *fasthttp.RequestCtx) {
+	name := string(ctx.QueryArgs().Peek("name"))
+	if name == "" {
+		writeError(ctx, fasthttp.StatusBadRequest, "Missing name parameter")
+		return
+	}
+
+	resolved := catalog.Default().Resolve(name)
+	baseSkin, err := h.db.GetBaseSkin(resolved.Weapon, resolved.Finish)
+	if err != nil {
+		writeDBError(ctx, fmt.Sprintf("Failed to load skin %q", name), err)
+		return
+	}
+
+	variants, err := h.db.GetVariantPrices(baseSkin.ID)
+	if err != nil {
+		internalError(ctx, "Failed to load variant prices", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"base_skin": baseSkin,
+		"name":      baseSkin.Name(),
+		"variants":  variants,
+		"count":     len(variants),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleCatalogResolve resolves the market hash name given in the name
+// query parameter against the item catalogue
+func (h *Handler) handleCatalogResolve(ctx *fasthttp.RequestCtx) {
+	name := string(ctx.QueryArgs().Peek("name"))
+	if name == "" {
+		writeError(ctx, fasthttp.StatusBadRequest, "Missing name parameter")
+		return
+	}
+
+	resolution := catalog.Default().Resolve(name, string(ctx.QueryArgs().Peek("category")))
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(resolution)
+}
 
-		opportunities = append(opportunities, opp)
+// handleCatalogRefresh reloads the item catalogue, either from a dataset
+// posted in the request body or from CATALOG_FILE / the bundled dataset. Only
+// a posted dataset is the caller's to fix; the server's own file failing to
+// load is an internal error.
+func (h *Handler) handleCatalogRefresh(ctx *fasthttp.RequestCtx) {
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := catalog.ReloadFrom(body); err != nil {
+			writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Failed to reload catalogue: %v", err))
+			return
+		}
+	} else if err := catalog.Reload(); err != nil {
+		internalError(ctx, "Failed to reload catalogue", err)
+		return
 	}
 
-	return opportunities, nil
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(map[string]interface{}{"message": "Catalogue reloaded successfully"})
+}
+
+// parseFloatArg reads a float query parameter, returning fallback when it is
+// missing or invalid
+func parseFloatArg(ctx *fasthttp.RequestCtx, name string, fallback float64) float64 {
+	value := string(ctx.QueryArgs().Peek(name))
+	if value == "" {
+		return fallback
+	}
+	parsed, err := json.Number(value).Float64()
+	if err != nil {
+		return fallback
+	}
+	return parsed
 }
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/auth"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
+	"github.com/valyala/fasthttp"
+)
+
+// keyStore knows a fixed set of API keys and nothing else
+type keyStore map[string]*models.APIKey
+
+func (s keyStore) GetActiveAPIKey(keyHash string) (*models.APIKey, error) {
+	return s[keyHash], nil
+}
+
+func (s keyStore) GetUserByUsername(username string) (*models.User, error) {
+	return nil, nil
+}
+
+func (s keyStore) InsertSession(tokenHash string, apiKeyID, userID *string, bootstrapKeyHash string, scopes []string, expiresAt time.Time) error {
+	return nil
+}
+
+func (s keyStore) GetSession(tokenHash, bootstrapKeyHash string) (*models.Session, error) {
+	return nil, nil
+}
+
+func (s keyStore) DeleteSession(tokenHash string) error {
+	return nil
+}
+
+func (s keyStore) DeleteExpiredSessions() error {
+	return nil
+}
+
+// newTestHandler returns a handler without a database, authenticating the
+// keys "read", "write", "refresh" and "admin" with the scope of the same name
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	t.Setenv("RATE_LIMIT_ENABLED", "false")
+	t.Setenv("ADMIN_API_KEY", "")
+
+	keys := keyStore{}
+	for _, scope := range auth.Scopes {
+		keys[auth.HashToken(scope)] = &models.APIKey{ID: scope, Name: scope, Scopes: []string{scope}}
+	}
+	return NewHandler(nil, auth.NewAuthenticator(keys), nil, httputil.HostPolicy{}, nil)
+}
+
+// serve sends a request through the handler, authenticated with key unless
+// it is empty
+func serve(h *Handler, method, uri, key, body string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	if key != "" {
+		ctx.Request.Header.Set("X-API-Key", key)
+	}
+	ctx.Request.SetBodyString(body)
+	h.HandleRequest(ctx)
+	return ctx
+}
+
+func TestRouteScopes(t *testing.T) {
+	h := newTestHandler(t)
+
+	// The status of each route with no key and each scope's key. The
+	// handlers past the scope check stop before reaching the database.
+	tests := []struct {
+		method, uri, body string
+		none              int
+		read, write       int
+		refresh, admin    int
+	}{
+		{"GET", "/api/health", "", 200, 200, 200, 200, 200},
+		{"GET", "/api/auth/me", "", 401, 200, 403, 403, 200},
+		{"GET", "/api/catalog/resolve", "", 401, 400, 403, 403, 400},
+		{"POST", "/api/catalog/refresh", "", 401, 403, 403, 200, 200},
+		{"POST", "/api/auth/keys", "{}", 401, 403, 403, 403, 400},
+		{"POST", "/api/stickers", "[", 401, 403, 403, 403, 400},
+		{"POST", "/api/phase-prices", "[", 401, 403, 403, 403, 400},
+		// The write key isn't a user's, so it gets past the scope check to
+		// be told it manages no alert rules
+		{"POST", "/api/alerts/rules", "[", 401, 403, 403, 403, 400},
+	}
+
+	for _, tt := range tests {
+		for key, want := range map[string]int{"": tt.none, auth.ScopeRead: tt.read, auth.ScopeWrite: tt.write, auth.ScopeRefresh: tt.refresh, auth.ScopeAdmin: tt.admin} {
+			ctx := serve(h, tt.method, tt.uri, key, tt.body)
+			if got := ctx.Response.StatusCode(); got != want {
+				t.Errorf("%s %s with key %q = %d, want %d: %s", tt.method, tt.uri, key, got, want, ctx.Response.Body())
+			}
+		}
+	}
+
+	// Unauthenticated requests are told how to authenticate
+	ctx := serve(h, "GET", "/api/auth/me", "", "")
+	if !strings.HasPrefix(string(ctx.Response.Header.Peek("WWW-Authenticate")), "Bearer") {
+		t.Errorf("WWW-Authenticate = %q", ctx.Response.Header.Peek("WWW-Authenticate"))
+	}
+	ctx = serve(h, "GET", "/api/auth/me", auth.ScopeRefresh, "")
+	if !strings.Contains(string(ctx.Response.Body()), "Requires the read scope") {
+		t.Errorf("forbidden response = %s", ctx.Response.Body())
+	}
+
+	// Read keys can't change a user's settings
+	for _, tt := range []struct{ method, uri string }{
+		{"PUT", "/api/me/settings"},
+		{"POST", "/api/me/presets"},
+		{"DELETE", "/api/me/watchlist/0b5e3c1a-9f2d-4e6b-8a7c-1d2e3f4a5b6c"},
+		{"POST", "/api/alerts/rules/0b5e3c1a-9f2d-4e6b-8a7c-1d2e3f4a5b6c/test"},
+	} {
+		ctx = serve(h, tt.method, tt.uri, auth.ScopeRead, "{}")
+		if !strings.Contains(string(ctx.Response.Body()), "Requires the write scope") {
+			t.Errorf("%s %s with a read key = %d %s", tt.method, tt.uri, ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+	}
+}
+
+func TestCatalogRefreshErrors(t *testing.T) {
+	h := newTestHandler(t)
+	t.Setenv("CATALOG_FILE", "/srv/private/catalog.json")
+
+	// The server's own file failing to load is internal, and its path stays
+	// out of the response
+	ctx := serve(h, "POST", "/api/catalog/refresh", auth.ScopeRefresh, "")
+	if got := ctx.Response.StatusCode(); got != fasthttp.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", got)
+	}
+	if strings.Contains(string(ctx.Response.Body()), "/srv/private") {
+		t.Errorf("response leaks the catalogue path: %s", ctx.Response.Body())
+	}
+
+	// An invalid posted dataset is the caller's to fix
+	ctx = serve(h, "POST", "/api/catalog/refresh", auth.ScopeRefresh, "[")
+	if got := ctx.Response.StatusCode(); got != fasthttp.StatusBadRequest {
+		t.Errorf("status = %d, want 400: %s", got, ctx.Response.Body())
+	}
+}
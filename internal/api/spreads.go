@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/valyala/fasthttp"
+)
+
+// handleSpreads returns the cross-marketplace spread matrix. Query params:
+// band_width (float band width, e.g. 0.01), min_profit (net % of the best
+// route), name, category and limit.
+func (h *Handler) handleSpreads(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+
+	filter := arbitrage.SpreadFilter{
+		FloatBandWidth: parseFloatArg(ctx, "band_width", 0),
+		Name:           string(args.Peek("name")),
+		Category:       string(args.Peek("category")),
+	}
+	if filter.FloatBandWidth < 0 || filter.FloatBandWidth > 1 {
+		writeError(ctx, fasthttp.StatusBadRequest, "band_width must be between 0 and 1")
+		return
+	}
+	if args.Has("min_profit") {
+		filter.HasMinProfit = true
+		filter.MinProfitPercent = parseFloatArg(ctx, "min_profit", 0)
+	}
+	if args.Has("limit") {
+		limit, err := strconv.Atoi(string(args.Peek("limit")))
+		if err != nil || limit < 1 {
+			writeError(ctx, fasthttp.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	spreads, total, err := arbitrage.Spreads(h.db, filter)
+	if err != nil {
+		internalError(ctx, "Failed to compute spreads", err)
+		return
+	}
+	if spreads == nil {
+		spreads = []arbitrage.Spread{}
+	}
+
+	response := map[string]interface{}{
+		"spreads":    spreads,
+		"count":      len(spreads),
+		"total":      total,
+		"band_width": filter.FloatBandWidth,
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
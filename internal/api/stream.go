@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// streamHeartbeatInterval keeps idle connections open through proxies
+const streamHeartbeatInterval = 15 * time.Second
+
+// handleStream pushes opportunity events to the client as Server-Sent Events
+func (h *Handler) handleStream(ctx *fasthttp.RequestCtx) {
+	if h.stream == nil {
+		writeError(ctx, fasthttp.StatusServiceUnavailable, "Streaming is not enabled")
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("Connection", "keep-alive")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+
+	events := h.stream.Subscribe()
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.stream.Unsubscribe(events)
+
+		// Tell the client how long to wait before reconnecting
+		fmt.Fprintf(w, "retry: 3000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return // Dropped for falling behind
+				}
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data())
+			case <-heartbeat.C:
+				fmt.Fprintf(w, ": heartbeat\n\n")
+			}
+
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/mswatii/cs2-arbitrage/internal/tradeup"
+	"github.com/valyala/fasthttp"
+)
+
+// handleTradeUps searches current listings for profitable trade-up
+// contracts. Query params: min_profit (expected %, default 0), stattrak
+// (true/false, both when omitted) and limit (default 20).
+func (h *Handler) handleTradeUps(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	filter := tradeup.Filter{
+		MinProfitPercent: parseFloatArg(ctx, "min_profit", 0),
+		Limit:            20,
+	}
+	if raw := string(args.Peek("stattrak")); raw != "" {
+		statTrak, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(ctx, fasthttp.StatusBadRequest, "stattrak must be true or false")
+			return
+		}
+		filter.StatTrak = &statTrak
+	}
+	if limit, err := strconv.Atoi(string(args.Peek("limit"))); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+
+	contracts, err := tradeup.Search(h.db, filter)
+	if err != nil {
+		internalError(ctx, "Failed to search trade-ups", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"contracts": contracts,
+		"count":     len(contracts),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleEvaluateTradeUp evaluates the trade-up contract of the listings in
+// {"item_ids": [...]}
+func (h *Handler) handleEvaluateTradeUp(ctx *fasthttp.RequestCtx) {
+	var req struct {
+		ItemIDs []string `json:"item_ids"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid JSON: %v", err))
+		return
+	}
+
+	contract, err := tradeup.Evaluate(h.db, req.ItemIDs)
+	if errors.Is(err, tradeup.ErrInvalid) {
+		logf(ctx, "Failed to evaluate trade-up: %v", err)
+		writeError(ctx, fasthttp.StatusBadRequest, invalidMessage("Failed to evaluate trade-up", err, tradeup.ErrInvalid))
+		return
+	}
+	if err != nil {
+		internalError(ctx, "Failed to evaluate trade-up", err)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(contract)
+}
@@ -0,0 +1,392 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/internal/auth"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/internal/router"
+	"github.com/valyala/fasthttp"
+)
+
+// currentUser returns the user the request acts for, writing a 403 response
+// when it isn't made by a user account
+func (h *Handler) currentUser(ctx *fasthttp.RequestCtx) (*models.User, bool) {
+	p := principal(ctx)
+	if p == nil || p.UserID == nil {
+		writeError(ctx, fasthttp.StatusForbidden, "Requires signing in as a user")
+		return nil, false
+	}
+
+	user, err := h.db.GetUser(*p.UserID)
+	if err != nil {
+		internalError(ctx, "Failed to get user", err)
+		return nil, false
+	}
+	if user == nil {
+		writeError(ctx, fasthttp.StatusForbidden, "User no longer exists")
+		return nil, false
+	}
+	return user, true
+}
+
+// ownerID returns the user whose alert rules a request may manage, or an
+// empty string for admins, who manage everyone's. It writes a 403 response
+// when the request may manage none.
+func ownerID(ctx *fasthttp.RequestCtx) (string, bool) {
+	p := principal(ctx)
+	if p != nil && p.UserID != nil {
+		return *p.UserID, true
+	}
+	if p != nil && p.Has(auth.ScopeAdmin) {
+		return "", true
+	}
+	writeError(ctx, fasthttp.StatusForbidden, "Requires signing in as a user")
+	return "", false
+}
+
+// handleUsers lists users
+func (h *Handler) handleUsers(ctx *fasthttp.RequestCtx) {
+	users, err := h.db.GetUsers()
+	if err != nil {
+		internalError(ctx, "Failed to get users", err)
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+
+	response := map[string]interface{}{
+		"users": users,
+		"count": len(users),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleCreateUser creates a user from {"username", "password", "scopes"},
+// by default able to read and to manage their own settings
+func (h *Handler) handleCreateUser(ctx *fasthttp.RequestCtx) {
+	var req struct {
+		Username string   `json:"username"`
+		Password string   `json:"password"`
+		Scopes   []string `json:"scopes"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid user: %v", err))
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{auth.ScopeRead, auth.ScopeWrite}
+	}
+	if req.Username == "" || !auth.ValidScopes(req.Scopes) {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("username is required and scopes must be some of %s", strings.Join(auth.Scopes, ", ")))
+		return
+	}
+	if len(req.Password) < auth.MinPasswordLength {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("password must be at least %d characters", auth.MinPasswordLength))
+		return
+	}
+
+	existing, err := h.db.GetUserByUsername(req.Username)
+	if err != nil {
+		internalError(ctx, "Failed to create user", err)
+		return
+	}
+	if existing != nil {
+		writeError(ctx, fasthttp.StatusConflict, fmt.Sprintf("User %q already exists", req.Username))
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		internalError(ctx, "Failed to create user", err)
+		return
+	}
+	user := models.User{Username: req.Username, PasswordHash: hash, Scopes: req.Scopes}
+	user.ID, err = h.db.InsertUser(&user)
+	if err != nil {
+		internalError(ctx, "Failed to create user", err)
+		return
+	}
+	user.Settings.DisplayCurrency = models.CurrencyUSD
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(user)
+}
+
+// handleDeleteUser deletes the user given by /{id}
+func (h *Handler) handleDeleteUser(ctx *fasthttp.RequestCtx) {
+	if err := h.db.DeleteUser(router.Param(ctx, "id")); err != nil {
+		writeDBError(ctx, "Failed to delete user", err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// handleSettings returns the signed in user's settings
+func (h *Handler) handleSettings(ctx *fasthttp.RequestCtx) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(user.Settings)
+}
+
+// handleUpdateSettings replaces the signed in user's settings with the JSON
+// body and returns them
+func (h *Handler) handleUpdateSettings(ctx *fasthttp.RequestCtx) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var settings models.UserSettings
+	if err := json.Unmarshal(ctx.PostBody(), &settings); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid settings: %v", err))
+		return
+	}
+	if err := validateSettings(&settings); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.db.UpdateUserSettings(user.ID, &settings); err != nil {
+		internalError(ctx, "Failed to update settings", err)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(settings)
+}
+
+// validateSettings checks user settings, defaulting the display currency
+func validateSettings(settings *models.UserSettings) error {
+	settings.DisplayCurrency = strings.ToUpper(settings.DisplayCurrency)
+	if settings.DisplayCurrency == "" {
+		settings.DisplayCurrency = models.CurrencyUSD
+	}
+	if settings.DisplayCurrency != models.CurrencyUSD && settings.DisplayCurrency != models.CurrencyIRR {
+		return fmt.Errorf("display_currency must be %s or %s", models.CurrencyUSD, models.CurrencyIRR)
+	}
+	if settings.MaxBuyPriceUSD != nil && *settings.MaxBuyPriceUSD < 0 {
+		return fmt.Errorf("max_buy_price_usd must not be negative")
+	}
+	if fee := settings.SteamSaleFeePercent; fee != nil && (*fee < 0 || *fee >= 100) {
+		return fmt.Errorf("steam_sale_fee_percent must be between 0 and 100")
+	}
+	for name, fee := range settings.MarketplaceFeePercent {
+		if fee < 0 || fee >= 100 {
+			return fmt.Errorf("marketplace_fee_percent for %s must be between 0 and 100", name)
+		}
+	}
+	return nil
+}
+
+// handlePresets lists the signed in user's filter presets
+func (h *Handler) handlePresets(ctx *fasthttp.RequestCtx) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	presets, err := h.db.GetFilterPresets(user.ID)
+	if err != nil {
+		internalError(ctx, "Failed to get presets", err)
+		return
+	}
+	if presets == nil {
+		presets = []models.FilterPreset{}
+	}
+
+	response := map[string]interface{}{
+		"presets": presets,
+		"count":   len(presets),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleSavePreset saves a filter preset of the signed in user from
+// {"name", "filters", "is_default"}
+func (h *Handler) handleSavePreset(ctx *fasthttp.RequestCtx) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var preset models.FilterPreset
+	if err := json.Unmarshal(ctx.PostBody(), &preset); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid preset: %v", err))
+		return
+	}
+	preset.Name = strings.TrimSpace(preset.Name)
+	if preset.Name == "" {
+		writeError(ctx, fasthttp.StatusBadRequest, "Preset needs a name")
+		return
+	}
+	if preset.Filters == nil {
+		preset.Filters = map[string]string{}
+	}
+	preset.UserID = user.ID
+
+	id, err := h.db.UpsertFilterPreset(&preset)
+	if err != nil {
+		internalError(ctx, "Failed to save preset", err)
+		return
+	}
+	preset.ID = id
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(preset)
+}
+
+// handleDeletePreset deletes the signed in user's preset given by /{id}
+func (h *Handler) handleDeletePreset(ctx *fasthttp.RequestCtx) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.db.DeleteFilterPreset(user.ID, router.Param(ctx, "id")); err != nil {
+		writeDBError(ctx, "Failed to delete preset", err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// handleWatchlist lists the signed in user's watched skins
+func (h *Handler) handleWatchlist(ctx *fasthttp.RequestCtx) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	watchlist, err := h.db.GetWatchlist(user.ID)
+	if err != nil {
+		internalError(ctx, "Failed to get watchlist", err)
+		return
+	}
+	if watchlist == nil {
+		watchlist = []models.WatchlistItem{}
+	}
+
+	response := map[string]interface{}{
+		"watchlist": watchlist,
+		"count":     len(watchlist),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(response)
+}
+
+// handleAddWatchlistItem adds a skin to the signed in user's watchlist from
+// {"market_hash_name", "max_price_usd", "note"}
+func (h *Handler) handleAddWatchlistItem(ctx *fasthttp.RequestCtx) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var item models.WatchlistItem
+	if err := json.Unmarshal(ctx.PostBody(), &item); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid watchlist item: %v", err))
+		return
+	}
+	item.MarketHashName = strings.TrimSpace(item.MarketHashName)
+	if item.MarketHashName == "" {
+		writeError(ctx, fasthttp.StatusBadRequest, "Watchlist item needs a market_hash_name")
+		return
+	}
+	if item.MaxPriceUSD != nil && *item.MaxPriceUSD <= 0 {
+		writeError(ctx, fasthttp.StatusBadRequest, "max_price_usd must be positive")
+		return
+	}
+	item.UserID = user.ID
+
+	id, err := h.db.UpsertWatchlistItem(&item)
+	if err != nil {
+		internalError(ctx, "Failed to update watchlist", err)
+		return
+	}
+	item.ID = id
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(item)
+}
+
+// handleDeleteWatchlistItem removes the signed in user's watchlist entry
+// given by /{id}
+func (h *Handler) handleDeleteWatchlistItem(ctx *fasthttp.RequestCtx) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.db.DeleteWatchlistItem(user.ID, router.Param(ctx, "id")); err != nil {
+		writeDBError(ctx, "Failed to update watchlist", err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// applyPreset fills the arbitrage query parameters a request leaves out from
+// the user's preset named by ?preset=, or else their default preset. It
+// returns the name of the preset applied, and false after writing a 404
+// response for an unknown preset.
+func (h *Handler) applyPreset(ctx *fasthttp.RequestCtx, userID string) (string, bool) {
+	presets, err := h.db.GetFilterPresets(userID)
+	if err != nil {
+		internalError(ctx, "Failed to get presets", err)
+		return "", false
+	}
+
+	name := string(ctx.QueryArgs().Peek("preset"))
+	preset, ok := pickPreset(presets, name)
+	if !ok {
+		writeError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Unknown preset %q", name))
+		return "", false
+	}
+	if preset == nil {
+		return "", true
+	}
+
+	fillFromPreset(ctx.QueryArgs(), preset)
+	return preset.Name, true
+}
+
+// pickPreset returns the preset with the given name, or the default preset
+// when name is empty. It returns nil when there is no default and false
+// when no preset has the name.
+func pickPreset(presets []models.FilterPreset, name string) (*models.FilterPreset, bool) {
+	for i := range presets {
+		if (name != "" && presets[i].Name == name) || (name == "" && presets[i].IsDefault) {
+			return &presets[i], true
+		}
+	}
+	return nil, name == ""
+}
+
+// fillFromPreset sets the query parameters args leaves out from a preset's
+// filters
+func fillFromPreset(args *fasthttp.Args, preset *models.FilterPreset) {
+	for key, value := range preset.Filters {
+		if key != "preset" && !args.Has(key) {
+			args.Set(key, value)
+		}
+	}
+}
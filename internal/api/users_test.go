@@ -0,0 +1,91 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/valyala/fasthttp"
+)
+
+func TestValidateSettings(t *testing.T) {
+	negative, zero, full, fee := -1.0, 0.0, 100.0, 13.0
+
+	tests := []struct {
+		name     string
+		settings models.UserSettings
+		valid    bool
+		currency string
+	}{
+		{"empty", models.UserSettings{}, true, models.CurrencyUSD},
+		{"lower case currency", models.UserSettings{DisplayCurrency: "irr"}, true, models.CurrencyIRR},
+		{"unknown currency", models.UserSettings{DisplayCurrency: "EUR"}, false, ""},
+		{"negative max buy price", models.UserSettings{MaxBuyPriceUSD: &negative}, false, ""},
+		{"zero max buy price", models.UserSettings{MaxBuyPriceUSD: &zero}, true, models.CurrencyUSD},
+		{"Steam fee", models.UserSettings{SteamSaleFeePercent: &fee}, true, models.CurrencyUSD},
+		{"negative Steam fee", models.UserSettings{SteamSaleFeePercent: &negative}, false, ""},
+		{"whole Steam fee", models.UserSettings{SteamSaleFeePercent: &full}, false, ""},
+		{"marketplace fee", models.UserSettings{MarketplaceFeePercent: map[string]float64{"csgoskin.ir": 5}}, true, models.CurrencyUSD},
+		{"whole marketplace fee", models.UserSettings{MarketplaceFeePercent: map[string]float64{"csgoskin.ir": 100}}, false, ""},
+	}
+
+	for _, tt := range tests {
+		settings := tt.settings
+		err := validateSettings(&settings)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: error = %v, want valid %v", tt.name, err, tt.valid)
+			continue
+		}
+		if tt.valid && settings.DisplayCurrency != tt.currency {
+			t.Errorf("%s: display currency = %q, want %q", tt.name, settings.DisplayCurrency, tt.currency)
+		}
+	}
+}
+
+func TestPickPreset(t *testing.T) {
+	presets := []models.FilterPreset{
+		{Name: "cheap"},
+		{Name: "daily", IsDefault: true},
+	}
+
+	tests := []struct {
+		name    string
+		presets []models.FilterPreset
+		want    string // Empty for none
+		ok      bool
+	}{
+		{"", presets, "daily", true},
+		{"cheap", presets, "cheap", true},
+		{"missing", presets, "", false},
+		{"", presets[:1], "", true},
+		{"", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		preset, ok := pickPreset(tt.presets, tt.name)
+		got := ""
+		if preset != nil {
+			got = preset.Name
+		}
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("pickPreset(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFillFromPreset(t *testing.T) {
+	args := &fasthttp.Args{}
+	args.Parse("min_profit=5&preset=daily")
+
+	fillFromPreset(args, &models.FilterPreset{Filters: map[string]string{
+		"min_profit": "15",
+		"direction":  "both",
+		"preset":     "other",
+	}})
+
+	want := map[string]string{"min_profit": "5", "direction": "both", "preset": "daily"}
+	for key, value := range want {
+		if got := string(args.Peek(key)); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
@@ -1,27 +1,23 @@
 package api
 
 import (
-	"fmt"
 	"io/ioutil"
 	"path/filepath"
-	"strings"
 
+	"github.com/mswatii/cs2-arbitrage/internal/router"
 	"github.com/valyala/fasthttp"
 )
 
 // Serve static files (CSS, JS, images)
 func (h *Handler) handleStatic(ctx *fasthttp.RequestCtx) {
-	filePath := string(ctx.Path())
-	// Remove the leading "/static/" from the path
-	filePath = strings.TrimPrefix(filePath, "/static/")
+	filePath := router.Param(ctx, "path")
 	// Build the actual file path
 	fullPath := filepath.Join("web/static", filePath)
 
 	// Try to read the file
 	content, err := ioutil.ReadFile(fullPath)
 	if err != nil {
-		ctx.SetStatusCode(fasthttp.StatusNotFound)
-		ctx.SetBodyString("File not found")
+		writeError(ctx, fasthttp.StatusNotFound, "File not found")
 		return
 	}
 
@@ -46,13 +42,24 @@ func (h *Handler) handleStatic(ctx *fasthttp.RequestCtx) {
 	ctx.SetBody(content)
 }
 
+// Serve the sign in page
+func (h *Handler) handleLoginPage(ctx *fasthttp.RequestCtx) {
+	content, err := ioutil.ReadFile("web/templates/login.html")
+	if err != nil {
+		internalError(ctx, "Error reading template", err)
+		return
+	}
+
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.SetBody(content)
+}
+
 // Serve the main HTML page
 func (h *Handler) handleIndex(ctx *fasthttp.RequestCtx) {
 	// Read the HTML template
 	content, err := ioutil.ReadFile("web/templates/index.html")
 	if err != nil {
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		ctx.SetBodyString(fmt.Sprintf("Error reading template: %v", err))
+		internalError(ctx, "Error reading template", err)
 		return
 	}
 
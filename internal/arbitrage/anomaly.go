@@ -0,0 +1,206 @@
+package arbitrage
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+)
+
+// TypeAnomaly marks listings priced far below their skin's own recent
+// prices on the same marketplace, whatever Steam says
+const TypeAnomaly = "price_anomaly"
+
+// madScale turns a median absolute deviation into a standard deviation
+// estimate for normally distributed prices
+const madScale = 0.6745
+
+// AnomalyModel decides when a listing's price is anomalous
+type AnomalyModel struct {
+	// Window is how many recent observations make up the distribution
+	Window int
+	// MinObservations is the least history needed to judge a listing
+	MinObservations int
+	// MinScore is the robust z-score below the median that counts as anomalous
+	MinScore float64
+	// MinDiscountPercent ignores statistically unusual but small discounts
+	MinDiscountPercent float64
+	// TypoTolerancePercent is how close to a tenth of the median a price must
+	// be to look like a dropped zero
+	TypoTolerancePercent float64
+}
+
+// AnomalyModelFromEnv builds an anomaly model from ANOMALY_WINDOW,
+// ANOMALY_MIN_OBSERVATIONS, ANOMALY_MIN_SCORE, ANOMALY_MIN_DISCOUNT_PERCENT
+// and ANOMALY_TYPO_TOLERANCE_PERCENT
+func AnomalyModelFromEnv() AnomalyModel {
+	return AnomalyModel{
+		Window:               config.Int("ANOMALY_WINDOW", 50),
+		MinObservations:      config.Int("ANOMALY_MIN_OBSERVATIONS", 8),
+		MinScore:             config.Float("ANOMALY_MIN_SCORE", 3.5),
+		MinDiscountPercent:   config.Float("ANOMALY_MIN_DISCOUNT_PERCENT", 25),
+		TypoTolerancePercent: config.Float("ANOMALY_TYPO_TOLERANCE_PERCENT", 20),
+	}
+}
+
+// AnomalyStats describes how a price compares to its history
+type AnomalyStats struct {
+	Observations    int     `json:"observations"`
+	Median          float64 `json:"median_price"`
+	MAD             float64 `json:"mad"`
+	Score           float64 `json:"score"` // Robust z-score below the median
+	DiscountPercent float64 `json:"discount_percent"`
+	LikelyTypo      bool    `json:"likely_typo"` // About a tenth of the median
+	Confidence      float64 `json:"confidence"`  // 0 to 1
+}
+
+// Evaluate compares a price to recent prices and reports whether it is
+// anomalously low. Prices must be in the same currency.
+func (m AnomalyModel) Evaluate(price float64, history []float64) (AnomalyStats, bool) {
+	stats := AnomalyStats{Observations: len(history)}
+	if price <= 0 || len(history) < max(m.MinObservations, 1) {
+		return stats, false
+	}
+
+	stats.Median = median(history)
+	if stats.Median <= 0 || price >= stats.Median {
+		return stats, false
+	}
+
+	deviations := make([]float64, len(history))
+	for i, p := range history {
+		deviations[i] = math.Abs(p - stats.Median)
+	}
+	stats.MAD = median(deviations)
+
+	// Identical prices give a MAD of zero, so floor it to keep the score finite
+	mad := math.Max(stats.MAD, stats.Median*0.01)
+	stats.Score = madScale * (stats.Median - price) / mad
+	stats.DiscountPercent = (stats.Median - price) / stats.Median * 100
+
+	ratio := stats.Median / price
+	stats.LikelyTypo = math.Abs(ratio-10)/10*100 <= m.TypoTolerancePercent
+
+	if !stats.LikelyTypo && (stats.Score < m.MinScore || stats.DiscountPercent < m.MinDiscountPercent) {
+		return stats, false
+	}
+
+	// Confidence grows with how extreme the price is and how much history
+	// backs it up. A dropped zero is a strong signal on its own.
+	scorePart := 0.0
+	if stats.Score > 0 {
+		scorePart = math.Max(0, 1-m.MinScore/stats.Score)
+	}
+	samplePart := 1.0
+	if m.MinObservations > 0 {
+		samplePart = math.Min(1, float64(len(history))/float64(2*m.MinObservations))
+	}
+	stats.Confidence = scorePart * samplePart
+	if stats.LikelyTypo {
+		stats.Confidence = math.Max(stats.Confidence, 0.9*samplePart)
+	}
+
+	return stats, true
+}
+
+// median returns the median of values without modifying them
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// Anomaly is a listing priced far below its skin's recent prices on the
+// same marketplace
+type Anomaly struct {
+	Type           string   `json:"type"`
+	ItemID         string   `json:"item_id"`
+	MarketItemID   string   `json:"market_item_id"`
+	MarketHashName string   `json:"market_hash_name"`
+	Marketplace    string   `json:"marketplace"`
+	Category       string   `json:"category"`
+	Quality        string   `json:"quality"`
+	IconURL        string   `json:"icon_url"`
+	IsStatTrak     bool     `json:"is_stattrak"`
+	Float          *float64 `json:"float"`
+	Stickers       []string `json:"stickers"`
+
+	Price         float64 `json:"price"` // Marketplace currency
+	PriceUSD      float64 `json:"price_usd"`
+	SteamPriceUSD float64 `json:"steam_price_usd"`
+	AnomalyStats
+
+	// Reselling at the median on the same marketplace, after its sale fee
+	MedianPriceUSD        float64 `json:"median_price_usd"`
+	ExpectedProfitUSD     float64 `json:"expected_profit_usd"`
+	ExpectedProfitPercent float64 `json:"expected_profit_percent"`
+}
+
+// AnomalyFilter narrows anomaly results
+type AnomalyFilter struct {
+	MinConfidence float64
+	TyposOnly     bool
+	Limit         int
+}
+
+// FindAnomalies finds current listings priced anomalously low against their
+// skin's price history, most confident first
+func FindAnomalies(db *database.Database, filter AnomalyFilter) ([]Anomaly, error) {
+	model := AnomalyModelFromEnv()
+	listings, err := db.GetPriceHistoryListings(model.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies := make([]Anomaly, 0)
+	for _, l := range listings {
+		stats, ok := model.Evaluate(l.Price, l.History)
+		if !ok || stats.Confidence < filter.MinConfidence || (filter.TyposOnly && !stats.LikelyTypo) {
+			continue
+		}
+
+		a := Anomaly{
+			Type:           TypeAnomaly,
+			ItemID:         l.ItemID,
+			MarketItemID:   l.MarketItemID,
+			MarketHashName: l.MarketHashName,
+			Marketplace:    l.Marketplace,
+			Category:       l.Category,
+			Quality:        l.Quality,
+			IconURL:        l.IconURL,
+			IsStatTrak:     l.IsStatTrak,
+			Float:          l.Float,
+			Stickers:       l.Stickers,
+			Price:          l.Price,
+			PriceUSD:       l.PriceUSD,
+			SteamPriceUSD:  l.SteamPriceUSD,
+			AnomalyStats:   stats,
+		}
+
+		// Convert the median at the listing's own exchange rate
+		if l.PriceUSD > 0 {
+			a.MedianPriceUSD = stats.Median * l.PriceUSD / l.Price
+			a.ExpectedProfitUSD = a.MedianPriceUSD*(1-l.SaleFeePercent/100) - l.PriceUSD
+			a.ExpectedProfitPercent = a.ExpectedProfitUSD / l.PriceUSD * 100
+		}
+
+		anomalies = append(anomalies, a)
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].Confidence != anomalies[j].Confidence {
+			return anomalies[i].Confidence > anomalies[j].Confidence
+		}
+		return anomalies[i].DiscountPercent > anomalies[j].DiscountPercent
+	})
+	if filter.Limit > 0 && len(anomalies) > filter.Limit {
+		anomalies = anomalies[:filter.Limit]
+	}
+
+	return anomalies, nil
+}
@@ -0,0 +1,100 @@
+package arbitrage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{5}, 5},
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{[]float64{7, 7, 7, 7}, 7},
+	}
+
+	for _, tt := range tests {
+		values := append([]float64(nil), tt.values...)
+		if got := median(values); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+		for i := range values {
+			if values[i] != tt.values[i] {
+				t.Errorf("median(%v) reordered its input", tt.values)
+				break
+			}
+		}
+	}
+}
+
+func TestAnomalyModelEvaluate(t *testing.T) {
+	model := AnomalyModel{MinObservations: 4, MinScore: 3.5, MinDiscountPercent: 25, TypoTolerancePercent: 20}
+	history := []float64{100, 98, 102, 101, 99, 100, 97, 103}
+
+	tests := []struct {
+		name      string
+		price     float64
+		history   []float64
+		anomalous bool
+		typo      bool
+	}{
+		{"at the median", 100, history, false, false},
+		{"above the median", 150, history, false, false},
+		{"small discount", 95, history, false, false},
+		{"deep discount", 50, history, true, false},
+		{"dropped zero", 10, history, true, true},
+		{"too little history", 50, history[:3], false, false},
+		{"no history", 50, nil, false, false},
+		{"free", 0, history, false, false},
+		// Identical prices have no spread, so the MAD floor keeps it finite
+		{"flat history", 60, []float64{100, 100, 100, 100}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, ok := model.Evaluate(tt.price, tt.history)
+			if ok != tt.anomalous || stats.LikelyTypo != tt.typo {
+				t.Fatalf("anomalous %v, typo %v, want %v, %v (%+v)", ok, stats.LikelyTypo, tt.anomalous, tt.typo, stats)
+			}
+			if stats.Observations != len(tt.history) {
+				t.Errorf("observations = %d, want %d", stats.Observations, len(tt.history))
+			}
+			if ok && (stats.Confidence <= 0 || stats.Confidence > 1 || math.IsInf(stats.Score, 0)) {
+				t.Errorf("confidence %v, score %v", stats.Confidence, stats.Score)
+			}
+		})
+	}
+
+	// Zero minimum observations still needs some history
+	if _, ok := (AnomalyModel{}).Evaluate(10, nil); ok {
+		t.Error("empty history was anomalous")
+	}
+}
+
+func TestAnomalyConfidence(t *testing.T) {
+	model := AnomalyModel{MinObservations: 4, MinScore: 3.5, MinDiscountPercent: 25, TypoTolerancePercent: 20}
+	history := []float64{100, 98, 102, 101, 99, 100, 97, 103}
+
+	stats, _ := model.Evaluate(50, history)
+	if math.Abs(stats.DiscountPercent-50) > 1e-9 || stats.Median != 100 || stats.MAD != 1.5 {
+		t.Errorf("discount %v, median %v, MAD %v", stats.DiscountPercent, stats.Median, stats.MAD)
+	}
+
+	// Deeper discounts and longer histories are more convincing
+	deeper, _ := model.Evaluate(30, history)
+	shorter, _ := model.Evaluate(50, history[:4])
+	if deeper.Confidence <= stats.Confidence {
+		t.Errorf("deeper discount confidence %v, want above %v", deeper.Confidence, stats.Confidence)
+	}
+	if shorter.Confidence >= stats.Confidence {
+		t.Errorf("shorter history confidence %v, want below %v", shorter.Confidence, stats.Confidence)
+	}
+
+	typo, _ := model.Evaluate(10, history)
+	if typo.Confidence < 0.9 {
+		t.Errorf("dropped zero confidence = %v, want at least 0.9", typo.Confidence)
+	}
+}
@@ -0,0 +1,166 @@
+package arbitrage
+
+import (
+	"log"
+	"sync"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// TrackerStore is the storage the tracker records opportunities in
+type TrackerStore interface {
+	UpsertOpenOpportunity(rec *models.OpportunityRecord) error
+	GetOpenOpportunities(marketplaceID string) ([]models.OpportunityRecord, error)
+	CloseOpportunity(id, reason string) error
+}
+
+// Tracker persists the lifecycle of opportunities: it opens one when a
+// listing first qualifies, refreshes it on every scrape it still qualifies
+// in and closes it with a reason once it no longer does. It implements
+// scraper.Observer.
+type Tracker struct {
+	db               TrackerStore
+	minProfitPercent float64
+	// value looks up the current opportunity of an item, nil when it has no
+	// usable prices
+	value func(itemID string) (*Opportunity, error)
+
+	mu sync.Mutex
+	// current holds the qualifying opportunities of the scrape in progress
+	// by marketplace and item ID
+	current map[string]map[string]*Opportunity
+}
+
+// NewTracker creates a tracker treating listings with at least
+// minProfitPercent, plain or sticker-adjusted, as opportunities
+func NewTracker(db *database.Database, minProfitPercent float64) *Tracker {
+	return &Tracker{
+		db:               db,
+		minProfitPercent: minProfitPercent,
+		value: func(itemID string) (*Opportunity, error) {
+			return FindByItemID(db, itemID)
+		},
+		current: make(map[string]map[string]*Opportunity),
+	}
+}
+
+// ScrapeStarted drops the opportunities noted in an earlier scrape of the
+// marketplace that failed before completing, so they aren't recorded as
+// still open
+func (t *Tracker) ScrapeStarted(marketplaceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.current, marketplaceID)
+}
+
+// ItemProcessed notes a listing that qualifies as an opportunity, recorded
+// once its scrape completes
+func (t *Tracker) ItemProcessed(item *models.Item, opp *Opportunity) {
+	if !Qualifies(opp, t.minProfitPercent) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current[item.MarketplaceID] == nil {
+		t.current[item.MarketplaceID] = make(map[string]*Opportunity)
+	}
+	t.current[item.MarketplaceID][item.ID] = opp
+}
+
+// ScrapeCompleted records the opportunities among the listings seen in the
+// scrape and closes the open ones that are gone. Unseen listings are only
+// taken as sold after a complete scrape; invalidated ones close either way.
+func (t *Tracker) ScrapeCompleted(summary *models.ScrapeSummary) {
+	marketplaceID := summary.MarketplaceID
+	seen := make(map[string]bool, len(summary.SeenItemIDs))
+	for _, id := range summary.SeenItemIDs {
+		seen[id] = true
+	}
+	invalid := make(map[string]bool, len(summary.InvalidItemIDs))
+	for _, id := range summary.InvalidItemIDs {
+		invalid[id] = true
+	}
+
+	t.mu.Lock()
+	current := t.current[marketplaceID]
+	delete(t.current, marketplaceID)
+	t.mu.Unlock()
+
+	for _, opp := range current {
+		rec := &models.OpportunityRecord{
+			ItemID:                    opp.ItemID,
+			MarketplaceID:             marketplaceID,
+			MarketHashName:            opp.MarketHashName,
+			Category:                  opp.Category,
+			BuyPriceUSD:               opp.BuyPriceUSD,
+			SellPriceUSD:              opp.SellPriceUSD,
+			ProfitUSD:                 opp.ProfitUSD,
+			ProfitPercent:             opp.ProfitPercent,
+			ProfitWithStickersPercent: opp.ProfitWithStickersPercent,
+		}
+		if err := t.db.UpsertOpenOpportunity(rec); err != nil {
+			log.Printf("Error recording opportunity for %s: %v", opp.MarketHashName, err)
+		}
+	}
+
+	open, err := t.db.GetOpenOpportunities(marketplaceID)
+	if err != nil {
+		log.Printf("Error loading open opportunities: %v", err)
+		return
+	}
+
+	closed := 0
+	for _, rec := range open {
+		if current[rec.ItemID] != nil {
+			continue
+		}
+
+		var reason string
+		switch {
+		case invalid[rec.ItemID]:
+			reason = models.CloseReasonInvalidated
+		case !seen[rec.ItemID]:
+			if !summary.Complete {
+				continue // May be on a page the scrape didn't reach
+			}
+			reason = models.CloseReasonSold
+		default:
+			reason, err = t.closeReason(&rec)
+			if err != nil {
+				log.Printf("Error checking why opportunity %s closed: %v", rec.ID, err)
+				continue
+			}
+		}
+
+		if err := t.db.CloseOpportunity(rec.ID, reason); err != nil {
+			log.Printf("Error closing opportunity %s: %v", rec.ID, err)
+			continue
+		}
+		closed++
+	}
+
+	log.Printf("Tracked %d open opportunities, closed %d", len(current), closed)
+}
+
+// closeReason works out why an open opportunity of a listing still listed no
+// longer qualifies by comparing its current prices with the last recorded ones
+func (t *Tracker) closeReason(rec *models.OpportunityRecord) (string, error) {
+	opp, err := t.value(rec.ItemID)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case opp == nil:
+		// No usable prices left, most likely the reference price vanished
+		return models.CloseReasonSteamPriceDropped, nil
+	case opp.BuyPriceUSD > rec.BuyPriceUSD:
+		return models.CloseReasonPriceRaised, nil
+	case opp.SellPriceUSD < rec.SellPriceUSD:
+		return models.CloseReasonSteamPriceDropped, nil
+	default:
+		return models.CloseReasonBelowThreshold, nil
+	}
+}
@@ -0,0 +1,154 @@
+package arbitrage
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// fakeTrackerStore keeps opportunities in memory by item ID
+type fakeTrackerStore struct {
+	open     map[string]models.OpportunityRecord
+	upserted []string
+	closed   map[string]string // Close reason by item ID
+}
+
+func newFakeTrackerStore(open ...models.OpportunityRecord) *fakeTrackerStore {
+	s := &fakeTrackerStore{open: make(map[string]models.OpportunityRecord), closed: make(map[string]string)}
+	for _, rec := range open {
+		s.open[rec.ItemID] = rec
+	}
+	return s
+}
+
+func (s *fakeTrackerStore) UpsertOpenOpportunity(rec *models.OpportunityRecord) error {
+	rec.ID = rec.ItemID
+	s.open[rec.ItemID] = *rec
+	s.upserted = append(s.upserted, rec.ItemID)
+	return nil
+}
+
+func (s *fakeTrackerStore) GetOpenOpportunities(marketplaceID string) ([]models.OpportunityRecord, error) {
+	var records []models.OpportunityRecord
+	for _, rec := range s.open {
+		if rec.MarketplaceID == marketplaceID {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+func (s *fakeTrackerStore) CloseOpportunity(id, reason string) error {
+	delete(s.open, id)
+	s.closed[id] = reason
+	return nil
+}
+
+func newTestTracker(store *fakeTrackerStore) *Tracker {
+	return &Tracker{
+		db:               store,
+		minProfitPercent: 10,
+		value:            func(itemID string) (*Opportunity, error) { return nil, nil },
+		current:          make(map[string]map[string]*Opportunity),
+	}
+}
+
+func trackedItem(id string) *models.Item {
+	return &models.Item{ID: id, MarketplaceID: "mp"}
+}
+
+func TestTrackerDropsFailedScrape(t *testing.T) {
+	store := newFakeTrackerStore()
+	tracker := newTestTracker(store)
+
+	// A scrape notes a listing, then fails before completing
+	tracker.ScrapeStarted("mp")
+	tracker.ItemProcessed(trackedItem("sold"), &Opportunity{ItemID: "sold", ProfitPercent: 20})
+
+	// The next scrape completes without it
+	tracker.ScrapeStarted("mp")
+	tracker.ItemProcessed(trackedItem("listed"), &Opportunity{ItemID: "listed", ProfitPercent: 20})
+	tracker.ScrapeCompleted(&models.ScrapeSummary{MarketplaceID: "mp", SeenItemIDs: []string{"listed"}, Complete: true})
+
+	if len(store.upserted) != 1 || store.upserted[0] != "listed" {
+		t.Errorf("upserted %v, want [listed]", store.upserted)
+	}
+	if _, ok := store.open["sold"]; ok {
+		t.Error("listing of the failed scrape was recorded as open")
+	}
+}
+
+func TestTrackerRecordsStickerOnlyOpportunity(t *testing.T) {
+	store := newFakeTrackerStore()
+	tracker := newTestTracker(store)
+
+	tracker.ScrapeStarted("mp")
+	tracker.ItemProcessed(trackedItem("stickered"), &Opportunity{ItemID: "stickered", ProfitPercent: 2, ProfitWithStickersPercent: 30})
+	tracker.ScrapeCompleted(&models.ScrapeSummary{MarketplaceID: "mp", SeenItemIDs: []string{"stickered"}, Complete: true})
+
+	rec, ok := store.open["stickered"]
+	if !ok {
+		t.Fatal("opportunity qualifying through its stickers wasn't recorded")
+	}
+	if rec.ProfitWithStickersPercent != 30 {
+		t.Errorf("sticker-adjusted profit = %.2f, want 30", rec.ProfitWithStickersPercent)
+	}
+}
+
+func TestTrackerScrapeCompleted(t *testing.T) {
+	open := []models.OpportunityRecord{
+		{ID: "kept", ItemID: "kept", MarketplaceID: "mp"},
+		{ID: "unseen", ItemID: "unseen", MarketplaceID: "mp"},
+		{ID: "invalid", ItemID: "invalid", MarketplaceID: "mp"},
+		{ID: "below", ItemID: "below", MarketplaceID: "mp"},
+		{ID: "other", ItemID: "other", MarketplaceID: "other-mp"},
+	}
+
+	tests := []struct {
+		name     string
+		complete bool
+		want     map[string]string
+	}{
+		{"complete", true, map[string]string{
+			"unseen":  models.CloseReasonSold,
+			"invalid": models.CloseReasonInvalidated,
+			"below":   models.CloseReasonSteamPriceDropped,
+		}},
+		{"partial", false, map[string]string{
+			"invalid": models.CloseReasonInvalidated,
+			"below":   models.CloseReasonSteamPriceDropped,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeTrackerStore(open...)
+			tracker := newTestTracker(store)
+
+			tracker.ScrapeStarted("mp")
+			tracker.ItemProcessed(trackedItem("kept"), &Opportunity{ItemID: "kept", ProfitPercent: 20})
+			tracker.ItemProcessed(trackedItem("below"), &Opportunity{ItemID: "below", ProfitPercent: 5})
+			tracker.ScrapeCompleted(&models.ScrapeSummary{
+				MarketplaceID:  "mp",
+				SeenItemIDs:    []string{"kept", "below"},
+				InvalidItemIDs: []string{"invalid"},
+				Complete:       tt.complete,
+			})
+
+			if len(store.closed) != len(tt.want) {
+				var closed []string
+				for id := range store.closed {
+					closed = append(closed, id)
+				}
+				sort.Strings(closed)
+				t.Fatalf("closed %v, want %d", closed, len(tt.want))
+			}
+			for id, reason := range tt.want {
+				if store.closed[id] != reason {
+					t.Errorf("%s closed as %q, want %q", id, store.closed[id], reason)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,470 @@
+package arbitrage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/internal/valuation"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+)
+
+// SteamSaleFeePercent returns the share of the sale price Steam keeps when an
+// item sells (STEAM_SALE_FEE_PERCENT, 13% by default: the 5% Steam fee plus
+// the 10% CS2 fee, expressed on the buyer-paid price)
+func SteamSaleFeePercent() float64 {
+	return config.Float("STEAM_SALE_FEE_PERCENT", 13)
+}
+
+// Trade directions
+const (
+	// DirectionForward buys a marketplace listing and sells it on Steam
+	DirectionForward = "forward"
+	// DirectionReverse buys on Steam and sells on a marketplace
+	DirectionReverse = "reverse"
+)
+
+// SteamMarket names Steam as a buy or sell market
+const SteamMarket = "Steam"
+
+// Opportunity represents a potential arbitrage opportunity
+type Opportunity struct {
+	Direction      string   `json:"direction"`
+	BuyMarket      string   `json:"buy_market"`
+	SellMarket     string   `json:"sell_market"`
+	ItemID         string   `json:"item_id"`
+	MarketItemID   string   `json:"market_item_id"`
+	MarketHashName string   `json:"market_hash_name"`
+	BuyPriceUSD    float64  `json:"buy_price_usd"`
+	SellPriceUSD   float64  `json:"sell_price_usd"`
+	ProfitUSD      float64  `json:"profit_usd"`
+	ProfitPercent  float64  `json:"profit_percent"`
+	Marketplace    string   `json:"marketplace"`
+	Float          *float64 `json:"float"`
+	Quality        string   `json:"quality"`
+	IconURL        string   `json:"icon_url"`
+	Category       string   `json:"category"`
+	IsStatTrak     bool     `json:"is_stattrak"`
+	IsSouvenir     bool     `json:"is_souvenir"`
+	BaseSkinID     string   `json:"base_skin_id"`
+	LabelMismatch  bool     `json:"label_mismatch"`
+	Stickers       []string `json:"stickers"`
+
+	// Pattern details; PriceSource tells whether the sell price is the Steam
+	// price or a phase-specific reference price
+	PaintIndex   int     `json:"paint_index"`
+	PaintSeed    int     `json:"paint_seed"`
+	Phase        string  `json:"phase"`
+	PhaseUnknown bool    `json:"phase_unknown"`
+	FadePercent  float64 `json:"fade_percent"`
+	PriceSource  string  `json:"price_source"`
+
+	// Float-adjusted valuation of the sell side; FloatPercentile is nil when
+	// the float is unknown and the fair value is the Steam price
+	MinFloat          *float64 `json:"min_float"`
+	MaxFloat          *float64 `json:"max_float"`
+	FloatPercentile   *float64 `json:"float_percentile"`
+	FloatMultiplier   float64  `json:"float_multiplier"`
+	FairValueUSD      float64  `json:"fair_value_usd"`
+	FairProfitUSD     float64  `json:"fair_profit_usd"`
+	FairProfitPercent float64  `json:"fair_profit_percent"`
+
+	// Sticker-adjusted valuation of the sell side
+	StickerDetails            []models.Sticker `json:"sticker_details"`
+	StickerValueUSD           float64          `json:"sticker_value_usd"`
+	StickerPremiumUSD         float64          `json:"sticker_premium_usd"`
+	SellPriceWithStickersUSD  float64          `json:"sell_price_with_stickers_usd"`
+	ProfitWithStickersUSD     float64          `json:"profit_with_stickers_usd"`
+	ProfitWithStickersPercent float64          `json:"profit_with_stickers_percent"`
+
+	// Profit after the fee charged when selling on Steam
+	SaleFeeUSD       float64 `json:"sale_fee_usd"`
+	NetProfitUSD     float64 `json:"net_profit_usd"`
+	NetProfitPercent float64 `json:"net_profit_percent"`
+
+	// Steam market liquidity; nil until the Steam statistics are fetched
+	SteamLowestPriceUSD *float64 `json:"steam_lowest_price_usd"`
+	SteamMedianPriceUSD *float64 `json:"steam_median_price_usd"`
+	DailyVolume         *int     `json:"daily_volume"`
+	ListingCount        *int     `json:"listing_count"`
+	LiquidityScore      *float64 `json:"liquidity_score"`
+
+	// Capital lockup: days until the item can be sold on Steam, the expected
+	// days until it sells there, and returns accounting for that time
+	TradeHoldUntil           *time.Time `json:"trade_hold_until"`
+	DaysUntilSellable        float64    `json:"days_until_sellable"`
+	ExpectedSaleDays         float64    `json:"expected_sale_days"`
+	CapitalCostUSD           float64    `json:"capital_cost_usd"`
+	NetProfitAfterCapitalUSD float64    `json:"net_profit_after_capital_usd"`
+	AnnualizedReturnPercent  float64    `json:"annualized_return_percent"`
+	// Net of the sale fee and capital cost when the stickers fetch their premium
+	NetProfitWithStickersAfterCapitalUSD float64 `json:"net_profit_with_stickers_after_capital_usd"`
+
+	// Prices in Rial, only set for users displaying IRR
+	BuyPriceIRR  float64 `json:"buy_price_irr,omitempty"`
+	SellPriceIRR float64 `json:"sell_price_irr,omitempty"`
+	SaleFeeIRR   float64 `json:"sale_fee_irr,omitempty"`
+	NetProfitIRR float64 `json:"net_profit_irr,omitempty"`
+}
+
+// applyCapital fills in the capital figures from cv, netting the
+// sticker-adjusted sale price at saleFeePercent
+func (o *Opportunity) applyCapital(cv valuation.CapitalValuation, saleFeePercent float64) {
+	o.ExpectedSaleDays = cv.SaleDays
+	o.CapitalCostUSD = cv.CostUSD
+	o.NetProfitAfterCapitalUSD = o.NetProfitUSD - cv.CostUSD
+	o.AnnualizedReturnPercent = cv.AnnualizedReturnPercent
+	netWithStickersUSD := o.SellPriceWithStickersUSD*(1-saleFeePercent/100) - o.BuyPriceUSD
+	o.NetProfitWithStickersAfterCapitalUSD = netWithStickersUSD - cv.CostUSD
+}
+
+// SetIRRPrices fills in the Rial prices at usdToIRR Rial per dollar
+func (o *Opportunity) SetIRRPrices(usdToIRR float64) {
+	o.BuyPriceIRR = math.Round(o.BuyPriceUSD * usdToIRR)
+	o.SellPriceIRR = math.Round(o.SellPriceUSD * usdToIRR)
+	o.SaleFeeIRR = math.Round(o.SaleFeeUSD * usdToIRR)
+	o.NetProfitIRR = math.Round(o.NetProfitUSD * usdToIRR)
+}
+
+// stickerIndexTTL is how long the sticker catalogue is cached between lookups
+const stickerIndexTTL = time.Minute
+
+var (
+	cachedStickerIndex     *valuation.StickerIndex
+	cachedStickerIndexTime time.Time
+	cachedStickerIndexMu   sync.Mutex
+)
+
+// loadStickerIndex returns the sticker catalogue index, cached briefly since
+// single-item lookups run for every scraped listing
+func loadStickerIndex(db *database.Database) (*valuation.StickerIndex, error) {
+	cachedStickerIndexMu.Lock()
+	defer cachedStickerIndexMu.Unlock()
+
+	if cachedStickerIndex != nil && time.Since(cachedStickerIndexTime) < stickerIndexTTL {
+		return cachedStickerIndex, nil
+	}
+
+	stickerPrices, err := db.GetStickerPrices()
+	if err != nil {
+		return nil, fmt.Errorf("error loading sticker prices: %v", err)
+	}
+
+	cachedStickerIndex = valuation.NewStickerIndex(stickerPrices)
+	cachedStickerIndexTime = time.Now()
+	return cachedStickerIndex, nil
+}
+
+// Filter narrows the opportunities returned by Find
+type Filter struct {
+	// MinProfitPercent applies to the better of the plain and the
+	// sticker-adjusted profit before fees, in both directions; see Qualifies
+	MinProfitPercent   float64
+	MinStickerValueUSD float64
+	// MaxDaysUntilSellable excludes items trade-locked for longer; 0
+	// disables the check
+	MaxDaysUntilSellable float64
+	// MinDailyVolume excludes items selling fewer units a day on Steam,
+	// including those without statistics yet; 0 disables the check
+	MinDailyVolume int
+	// MaxBuyPriceUSD excludes items costing more; 0 disables the check
+	MaxBuyPriceUSD float64
+	// MarketHashNames restricts results to these skins, each with its own
+	// max buy price (0 for none); nil means any skin
+	MarketHashNames map[string]float64
+
+	// SteamSaleFeePercent overrides the Steam sale fee when set
+	SteamSaleFeePercent *float64
+	// MarketplaceSaleFeePercent overrides the sale fee of marketplaces by name
+	MarketplaceSaleFeePercent map[string]float64
+}
+
+// steamSaleFeePercent returns the Steam sale fee the filter values sales at
+func (f Filter) steamSaleFeePercent() float64 {
+	if f.SteamSaleFeePercent != nil {
+		return *f.SteamSaleFeePercent
+	}
+	return SteamSaleFeePercent()
+}
+
+// allowsPrice reports whether an opportunity passes the buy price and skin
+// restrictions
+func (f Filter) allowsPrice(opp *Opportunity) bool {
+	if f.MaxBuyPriceUSD > 0 && opp.BuyPriceUSD > f.MaxBuyPriceUSD {
+		return false
+	}
+	if f.MarketHashNames != nil {
+		maxPrice, ok := f.MarketHashNames[opp.MarketHashName]
+		if !ok || (maxPrice > 0 && opp.BuyPriceUSD > maxPrice) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reprice returns a forward opportunity with its sale fee, net profit and
+// capital figures recomputed at the filter's Steam sale fee, for valuing a
+// listing found at the default fee for a user with their own. Reverse
+// opportunities are returned unchanged since FindReverse already applies
+// the filter's marketplace fees.
+func (f Filter) Reprice(opp Opportunity) Opportunity {
+	if opp.Direction == DirectionReverse || opp.BuyPriceUSD <= 0 {
+		return opp
+	}
+
+	saleFeePercent := f.steamSaleFeePercent()
+	opp.SaleFeeUSD = opp.SellPriceUSD * saleFeePercent / 100
+	opp.NetProfitUSD = opp.SellPriceUSD - opp.SaleFeeUSD - opp.BuyPriceUSD
+	opp.NetProfitPercent = opp.NetProfitUSD / opp.BuyPriceUSD * 100
+
+	cv := valuation.CapitalModelFromEnv().Value(opp.BuyPriceUSD, opp.NetProfitUSD, opp.DaysUntilSellable, opp.DailyVolume, opp.ListingCount)
+	opp.applyCapital(cv, saleFeePercent)
+	return opp
+}
+
+// Find finds arbitrage opportunities matching the filter, most profitable
+// first by the better of the plain and sticker-adjusted profit
+func Find(db *database.Database, filter Filter) ([]Opportunity, error) {
+	stickerIndex, err := loadStickerIndex(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stickered listings may qualify on their sticker-adjusted profit, which
+	// is only known once valued, so they are let through as long as every
+	// sticker slot at the catalogue's highest premium would be enough
+	condition := `(ref.price_usd - i.price_usd) / i.price_usd * 100 >= $1
+		OR (ref.price_usd + cardinality(i.stickers) * $2::float8 - i.price_usd) / i.price_usd * 100 >= $1`
+	maxStickerPremium := valuation.StickerModelFromEnv().MaxPremiumUSD(stickerIndex)
+	opportunities, err := find(db, filter.steamSaleFeePercent(), condition, filter.MinProfitPercent, maxStickerPremium)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []Opportunity
+	for _, opp := range opportunities {
+		if !Qualifies(&opp, filter.MinProfitPercent) {
+			continue
+		}
+		if opp.StickerValueUSD < filter.MinStickerValueUSD {
+			continue
+		}
+		if filter.MaxDaysUntilSellable > 0 && opp.DaysUntilSellable > filter.MaxDaysUntilSellable {
+			continue
+		}
+		if filter.MinDailyVolume > 0 && (opp.DailyVolume == nil || *opp.DailyVolume < filter.MinDailyVolume) {
+			continue
+		}
+		if !filter.allowsPrice(&opp) {
+			continue
+		}
+		filtered = append(filtered, opp)
+	}
+
+	SortByProfit(filtered)
+	return filtered, nil
+}
+
+// bestProfitPercent returns the better of an opportunity's plain and
+// sticker-adjusted profit
+func bestProfitPercent(opp *Opportunity) float64 {
+	return math.Max(opp.ProfitPercent, opp.ProfitWithStickersPercent)
+}
+
+// Qualifies reports whether a listing is an opportunity at minProfitPercent,
+// on its plain or its sticker-adjusted profit. The tracker, the stream and
+// Find all share this rule so they agree on which listings are open.
+func Qualifies(opp *Opportunity, minProfitPercent float64) bool {
+	return opp != nil && bestProfitPercent(opp) >= minProfitPercent
+}
+
+// SortByProfit orders opportunities by the profit Qualifies judges them on,
+// highest first, so forward and reverse opportunities can be merged
+func SortByProfit(opportunities []Opportunity) {
+	sort.SliceStable(opportunities, func(i, j int) bool {
+		return bestProfitPercent(&opportunities[i]) > bestProfitPercent(&opportunities[j])
+	})
+}
+
+// FindByItemID values a single listing regardless of its profit, returning
+// nil when the item does not exist or has no usable prices
+func FindByItemID(db *database.Database, itemID string) (*Opportunity, error) {
+	opportunities, err := find(db, SteamSaleFeePercent(), `i.id = $1::uuid`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(opportunities) == 0 {
+		return nil, nil
+	}
+	return &opportunities[0], nil
+}
+
+// find queries listings with usable prices matching an extra SQL condition
+// and values them, charging saleFeePercent when selling on Steam
+func find(db *database.Database, saleFeePercent float64, condition string, args ...interface{}) ([]Opportunity, error) {
+	query := `
+        SELECT 
+            s.market_hash_name, 
+            i.price_usd as buy_price_usd, 
+            ref.price_usd as sell_price_usd,
+            (ref.price_usd - i.price_usd) AS profit_usd,
+            (ref.price_usd - i.price_usd) / i.price_usd * 100 AS profit_percent,
+            m.name AS marketplace,
+            i.float,
+            COALESCE(s.quality, '') AS quality,
+            s.icon_url, 
+            s.category,
+            s.is_stattrak,
+            i.stickers,
+            s.min_float,
+            s.max_float,
+            COALESCE(i.paint_index, 0) AS paint_index,
+            COALESCE(i.paint_seed, 0) AS paint_seed,
+            COALESCE(i.phase, '') AS phase,
+            COALESCE(i.fade_percent, 0) AS fade_percent,
+            ref.source AS price_source,
+            COALESCE(s.base_skin_id::text, '') AS base_skin_id,
+            s.is_souvenir,
+            i.label_mismatch,
+            i.id::text AS item_id,
+            i.market_item_id,
+            NULLIF(st.lowest_price_usd, 0) AS steam_lowest_price_usd,
+            NULLIF(st.median_price_usd, 0) AS steam_median_price_usd,
+            st.daily_volume,
+            NULLIF(st.listing_count, -1) AS listing_count,
+            i.trade_hold_until
+        FROM 
+            items i
+        JOIN 
+            skins s ON i.skin_id = s.id
+        JOIN 
+            marketplaces m ON i.marketplace_id = m.id
+        LEFT JOIN
+            steam_price_stats st ON st.skin_id = s.id
+        LEFT JOIN
+            phase_prices pp ON pp.market_hash_name = s.market_hash_name AND pp.phase = i.phase
+        CROSS JOIN LATERAL (
+            SELECT
+                COALESCE(pp.price_usd, i.steam_price_usd) AS price_usd,
+                CASE WHEN pp.price_usd IS NULL THEN 'steam' ELSE 'phase' END AS source
+        ) ref
+        WHERE 
+            ref.price_usd > 0
+            AND i.price_usd > 0
+            AND NOT i.quarantined
+            AND (` + condition + `)
+        ORDER BY 
+            profit_percent DESC
+    `
+
+	rows, err := db.ExecuteQuery(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying arbitrage opportunities: %v", err)
+	}
+
+	stickerIndex, err := loadStickerIndex(db)
+	if err != nil {
+		return nil, err
+	}
+
+	floatModel := valuation.FloatModelFromEnv()
+	stickerModel := valuation.StickerModelFromEnv()
+	liquidityModel := valuation.LiquidityModelFromEnv()
+	capitalModel := valuation.CapitalModelFromEnv()
+	now := time.Now()
+
+	var opportunities []Opportunity
+
+	for _, row := range rows {
+		opp := Opportunity{
+			Direction:      DirectionForward,
+			BuyMarket:      row.Marketplace,
+			SellMarket:     SteamMarket,
+			ItemID:         row.ItemID,
+			MarketItemID:   row.MarketItemID,
+			MarketHashName: row.MarketHashName,
+			BuyPriceUSD:    row.BuyPriceUSD,
+			SellPriceUSD:   row.SellPriceUSD,
+			ProfitUSD:      row.ProfitUSD,
+			ProfitPercent:  row.ProfitPercent,
+			Marketplace:    row.Marketplace,
+			Float:          row.Float,
+			Quality:        row.Quality,
+			IconURL:        row.IconURL,
+			Category:       row.Category,
+			IsStatTrak:     row.IsStatTrak,
+			IsSouvenir:     row.IsSouvenir,
+			BaseSkinID:     row.BaseSkinID,
+			LabelMismatch:  row.LabelMismatch,
+			Stickers:       row.Stickers,
+			MinFloat:       row.MinFloat,
+			MaxFloat:       row.MaxFloat,
+			PaintIndex:     row.PaintIndex,
+			PaintSeed:      row.PaintSeed,
+			Phase:          row.Phase,
+			FadePercent:    row.FadePercent,
+			PriceSource:    row.PriceSource,
+		}
+
+		// The Steam price of a phased finish is the cheapest phase, so an
+		// unidentified phase makes the sell price unreliable
+		opp.PhaseUnknown = row.Phase == "" && catalog.HasPhases(catalog.ParseMarketHashName(row.MarketHashName).Finish)
+
+		fv := floatModel.Value(row.SellPriceUSD, row.Float, row.MinFloat, row.MaxFloat, row.Quality)
+		if fv.Known {
+			opp.FloatPercentile = &fv.Percentile
+		}
+		opp.FloatMultiplier = fv.Multiplier
+		opp.FairValueUSD = fv.FairValueUSD
+		opp.FairProfitUSD = fv.FairValueUSD - row.BuyPriceUSD
+		opp.FairProfitPercent = opp.FairProfitUSD / row.BuyPriceUSD * 100
+
+		sv := stickerModel.Value(row.Stickers, stickerIndex)
+		opp.StickerDetails = sv.Stickers
+		opp.StickerValueUSD = sv.ValueUSD
+		opp.StickerPremiumUSD = sv.PremiumUSD
+		opp.SellPriceWithStickersUSD = row.SellPriceUSD + sv.PremiumUSD
+		opp.ProfitWithStickersUSD = opp.SellPriceWithStickersUSD - row.BuyPriceUSD
+		opp.ProfitWithStickersPercent = opp.ProfitWithStickersUSD / row.BuyPriceUSD * 100
+
+		opp.SaleFeeUSD = row.SellPriceUSD * saleFeePercent / 100
+		opp.NetProfitUSD = row.SellPriceUSD - opp.SaleFeeUSD - row.BuyPriceUSD
+		opp.NetProfitPercent = opp.NetProfitUSD / row.BuyPriceUSD * 100
+
+		opp.SteamLowestPriceUSD = row.SteamLowestPriceUSD
+		opp.SteamMedianPriceUSD = row.SteamMedianPriceUSD
+		opp.DailyVolume = row.SteamDailyVolume
+		opp.ListingCount = row.SteamListingCount
+		if row.SteamDailyVolume != nil {
+			listingCount := -1
+			if row.SteamListingCount != nil {
+				listingCount = *row.SteamListingCount
+			}
+			var lowest, median float64
+			if row.SteamLowestPriceUSD != nil {
+				lowest = *row.SteamLowestPriceUSD
+			}
+			if row.SteamMedianPriceUSD != nil {
+				median = *row.SteamMedianPriceUSD
+			}
+			score := liquidityModel.Score(*row.SteamDailyVolume, listingCount, lowest, median)
+			opp.LiquidityScore = &score
+		}
+
+		opp.TradeHoldUntil = row.TradeHoldUntil
+		if row.TradeHoldUntil != nil && row.TradeHoldUntil.After(now) {
+			opp.DaysUntilSellable = row.TradeHoldUntil.Sub(now).Hours() / 24
+		}
+		cv := capitalModel.Value(row.BuyPriceUSD, opp.NetProfitUSD, opp.DaysUntilSellable, row.SteamDailyVolume, row.SteamListingCount)
+		opp.applyCapital(cv, saleFeePercent)
+
+		opportunities = append(opportunities, opp)
+	}
+
+	return opportunities, nil
+}
@@ -0,0 +1,47 @@
+package arbitrage
+
+import "testing"
+
+func TestSortByProfit(t *testing.T) {
+	opportunities := []Opportunity{
+		{ItemID: "plain", ProfitPercent: 10, ProfitWithStickersPercent: 10},
+		{ItemID: "stickered", ProfitPercent: 2, ProfitWithStickersPercent: 30},
+		{ItemID: "best-plain", ProfitPercent: 20, ProfitWithStickersPercent: 20},
+		{ItemID: "loss", ProfitPercent: -5, ProfitWithStickersPercent: -5},
+	}
+
+	SortByProfit(opportunities)
+
+	want := []string{"stickered", "best-plain", "plain", "loss"}
+	for i, id := range want {
+		if opportunities[i].ItemID != id {
+			t.Fatalf("position %d = %s, want %s (order %v)", i, opportunities[i].ItemID, id, want)
+		}
+	}
+}
+
+func TestFilterAllowsPrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		buy    float64
+		want   bool
+	}{
+		{"no limits", Filter{}, 500, true},
+		{"under the cap", Filter{MaxBuyPriceUSD: 100}, 100, true},
+		{"over the cap", Filter{MaxBuyPriceUSD: 100}, 100.01, false},
+		{"watched without a target", Filter{MarketHashNames: map[string]float64{"AK-47 | Redline (Field-Tested)": 0}}, 500, true},
+		{"watched under its target", Filter{MarketHashNames: map[string]float64{"AK-47 | Redline (Field-Tested)": 20}}, 20, true},
+		{"watched over its target", Filter{MarketHashNames: map[string]float64{"AK-47 | Redline (Field-Tested)": 20}}, 21, false},
+		{"not watched", Filter{MarketHashNames: map[string]float64{"AWP | Asiimov (Field-Tested)": 0}}, 5, false},
+		{"empty watchlist", Filter{MarketHashNames: map[string]float64{}}, 5, false},
+		{"under its target over the cap", Filter{MaxBuyPriceUSD: 10, MarketHashNames: map[string]float64{"AK-47 | Redline (Field-Tested)": 20}}, 15, false},
+	}
+
+	for _, tt := range tests {
+		opp := Opportunity{MarketHashName: "AK-47 | Redline (Field-Tested)", BuyPriceUSD: tt.buy}
+		if got := tt.filter.allowsPrice(&opp); got != tt.want {
+			t.Errorf("%s: allowsPrice = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
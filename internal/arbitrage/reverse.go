@@ -0,0 +1,136 @@
+package arbitrage
+
+import (
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/valuation"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+)
+
+// ReverseModel holds the costs of buying on Steam to sell on a marketplace
+type ReverseModel struct {
+	// WalletPremiumPercent is what a dollar of Steam wallet costs above the
+	// exchange rate, since wallet funds are bought with rial at a markup
+	WalletPremiumPercent float64
+	// UndercutPercent is how far below the marketplace's lowest ask we list
+	UndercutPercent float64
+	// HoldDays is the trade hold on items bought from the Steam market
+	HoldDays float64
+}
+
+// ReverseModelFromEnv builds a reverse model from STEAM_WALLET_PREMIUM_PERCENT,
+// REVERSE_UNDERCUT_PERCENT and STEAM_PURCHASE_HOLD_DAYS
+func ReverseModelFromEnv() ReverseModel {
+	return ReverseModel{
+		WalletPremiumPercent: config.Float("STEAM_WALLET_PREMIUM_PERCENT", 0),
+		UndercutPercent:      config.Float("REVERSE_UNDERCUT_PERCENT", 1),
+		HoldDays:             config.Float("STEAM_PURCHASE_HOLD_DAYS", 7),
+	}
+}
+
+// FindReverse finds skins that cost less on Steam, including the wallet
+// premium, than they would net when listed just under the lowest ask on a
+// marketplace after its fees, most profitable first. They qualify and are
+// ordered on the same profit as forward opportunities.
+func FindReverse(db *database.Database, filter Filter) ([]Opportunity, error) {
+	// An arbitrary Steam listing carries no known stickers
+	if filter.MinStickerValueUSD > 0 {
+		return nil, nil
+	}
+
+	asks, err := db.GetMarketAsks(0)
+	if err != nil {
+		return nil, err
+	}
+
+	model := ReverseModelFromEnv()
+	capitalModel := valuation.CapitalModelFromEnv()
+	liquidityModel := valuation.LiquidityModelFromEnv()
+	holdUntil := time.Now().Add(time.Duration(model.HoldDays * float64(24*time.Hour)))
+
+	var opportunities []Opportunity
+	for _, ask := range asks {
+		if ask.SteamPriceUSD <= 0 {
+			continue
+		}
+
+		opp := reverseOpportunity(ask, filter, model, capitalModel)
+		if !Qualifies(&opp, filter.MinProfitPercent) {
+			continue
+		}
+		if filter.MaxDaysUntilSellable > 0 && opp.DaysUntilSellable > filter.MaxDaysUntilSellable {
+			continue
+		}
+		if filter.MinDailyVolume > 0 && (opp.DailyVolume == nil || *opp.DailyVolume < filter.MinDailyVolume) {
+			continue
+		}
+		if !filter.allowsPrice(&opp) {
+			continue
+		}
+
+		if ask.DailyVolume != nil {
+			score := liquidityModel.Score(*ask.DailyVolume, -1, 0, 0)
+			opp.LiquidityScore = &score
+		}
+		opp.TradeHoldUntil = &holdUntil
+
+		opportunities = append(opportunities, opp)
+	}
+
+	SortByProfit(opportunities)
+	return opportunities, nil
+}
+
+// reverseOpportunity prices buying a skin on Steam and listing it just under
+// its lowest ask on a marketplace, at the filter's fee for the marketplace
+func reverseOpportunity(ask database.MarketAsk, filter Filter, model ReverseModel, capitalModel valuation.CapitalModel) Opportunity {
+	sell := Ask{
+		Market:            ask.Marketplace,
+		LowestPriceUSD:    ask.LowestPriceUSD,
+		SaleFeePercent:    ask.SaleFeePercent,
+		ListingFeePercent: ask.ListingFeePercent,
+	}
+	if override, ok := filter.MarketplaceSaleFeePercent[ask.Marketplace]; ok {
+		sell.SaleFeePercent = override
+	}
+	route := priceRoute(Ask{Market: SteamMarket, LowestPriceUSD: ask.SteamPriceUSD}, sell, model)
+
+	opp := Opportunity{
+		Direction:        DirectionReverse,
+		BuyMarket:        route.BuyMarket,
+		SellMarket:       route.SellMarket,
+		MarketHashName:   ask.MarketHashName,
+		BuyPriceUSD:      route.BuyPriceUSD,
+		SellPriceUSD:     route.SellPriceUSD,
+		ProfitUSD:        route.SellPriceUSD - route.BuyPriceUSD,
+		Marketplace:      ask.Marketplace,
+		Quality:          ask.Quality,
+		IconURL:          ask.IconURL,
+		Category:         ask.Category,
+		IsStatTrak:       ask.IsStatTrak,
+		PriceSource:      "steam",
+		SaleFeeUSD:       route.FeesUSD,
+		NetProfitUSD:     route.NetProfitUSD,
+		NetProfitPercent: route.NetProfitPercent,
+		DailyVolume:      ask.DailyVolume,
+		// Items bought on the Steam market are trade-locked before they can
+		// be sent to the marketplace
+		DaysUntilSellable: model.HoldDays,
+	}
+	opp.ProfitPercent = opp.ProfitUSD / opp.BuyPriceUSD * 100
+
+	// Sticker and float premiums don't apply to an arbitrary Steam listing
+	opp.FloatMultiplier = 1
+	opp.FairValueUSD = opp.SellPriceUSD
+	opp.FairProfitUSD = opp.ProfitUSD
+	opp.FairProfitPercent = opp.ProfitPercent
+	opp.SellPriceWithStickersUSD = opp.SellPriceUSD
+	opp.ProfitWithStickersUSD = opp.ProfitUSD
+	opp.ProfitWithStickersPercent = opp.ProfitPercent
+
+	cv := capitalModel.Value(opp.BuyPriceUSD, opp.NetProfitUSD, model.HoldDays, nil, nil)
+	opp.applyCapital(cv, sell.SaleFeePercent+sell.ListingFeePercent)
+
+	return opp
+}
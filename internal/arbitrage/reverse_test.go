@@ -0,0 +1,67 @@
+package arbitrage
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/valuation"
+)
+
+func TestReverseOpportunity(t *testing.T) {
+	model := ReverseModel{WalletPremiumPercent: 10, UndercutPercent: 1, HoldDays: 7}
+	capitalModel := valuation.CapitalModel{AnnualCostPercent: 36.5, DefaultSaleDays: 1, MaxSaleDays: 30}
+	ask := database.MarketAsk{
+		MarketHashName:    "AK-47 | Redline (Field-Tested)",
+		Marketplace:       "csgoskin.ir",
+		LowestPriceUSD:    20,
+		SaleFeePercent:    5,
+		ListingFeePercent: 1,
+		SteamPriceUSD:     10,
+	}
+
+	tests := []struct {
+		name         string
+		filter       Filter
+		wantFeeUSD   float64
+		wantNetUSD   float64
+		wantGrossPct float64
+	}{
+		// Buy at 11 with the wallet premium, list at 19.8 less 6% in fees
+		{"marketplace fees", Filter{}, 1.188, 7.612, 80},
+		{"fee override", Filter{MarketplaceSaleFeePercent: map[string]float64{"csgoskin.ir": 0}}, 0.198, 8.602, 80},
+	}
+
+	for _, tt := range tests {
+		opp := reverseOpportunity(ask, tt.filter, model, capitalModel)
+		if opp.Direction != DirectionReverse || opp.BuyMarket != SteamMarket || opp.SellMarket != "csgoskin.ir" {
+			t.Errorf("%s: route %s %s→%s", tt.name, opp.Direction, opp.BuyMarket, opp.SellMarket)
+		}
+		if math.Abs(opp.BuyPriceUSD-11) > 1e-9 || math.Abs(opp.SellPriceUSD-19.8) > 1e-9 {
+			t.Errorf("%s: buy, sell = %v, %v, want 11, 19.8", tt.name, opp.BuyPriceUSD, opp.SellPriceUSD)
+		}
+		if math.Abs(opp.SaleFeeUSD-tt.wantFeeUSD) > 1e-9 || math.Abs(opp.NetProfitUSD-tt.wantNetUSD) > 1e-9 {
+			t.Errorf("%s: fee, net = %v, %v, want %v, %v", tt.name, opp.SaleFeeUSD, opp.NetProfitUSD, tt.wantFeeUSD, tt.wantNetUSD)
+		}
+		if math.Abs(opp.NetProfitPercent-tt.wantNetUSD/11*100) > 1e-9 || math.Abs(opp.ProfitPercent-tt.wantGrossPct) > 1e-9 {
+			t.Errorf("%s: net, gross percent = %v, %v", tt.name, opp.NetProfitPercent, opp.ProfitPercent)
+		}
+		// Minimums apply to the gross profit, as for forward opportunities
+		if !Qualifies(&opp, tt.wantGrossPct) || Qualifies(&opp, tt.wantGrossPct+1) {
+			t.Errorf("%s: qualifies at %v%% but not above", tt.name, tt.wantGrossPct)
+		}
+		// A week of trade hold and a default day to sell at 0.1% a day
+		if math.Abs(opp.CapitalCostUSD-11*0.001*8) > 1e-9 || math.Abs(opp.NetProfitWithStickersAfterCapitalUSD-opp.NetProfitAfterCapitalUSD) > 1e-9 {
+			t.Errorf("%s: capital cost %v, after capital %v / %v", tt.name, opp.CapitalCostUSD, opp.NetProfitAfterCapitalUSD, opp.NetProfitWithStickersAfterCapitalUSD)
+		}
+	}
+}
+
+func TestFindReverseWithStickerMinimum(t *testing.T) {
+	// Steam listings have no known stickers, so nothing qualifies and the
+	// database isn't consulted
+	opportunities, err := FindReverse(nil, Filter{MinStickerValueUSD: 1})
+	if err != nil || len(opportunities) != 0 {
+		t.Errorf("FindReverse = %v, %v, want none", opportunities, err)
+	}
+}
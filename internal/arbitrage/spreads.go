@@ -0,0 +1,179 @@
+package arbitrage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+)
+
+// Ask is the lowest price of a skin on one market, in USD
+type Ask struct {
+	Market            string  `json:"market"`
+	LowestPriceUSD    float64 `json:"lowest_price_usd"`
+	ListingCount      int     `json:"listing_count,omitempty"`
+	SaleFeePercent    float64 `json:"sale_fee_percent"`
+	ListingFeePercent float64 `json:"listing_fee_percent"`
+}
+
+// Route is the result of buying on one market and selling on another
+type Route struct {
+	BuyMarket        string  `json:"buy_market"`
+	SellMarket       string  `json:"sell_market"`
+	BuyPriceUSD      float64 `json:"buy_price_usd"`  // Including the Steam wallet premium when buying on Steam
+	SellPriceUSD     float64 `json:"sell_price_usd"` // Listing price, just under the lowest ask
+	FeesUSD          float64 `json:"fees_usd"`
+	NetProfitUSD     float64 `json:"net_profit_usd"`
+	NetProfitPercent float64 `json:"net_profit_percent"`
+}
+
+// Spread compares the lowest asks of one skin, or one float band of it,
+// across every market
+type Spread struct {
+	SkinID         string   `json:"skin_id"`
+	MarketHashName string   `json:"market_hash_name"`
+	Category       string   `json:"category"`
+	IconURL        string   `json:"icon_url"`
+	FloatBandMin   *float64 `json:"float_band_min"`
+	FloatBandMax   *float64 `json:"float_band_max"`
+	Asks           []Ask    `json:"asks"`
+	Best           *Route   `json:"best"` // nil when fewer than two markets list it
+}
+
+// SpreadFilter narrows the spreads returned by Spreads
+type SpreadFilter struct {
+	FloatBandWidth   float64 // Split skins into float bands of this width; 0 disables
+	MinProfitPercent float64 // On the best route; ignored when HasMinProfit is false
+	HasMinProfit     bool
+	Name             string // Case-insensitive substring of the market hash name
+	Category         string
+	Limit            int // Most spreads returned; 0 for all
+}
+
+// Spreads builds the spread matrix: the lowest ask of every skin on each
+// marketplace and on Steam, and the most profitable buy→sell route after
+// fees, best routes first. It also returns how many spreads matched before
+// the limit.
+func Spreads(db *database.Database, filter SpreadFilter) ([]Spread, int, error) {
+	asks, err := db.GetMarketAsks(filter.FloatBandWidth)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	model := ReverseModelFromEnv()
+	steamFee := SteamSaleFeePercent()
+
+	var spreads []Spread
+	byKey := make(map[string]int)
+	for _, ask := range asks {
+		if filter.Name != "" && !strings.Contains(strings.ToLower(ask.MarketHashName), strings.ToLower(filter.Name)) {
+			continue
+		}
+		if filter.Category != "" && !strings.EqualFold(ask.Category, filter.Category) {
+			continue
+		}
+
+		key := ask.SkinID
+		if ask.FloatBandMin != nil {
+			key += fmt.Sprintf("|%g", *ask.FloatBandMin)
+		}
+		i, ok := byKey[key]
+		if !ok {
+			spreads = append(spreads, Spread{
+				SkinID:         ask.SkinID,
+				MarketHashName: ask.MarketHashName,
+				Category:       ask.Category,
+				IconURL:        ask.IconURL,
+				FloatBandMin:   ask.FloatBandMin,
+				FloatBandMax:   ask.FloatBandMax,
+			})
+			i = len(spreads) - 1
+			byKey[key] = i
+
+			// Steam prices the skin as a whole, so it appears in every band
+			if ask.SteamPriceUSD > 0 {
+				spreads[i].Asks = append(spreads[i].Asks, Ask{
+					Market:         SteamMarket,
+					LowestPriceUSD: ask.SteamPriceUSD,
+					SaleFeePercent: steamFee,
+				})
+			}
+		}
+
+		spreads[i].Asks = append(spreads[i].Asks, Ask{
+			Market:            ask.Marketplace,
+			LowestPriceUSD:    ask.LowestPriceUSD,
+			ListingCount:      ask.ListingCount,
+			SaleFeePercent:    ask.SaleFeePercent,
+			ListingFeePercent: ask.ListingFeePercent,
+		})
+	}
+
+	var filtered []Spread
+	for _, spread := range spreads {
+		spread.Best = bestRoute(spread.Asks, model)
+		if filter.HasMinProfit && (spread.Best == nil || spread.Best.NetProfitPercent < filter.MinProfitPercent) {
+			continue
+		}
+		filtered = append(filtered, spread)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := filtered[i].Best, filtered[j].Best
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.NetProfitPercent > b.NetProfitPercent
+	})
+
+	total := len(filtered)
+	if filter.Limit > 0 && filter.Limit < total {
+		filtered = filtered[:filter.Limit]
+	}
+	return filtered, total, nil
+}
+
+// bestRoute tries every ordered pair of markets and returns the one with the
+// highest net profit
+func bestRoute(asks []Ask, model ReverseModel) *Route {
+	var best *Route
+	for _, buy := range asks {
+		for _, sell := range asks {
+			if buy.Market == sell.Market {
+				continue
+			}
+
+			route := priceRoute(buy, sell, model)
+			if best == nil || route.NetProfitUSD > best.NetProfitUSD {
+				best = &route
+			}
+		}
+	}
+	return best
+}
+
+// priceRoute computes the net profit of buying at one market's lowest ask and
+// listing just under another's
+func priceRoute(buy, sell Ask, model ReverseModel) Route {
+	buyPrice := buy.LowestPriceUSD
+	if buy.Market == SteamMarket {
+		buyPrice *= 1 + model.WalletPremiumPercent/100
+	}
+
+	sellPrice := sell.LowestPriceUSD * (1 - model.UndercutPercent/100)
+	fees := sellPrice * (sell.SaleFeePercent + sell.ListingFeePercent) / 100
+
+	route := Route{
+		BuyMarket:    buy.Market,
+		SellMarket:   sell.Market,
+		BuyPriceUSD:  buyPrice,
+		SellPriceUSD: sellPrice,
+		FeesUSD:      fees,
+		NetProfitUSD: sellPrice - fees - buyPrice,
+	}
+	if buyPrice > 0 {
+		route.NetProfitPercent = route.NetProfitUSD / buyPrice * 100
+	}
+	return route
+}
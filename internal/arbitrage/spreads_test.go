@@ -0,0 +1,76 @@
+package arbitrage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceRoute(t *testing.T) {
+	model := ReverseModel{WalletPremiumPercent: 10, UndercutPercent: 1}
+
+	tests := []struct {
+		name      string
+		buy, sell Ask
+		want      Route
+	}{
+		{
+			name: "steam to marketplace",
+			buy:  Ask{Market: SteamMarket, LowestPriceUSD: 10, SaleFeePercent: 13},
+			sell: Ask{Market: "a", LowestPriceUSD: 20, SaleFeePercent: 5, ListingFeePercent: 1},
+			// Wallet premium on the buy, undercut and both fees on the sale
+			want: Route{BuyMarket: SteamMarket, SellMarket: "a", BuyPriceUSD: 11, SellPriceUSD: 19.8, FeesUSD: 1.188, NetProfitUSD: 7.612, NetProfitPercent: 69.2},
+		},
+		{
+			name: "marketplace to steam",
+			buy:  Ask{Market: "a", LowestPriceUSD: 10, SaleFeePercent: 5},
+			sell: Ask{Market: SteamMarket, LowestPriceUSD: 10, SaleFeePercent: 13},
+			want: Route{BuyMarket: "a", SellMarket: SteamMarket, BuyPriceUSD: 10, SellPriceUSD: 9.9, FeesUSD: 1.287, NetProfitUSD: -1.387, NetProfitPercent: -13.87},
+		},
+		{
+			name: "free listing",
+			buy:  Ask{Market: "a", LowestPriceUSD: 0},
+			sell: Ask{Market: "b", LowestPriceUSD: 10},
+			want: Route{BuyMarket: "a", SellMarket: "b", SellPriceUSD: 9.9, NetProfitUSD: 9.9},
+		},
+	}
+
+	for _, tt := range tests {
+		got := priceRoute(tt.buy, tt.sell, model)
+		if got.BuyMarket != tt.want.BuyMarket || got.SellMarket != tt.want.SellMarket ||
+			math.Abs(got.BuyPriceUSD-tt.want.BuyPriceUSD) > 1e-9 ||
+			math.Abs(got.SellPriceUSD-tt.want.SellPriceUSD) > 1e-9 ||
+			math.Abs(got.FeesUSD-tt.want.FeesUSD) > 1e-9 ||
+			math.Abs(got.NetProfitUSD-tt.want.NetProfitUSD) > 1e-9 ||
+			math.Abs(got.NetProfitPercent-tt.want.NetProfitPercent) > 1e-9 {
+			t.Errorf("%s: priceRoute = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBestRoute(t *testing.T) {
+	model := ReverseModel{UndercutPercent: 0}
+
+	asks := []Ask{
+		{Market: SteamMarket, LowestPriceUSD: 15, SaleFeePercent: 13},
+		{Market: "cheap", LowestPriceUSD: 10, SaleFeePercent: 10},
+		{Market: "dear", LowestPriceUSD: 14, SaleFeePercent: 2},
+	}
+	// Selling on Steam nets 13.05, on "dear" 13.72, both bought on "cheap"
+	best := bestRoute(asks, model)
+	if best == nil || best.BuyMarket != "cheap" || best.SellMarket != "dear" {
+		t.Fatalf("bestRoute = %+v, want cheap→dear", best)
+	}
+	if math.Abs(best.NetProfitUSD-3.72) > 1e-9 {
+		t.Errorf("net profit = %v, want 3.72", best.NetProfitUSD)
+	}
+
+	if best := bestRoute(asks[:1], model); best != nil {
+		t.Errorf("bestRoute of one market = %+v, want nil", best)
+	}
+
+	// With every route at a loss the least bad one is still reported
+	losing := []Ask{{Market: "a", LowestPriceUSD: 10, SaleFeePercent: 10}, {Market: "b", LowestPriceUSD: 10, SaleFeePercent: 20}}
+	if best := bestRoute(losing, model); best == nil || best.SellMarket != "a" || best.NetProfitUSD >= 0 {
+		t.Errorf("bestRoute of losing routes = %+v, want the loss selling on a", best)
+	}
+}
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Scopes an API key can grant. Admin implies every other scope.
+const (
+	ScopeRead    = "read"    // Read market data and opportunities
+	ScopeWrite   = "write"   // Manage the user's own settings, lists and alert rules
+	ScopeRefresh = "refresh" // Trigger scrapes and catalogue reloads
+	ScopeAdmin   = "admin"   // Manage keys, alerts and the portfolio
+)
+
+// Scopes lists every valid scope
+var Scopes = []string{ScopeRead, ScopeWrite, ScopeRefresh, ScopeAdmin}
+
+// KeyPrefix starts every generated API key, so leaked keys are easy to spot
+const KeyPrefix = "csa_"
+
+// SessionCookie is the cookie holding the web UI session token
+const SessionCookie = "cs2a_session"
+
+// ErrInvalidKey is returned when signing in with an unknown or revoked key
+var ErrInvalidKey = errors.New("invalid API key")
+
+// ErrInvalidPassword is returned when signing in with an unknown username or
+// a wrong password
+var ErrInvalidPassword = errors.New("invalid username or password")
+
+// MinPasswordLength is the shortest password accepted for a user
+const MinPasswordLength = 8
+
+// dummyPasswordHash is a bcrypt hash of a random password at the default
+// cost. Passwords for unknown usernames are checked against it, so signing
+// in takes as long whether or not the username exists.
+const dummyPasswordHash = "$2a$10$QQ/iCZHYFShIcMGkJQf5RuuEz7VSAwmzarL7Yt324bLkSE89T1vGC"
+
+// Principal is who a request is made by
+type Principal struct {
+	Name     string   `json:"name"`
+	APIKeyID *string  `json:"api_key_id"` // nil for the bootstrap admin key
+	UserID   *string  `json:"user_id"`    // nil when not acting for a user
+	Scopes   []string `json:"scopes"`
+	Session  bool     `json:"session"` // Signed in through the web UI
+}
+
+// Has reports whether the principal was granted a scope
+func (p *Principal) Has(scope string) bool {
+	return slices.Contains(p.Scopes, ScopeAdmin) || slices.Contains(p.Scopes, scope)
+}
+
+// Key identifies the principal for per-client limits: the user it acts for,
+// else its API key, so every session and key of a user shares one allowance
+func (p *Principal) Key() string {
+	switch {
+	case p.UserID != nil:
+		return "user:" + *p.UserID
+	case p.APIKeyID != nil:
+		return "key:" + *p.APIKeyID
+	}
+	return "key:bootstrap"
+}
+
+// ValidScopes reports whether every scope is known
+func ValidScopes(scopes []string) bool {
+	for _, s := range scopes {
+		if !slices.Contains(Scopes, s) {
+			return false
+		}
+	}
+	return len(scopes) > 0
+}
+
+// HashPassword returns the bcrypt hash of a password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether a password matches a bcrypt hash
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// GenerateKey returns a new random API key
+func GenerateKey() (string, error) {
+	return randomToken(KeyPrefix)
+}
+
+// HashToken returns the hex SHA-256 of an API key or session token, which is
+// what gets stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns a prefix followed by 32 random bytes in hex
+func randomToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// Store is the storage the authenticator needs
+type Store interface {
+	GetActiveAPIKey(keyHash string) (*models.APIKey, error)
+	GetUserByUsername(username string) (*models.User, error)
+	InsertSession(tokenHash string, apiKeyID, userID *string, bootstrapKeyHash string, scopes []string, expiresAt time.Time) error
+	GetSession(tokenHash, bootstrapKeyHash string) (*models.Session, error)
+	DeleteSession(tokenHash string) error
+	DeleteExpiredSessions() error
+}
+
+// Authenticator resolves requests to principals from API keys and sessions
+type Authenticator struct {
+	db Store
+	// Enabled turns authentication on; when off every request is admin
+	Enabled bool
+	// adminKeyHash is the hash of ADMIN_API_KEY, a key with every scope
+	// that lets the first real keys be created
+	adminKeyHash string
+	SessionTTL   time.Duration
+	SecureCookie bool
+}
+
+// NewAuthenticator creates an authenticator configured from AUTH_ENABLED,
+// ADMIN_API_KEY, SESSION_TTL and AUTH_COOKIE_SECURE. The session cookie is
+// only sent over HTTPS unless AUTH_COOKIE_SECURE is false.
+func NewAuthenticator(db Store) *Authenticator {
+	a := &Authenticator{
+		db:           db,
+		Enabled:      config.Bool("AUTH_ENABLED", true),
+		SessionTTL:   config.Duration("SESSION_TTL", 7*24*time.Hour),
+		SecureCookie: config.Bool("AUTH_COOKIE_SECURE", true),
+	}
+	if key := config.String("ADMIN_API_KEY", ""); key != "" {
+		a.adminKeyHash = HashToken(key)
+	}
+
+	if !a.Enabled {
+		log.Printf("Warning: API authentication is disabled (AUTH_ENABLED=false)")
+	} else if a.adminKeyHash == "" {
+		log.Printf("Warning: ADMIN_API_KEY is not set; only API keys already stored can be used")
+	}
+	return a
+}
+
+// Authenticate resolves the principal of a request from an API key in the
+// Authorization (Bearer) or X-API-Key header, or from the session cookie.
+// It returns nil when the request carries no valid credentials.
+func (a *Authenticator) Authenticate(ctx *fasthttp.RequestCtx) (*Principal, error) {
+	if !a.Enabled {
+		return &Principal{Name: "anonymous", Scopes: []string{ScopeAdmin}}, nil
+	}
+
+	if key := requestKey(ctx); key != "" {
+		return a.lookupKey(key)
+	}
+
+	if token := string(ctx.Request.Header.Cookie(SessionCookie)); token != "" {
+		session, err := a.db.GetSession(HashToken(token), a.adminKeyHash)
+		if err != nil || session == nil {
+			return nil, err
+		}
+		return &Principal{Name: "session", APIKeyID: session.APIKeyID, UserID: session.UserID, Scopes: session.Scopes, Session: true}, nil
+	}
+
+	return nil, nil
+}
+
+// lookupKey resolves an API key to its principal
+func (a *Authenticator) lookupKey(key string) (*Principal, error) {
+	hash := HashToken(key)
+	if a.adminKeyHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(a.adminKeyHash)) == 1 {
+		return &Principal{Name: "admin", Scopes: []string{ScopeAdmin}}, nil
+	}
+
+	apiKey, err := a.db.GetActiveAPIKey(hash)
+	if err != nil || apiKey == nil {
+		return nil, err
+	}
+	return &Principal{Name: apiKey.Name, APIKeyID: &apiKey.ID, UserID: apiKey.UserID, Scopes: apiKey.Scopes}, nil
+}
+
+// requestKey returns the API key sent with a request, if any
+func requestKey(ctx *fasthttp.RequestCtx) string {
+	if header := string(ctx.Request.Header.Peek("Authorization")); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(string(ctx.Request.Header.Peek("X-API-Key")))
+}
+
+// Login exchanges an API key for a web UI session, setting its cookie
+func (a *Authenticator) Login(ctx *fasthttp.RequestCtx, apiKey string) (*Principal, error) {
+	principal, err := a.lookupKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return nil, ErrInvalidKey
+	}
+	return a.startSession(ctx, principal)
+}
+
+// LoginPassword signs a user in with their username and password, setting
+// the session cookie
+func (a *Authenticator) LoginPassword(ctx *fasthttp.RequestCtx, username, password string) (*Principal, error) {
+	user, err := a.db.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		CheckPassword(dummyPasswordHash, password)
+		return nil, ErrInvalidPassword
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return nil, ErrInvalidPassword
+	}
+	return a.startSession(ctx, &Principal{Name: user.Username, UserID: &user.ID, Scopes: user.Scopes})
+}
+
+// startSession stores a new session for a principal and sets its cookie. A
+// session of the bootstrap admin key records the key's hash, so rotating
+// ADMIN_API_KEY ends it.
+func (a *Authenticator) startSession(ctx *fasthttp.RequestCtx, principal *Principal) (*Principal, error) {
+	token, err := randomToken("")
+	if err != nil {
+		return nil, err
+	}
+	bootstrapKeyHash := ""
+	if principal.APIKeyID == nil && principal.UserID == nil {
+		bootstrapKeyHash = a.adminKeyHash
+	}
+	expiresAt := time.Now().Add(a.SessionTTL)
+	if err := a.db.InsertSession(HashToken(token), principal.APIKeyID, principal.UserID, bootstrapKeyHash, principal.Scopes, expiresAt); err != nil {
+		return nil, err
+	}
+
+	a.setCookie(ctx, token, expiresAt)
+	principal.Session = true
+	return principal, nil
+}
+
+// Logout ends the request's session and clears its cookie
+func (a *Authenticator) Logout(ctx *fasthttp.RequestCtx) error {
+	token := string(ctx.Request.Header.Cookie(SessionCookie))
+	a.setCookie(ctx, "", time.Unix(0, 0))
+	if token == "" {
+		return nil
+	}
+	return a.db.DeleteSession(HashToken(token))
+}
+
+// setCookie sets the session cookie, expiring it when expiresAt is past
+func (a *Authenticator) setCookie(ctx *fasthttp.RequestCtx, token string, expiresAt time.Time) {
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(SessionCookie)
+	cookie.SetValue(token)
+	cookie.SetPath("/")
+	cookie.SetExpire(expiresAt)
+	cookie.SetHTTPOnly(true)
+	cookie.SetSecure(a.SecureCookie)
+	cookie.SetSameSite(fasthttp.CookieSameSiteLaxMode)
+	ctx.Response.Header.SetCookie(cookie)
+}
+
+// StartCleanup periodically removes expired sessions
+func (a *Authenticator) StartCleanup(interval time.Duration) {
+	go func() {
+		for {
+			if err := a.db.DeleteExpiredSessions(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
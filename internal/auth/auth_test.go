@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// storedSession is a session as the database keeps it
+type storedSession struct {
+	models.Session
+	bootstrapKeyHash string
+}
+
+// fakeStore keeps keys, users and sessions in memory
+type fakeStore struct {
+	keys     map[string]*models.APIKey // By key hash
+	users    map[string]*models.User   // By username
+	sessions map[string]storedSession  // By token hash
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		keys:     make(map[string]*models.APIKey),
+		users:    make(map[string]*models.User),
+		sessions: make(map[string]storedSession),
+	}
+}
+
+func (s *fakeStore) GetActiveAPIKey(keyHash string) (*models.APIKey, error) {
+	k := s.keys[keyHash]
+	if k == nil || k.RevokedAt != nil {
+		return nil, nil
+	}
+	return k, nil
+}
+
+func (s *fakeStore) GetUserByUsername(username string) (*models.User, error) {
+	return s.users[username], nil
+}
+
+func (s *fakeStore) InsertSession(tokenHash string, apiKeyID, userID *string, bootstrapKeyHash string, scopes []string, expiresAt time.Time) error {
+	s.sessions[tokenHash] = storedSession{
+		Session:          models.Session{APIKeyID: apiKeyID, UserID: userID, Scopes: scopes, ExpiresAt: expiresAt},
+		bootstrapKeyHash: bootstrapKeyHash,
+	}
+	return nil
+}
+
+func (s *fakeStore) GetSession(tokenHash, bootstrapKeyHash string) (*models.Session, error) {
+	stored, ok := s.sessions[tokenHash]
+	if !ok || !stored.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	if stored.APIKeyID == nil && stored.UserID == nil && (stored.bootstrapKeyHash == "" || stored.bootstrapKeyHash != bootstrapKeyHash) {
+		return nil, nil
+	}
+	return &stored.Session, nil
+}
+
+func (s *fakeStore) DeleteSession(tokenHash string) error {
+	delete(s.sessions, tokenHash)
+	return nil
+}
+
+func (s *fakeStore) DeleteExpiredSessions() error {
+	return nil
+}
+
+// request returns a request context with the given headers
+func request(headers map[string]string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	for name, value := range headers {
+		ctx.Request.Header.Set(name, value)
+	}
+	return ctx
+}
+
+// sessionCookie returns the session token set on a response
+func sessionCookie(ctx *fasthttp.RequestCtx) string {
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+	cookie.SetKey(SessionCookie)
+	if !ctx.Response.Header.Cookie(cookie) {
+		return ""
+	}
+	return string(cookie.Value())
+}
+
+func TestPrincipalHas(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{ScopeRead}, ScopeRead, true},
+		{[]string{ScopeRead}, ScopeRefresh, false},
+		{[]string{ScopeRead}, ScopeAdmin, false},
+		{[]string{ScopeRead, ScopeRefresh}, ScopeRefresh, true},
+		{[]string{ScopeAdmin}, ScopeRead, true},
+		{[]string{ScopeAdmin}, ScopeRefresh, true},
+		{nil, ScopeRead, false},
+	}
+
+	for _, tt := range tests {
+		p := Principal{Scopes: tt.scopes}
+		if got := p.Has(tt.scope); got != tt.want {
+			t.Errorf("%v.Has(%s) = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "bootstrap-key")
+	db := newFakeStore()
+	userID := "user-1"
+	db.keys[HashToken("csa_reader")] = &models.APIKey{ID: "key-1", Name: "reader", Scopes: []string{ScopeRead}}
+	db.keys[HashToken("csa_user")] = &models.APIKey{ID: "key-2", Name: "user key", UserID: &userID, Scopes: []string{ScopeRead}}
+	revokedAt := time.Now()
+	db.keys[HashToken("csa_revoked")] = &models.APIKey{ID: "key-3", Scopes: []string{ScopeAdmin}, RevokedAt: &revokedAt}
+	a := NewAuthenticator(db)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string // Principal name, empty for none
+		key     string // Rate limit key
+	}{
+		{"bearer key", map[string]string{"Authorization": "Bearer csa_reader"}, "reader", "key:key-1"},
+		{"header key", map[string]string{"X-API-Key": " csa_reader "}, "reader", "key:key-1"},
+		{"user key", map[string]string{"X-API-Key": "csa_user"}, "user key", "user:user-1"},
+		{"bootstrap key", map[string]string{"Authorization": "Bearer bootstrap-key"}, "admin", "key:bootstrap"},
+		{"revoked key", map[string]string{"X-API-Key": "csa_revoked"}, "", ""},
+		{"unknown key", map[string]string{"X-API-Key": "csa_unknown"}, "", ""},
+		{"basic auth", map[string]string{"Authorization": "Basic Zm9vOmJhcg=="}, "", ""},
+		{"no credentials", nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := a.Authenticate(request(tt.headers))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if p != nil {
+					t.Errorf("authenticated as %+v", p)
+				}
+				return
+			}
+			if p == nil || p.Name != tt.want || p.Key() != tt.key {
+				t.Errorf("principal = %+v, want %s (%s)", p, tt.want, tt.key)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("AUTH_ENABLED", "false")
+		p, err := NewAuthenticator(db).Authenticate(request(nil))
+		if err != nil || p == nil || !p.Has(ScopeAdmin) {
+			t.Errorf("principal = %+v, %v, want admin", p, err)
+		}
+	})
+}
+
+func TestSessions(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "bootstrap-key")
+	db := newFakeStore()
+	db.keys[HashToken("csa_reader")] = &models.APIKey{ID: "key-1", Name: "reader", Scopes: []string{ScopeRead}}
+	a := NewAuthenticator(db)
+
+	signIn := func(key string) string {
+		t.Helper()
+		ctx := request(nil)
+		if _, err := a.Login(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		return sessionCookie(ctx)
+	}
+	withSession := func(authenticator *Authenticator, token string) *Principal {
+		t.Helper()
+		ctx := request(nil)
+		ctx.Request.Header.SetCookie(SessionCookie, token)
+		p, err := authenticator.Authenticate(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	readerToken := signIn("csa_reader")
+	if p := withSession(a, readerToken); p == nil || !p.Session || p.APIKeyID == nil || *p.APIKeyID != "key-1" {
+		t.Errorf("reader session = %+v", p)
+	}
+
+	bootstrapToken := signIn("bootstrap-key")
+	if p := withSession(a, bootstrapToken); p == nil || !p.Has(ScopeAdmin) {
+		t.Errorf("bootstrap session = %+v", p)
+	}
+
+	// Rotating the bootstrap key ends its sessions but no others
+	t.Setenv("ADMIN_API_KEY", "rotated-key")
+	rotated := NewAuthenticator(db)
+	if p := withSession(rotated, bootstrapToken); p != nil {
+		t.Errorf("bootstrap session survived rotation: %+v", p)
+	}
+	if p := withSession(rotated, readerToken); p == nil {
+		t.Error("reader session ended with the rotation")
+	}
+
+	// As does removing it
+	t.Setenv("ADMIN_API_KEY", "")
+	if p := withSession(NewAuthenticator(db), bootstrapToken); p != nil {
+		t.Errorf("bootstrap session survived removing the key: %+v", p)
+	}
+
+	if _, err := a.Login(request(nil), "csa_unknown"); err != ErrInvalidKey {
+		t.Errorf("unknown key sign in error = %v, want ErrInvalidKey", err)
+	}
+	if p := withSession(a, "forged"); p != nil {
+		t.Errorf("forged session = %+v", p)
+	}
+}
+
+func TestSecureCookieByDefault(t *testing.T) {
+	ctx := request(nil)
+	NewAuthenticator(newFakeStore()).setCookie(ctx, "token", time.Now().Add(time.Hour))
+	if cookie := string(ctx.Response.Header.PeekCookie(SessionCookie)); !strings.Contains(strings.ToLower(cookie), "; secure") {
+		t.Errorf("cookie %q isn't secure", cookie)
+	}
+
+	t.Setenv("AUTH_COOKIE_SECURE", "false")
+	ctx = request(nil)
+	NewAuthenticator(newFakeStore()).setCookie(ctx, "token", time.Now().Add(time.Hour))
+	if cookie := string(ctx.Response.Header.PeekCookie(SessionCookie)); strings.Contains(strings.ToLower(cookie), "; secure") {
+		t.Errorf("cookie %q is secure with AUTH_COOKIE_SECURE=false", cookie)
+	}
+}
+
+func TestLoginPassword(t *testing.T) {
+	db := newFakeStore()
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.users["alice"] = &models.User{ID: "user-1", Username: "alice", PasswordHash: hash, Scopes: []string{ScopeRead}}
+	a := NewAuthenticator(db)
+
+	ctx := request(nil)
+	p, err := a.LoginPassword(ctx, "alice", "correct horse")
+	if err != nil || p.UserID == nil || *p.UserID != "user-1" || !p.Session || sessionCookie(ctx) == "" {
+		t.Errorf("sign in = %+v, %v", p, err)
+	}
+
+	for _, username := range []string{"alice", "mallory"} {
+		ctx := request(nil)
+		if _, err := a.LoginPassword(ctx, username, "wrong"); err != ErrInvalidPassword {
+			t.Errorf("%s with a wrong password: error = %v, want ErrInvalidPassword", username, err)
+		}
+		if sessionCookie(ctx) != "" {
+			t.Errorf("%s with a wrong password got a session", username)
+		}
+	}
+}
+
+func TestDummyPasswordHash(t *testing.T) {
+	// Unknown usernames must cost as much as real ones
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash))
+	if err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash cost = %d, %v, want %d", cost, err, bcrypt.DefaultCost)
+	}
+}
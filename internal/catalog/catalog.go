@@ -0,0 +1,224 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// TypeOther is the type of items whose weapon is not in the catalogue
+const TypeOther = "Other"
+
+// TypeKnife is the type of knives, which share float ranges by finish
+const TypeKnife = "Knife"
+
+//go:embed data/catalog.json
+var bundledData []byte
+
+// Weapon is a catalogue entry for a weapon, knife or glove model
+type Weapon struct {
+	Name    string   `json:"weapon"`
+	Type    string   `json:"type"`              // e.g. Rifle, Knife, Gloves
+	Aliases []string `json:"aliases,omitempty"` // Alternative spellings used by marketplaces
+	Rarity  string   `json:"rarity,omitempty"`  // Rarity shared by every finish, e.g. for knives
+	// Float range shared by every finish of the weapon (e.g. gloves)
+	MinFloat float64 `json:"min_float,omitempty"`
+	MaxFloat float64 `json:"max_float,omitempty"`
+}
+
+// SkinInfo is a catalogue entry for a finish, either on a specific weapon or
+// shared by every weapon with that finish when Weapon is empty
+type SkinInfo struct {
+	Weapon   string  `json:"weapon,omitempty"`
+	Finish   string  `json:"finish,omitempty"`
+	Rarity   string  `json:"rarity,omitempty"`
+	MinFloat float64 `json:"min_float"`
+	MaxFloat float64 `json:"max_float"`
+}
+
+// dataset is the on-disk layout of the catalogue
+type dataset struct {
+	Weapons []Weapon `json:"weapons"`
+	// Skins holds metadata for a specific weapon and finish
+	Skins []SkinInfo `json:"skins"`
+	// Finishes holds ranges shared by every knife with a finish
+	Finishes []SkinInfo `json:"finishes"`
+	// AgentFactions lists the factions used as the second half of agent names
+	AgentFactions []string `json:"agent_factions"`
+}
+
+// Catalog resolves market hash names to canonical item metadata
+type Catalog struct {
+	weapons  map[string]Weapon // keyed by normalized name and aliases
+	skins    map[string]SkinInfo
+	finishes map[string]SkinInfo
+
+	agentFactions map[string]struct{}
+}
+
+// Resolution is the canonical description of a market hash name
+type Resolution struct {
+	Name
+	Type   string `json:"type"`   // e.g. Rifle, Knife, Gloves, Other
+	Rarity string `json:"rarity"` // e.g. Covert; empty when unknown
+	Known  bool   `json:"known"`  // Whether the weapon is in the catalogue
+}
+
+var (
+	defaultCatalog     atomic.Pointer[Catalog]
+	defaultCatalogOnce sync.Once
+)
+
+// Default returns the shared catalogue, loading it on first use from
+// CATALOG_FILE when set and from the bundled dataset otherwise
+func Default() *Catalog {
+	defaultCatalogOnce.Do(func() {
+		if err := Reload(); err != nil {
+			log.Printf("Error loading skin catalogue: %v", err)
+			defaultCatalog.Store(&Catalog{})
+		}
+	})
+	return defaultCatalog.Load()
+}
+
+// Reload rebuilds the shared catalogue from CATALOG_FILE or the bundled
+// dataset. The previous catalogue stays in place if loading fails.
+func Reload() error {
+	data := bundledData
+	if path := os.Getenv("CATALOG_FILE"); path != "" {
+		fileData, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read catalogue file %s: %v", path, err)
+		}
+		data = fileData
+	}
+
+	return ReloadFrom(data)
+}
+
+// ReloadFrom replaces the shared catalogue with one built from data
+func ReloadFrom(data []byte) error {
+	c, err := Load(data)
+	if err != nil {
+		return err
+	}
+	defaultCatalog.Store(c)
+	return nil
+}
+
+// Load builds a catalogue from a JSON dataset
+func Load(data []byte) (*Catalog, error) {
+	var ds dataset
+	if err := json.Unmarshal(data, &ds); err != nil {
+		return nil, fmt.Errorf("failed to parse catalogue: %v", err)
+	}
+
+	c := &Catalog{
+		weapons:  make(map[string]Weapon, len(ds.Weapons)),
+		skins:    make(map[string]SkinInfo, len(ds.Skins)),
+		finishes: make(map[string]SkinInfo, len(ds.Finishes)),
+
+		agentFactions: make(map[string]struct{}, len(ds.AgentFactions)),
+	}
+	for _, w := range ds.Weapons {
+		c.weapons[weaponKey(w.Name)] = w
+		for _, alias := range w.Aliases {
+			c.weapons[weaponKey(alias)] = w
+		}
+	}
+	for _, s := range ds.Skins {
+		c.skins[key(s.Weapon, s.Finish)] = s
+	}
+	for _, s := range ds.Finishes {
+		c.finishes[key(s.Finish)] = s
+	}
+	for _, f := range ds.AgentFactions {
+		c.agentFactions[strings.ToLower(f)] = struct{}{}
+	}
+
+	return c, nil
+}
+
+// Resolve describes a market hash name. Hints, such as a marketplace's own
+// category label, are used to identify the weapon when the name alone does
+// not match the catalogue.
+func (c *Catalog) Resolve(marketHashName string, hints ...string) Resolution {
+	res := Resolution{Name: ParseMarketHashName(marketHashName), Type: TypeOther}
+
+	weapon, ok := c.weapons[weaponKey(res.Weapon)]
+	if !ok {
+		if itemType, found := c.resolveNonWeapon(res.Name); found {
+			res.Type = itemType
+			res.Known = true
+			return res
+		}
+	}
+	for _, hint := range hints {
+		if ok {
+			break
+		}
+		weapon, ok = c.lookupWeaponHint(hint)
+	}
+	if !ok {
+		return res
+	}
+
+	res.Known = true
+	res.Weapon = weapon.Name
+	res.Type = weapon.Type
+	res.Rarity = weapon.Rarity
+	if s, found := c.skins[key(weapon.Name, res.Finish)]; found && s.Rarity != "" {
+		res.Rarity = s.Rarity
+	}
+
+	return res
+}
+
+// lookupWeaponHint matches the longest leading run of words in a label such
+// as "DESERT EAGLE BLAZE" against the weapon names and aliases
+func (c *Catalog) lookupWeaponHint(hint string) (Weapon, bool) {
+	hint = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(hint), "★"))
+	words := strings.Fields(hint)
+	for n := len(words); n > 0; n-- {
+		if w, ok := c.weapons[weaponKey(strings.Join(words[:n], " "))]; ok {
+			return w, true
+		}
+	}
+	return Weapon{}, false
+}
+
+// FloatRange returns the paint float range for a weapon and finish.
+// Specific skins take precedence over weapon defaults, which take
+// precedence over finish defaults. Finish defaults only apply to knives,
+// since the same finish on a gun can have a different range.
+func (c *Catalog) FloatRange(weapon, finish string) (float64, float64, bool) {
+	if s, ok := c.skins[key(weapon, finish)]; ok {
+		return s.MinFloat, s.MaxFloat, true
+	}
+	w, ok := c.weapons[weaponKey(weapon)]
+	if ok && w.MaxFloat > 0 {
+		return w.MinFloat, w.MaxFloat, true
+	}
+	if s, found := c.finishes[key(finish)]; found && ok && w.Type == TypeKnife {
+		return s.MinFloat, s.MaxFloat, true
+	}
+	return 0, 0, false
+}
+
+// weaponKey normalizes a weapon name so that "DESERT EAGLE", "Desert Eagle"
+// and "desert-eagle" match
+func weaponKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package catalog
+
+import "testing"
+
+const testDataset = `{
+	"weapons": [
+		{"weapon": "AK-47", "type": "Rifle"},
+		{"weapon": "Glock-18", "type": "Pistol", "aliases": ["GLOCK"]},
+		{"weapon": "Karambit", "type": "Knife", "rarity": "Covert"},
+		{"weapon": "Sport Gloves", "type": "Gloves", "rarity": "Extraordinary", "min_float": 0.06, "max_float": 0.8}
+	],
+	"skins": [
+		{"weapon": "AK-47", "finish": "Redline", "rarity": "Classified", "min_float": 0.1, "max_float": 0.7},
+		{"weapon": "Karambit", "finish": "Fade", "min_float": 0, "max_float": 0.06}
+	],
+	"finishes": [
+		{"finish": "Fade", "min_float": 0, "max_float": 0.08},
+		{"finish": "Case Hardened", "min_float": 0, "max_float": 1}
+	],
+	"agent_factions": ["Sabre"]
+}`
+
+func testCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	c, err := Load([]byte(testDataset))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestFloatRange(t *testing.T) {
+	c := testCatalog(t)
+
+	tests := []struct {
+		weapon, finish string
+		min, max       float64
+		ok             bool
+	}{
+		{"AK-47", "Redline", 0.1, 0.7, true},
+		{"Karambit", "Fade", 0, 0.06, true},             // Skin entry wins over the finish
+		{"Karambit", "Case Hardened", 0, 1, true},       // Knife finish default
+		{"Sport Gloves", "Vice", 0.06, 0.8, true},       // Weapon default
+		{"Glock-18", "Fade", 0, 0, false},               // Knife finishes don't apply to guns
+		{"AK-47", "Case Hardened", 0, 0, false},         // Nor to rifles
+		{"Unknown Knife", "Case Hardened", 0, 0, false}, // Nor to unknown weapons
+		{"AK-47", "Unknown Finish", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		min, max, ok := c.FloatRange(tt.weapon, tt.finish)
+		if ok != tt.ok || min != tt.min || max != tt.max {
+			t.Errorf("FloatRange(%q, %q) = %v, %v, %v, want %v, %v, %v", tt.weapon, tt.finish, min, max, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	c := testCatalog(t)
+
+	tests := []struct {
+		name   string
+		hints  []string
+		weapon string
+		typ    string
+		rarity string
+		known  bool
+	}{
+		{"AK-47 | Redline (Field-Tested)", nil, "AK-47", "Rifle", "Classified", true},
+		{"★ Karambit | Fade (Factory New)", nil, "Karambit", "Knife", "Covert", true},
+		{"GLOCK | Fade (Factory New)", nil, "Glock-18", "Pistol", "", true},
+		{"Blaze (Factory New)", []string{"GLOCK BLAZE"}, "Glock-18", "Pistol", "", true},
+		{"Sticker | Titan | Katowice 2014", nil, "Sticker", TypeSticker, "", true},
+		{"Operation Bravo Case", nil, "Operation Bravo Case", TypeCase, "", true},
+		{"Cmdr. Frank 'Wet Sox' Baroud | Sabre", nil, "Cmdr. Frank 'Wet Sox' Baroud", TypeAgent, "", true},
+		{"Mystery Gun | Thing (Field-Tested)", nil, "Mystery Gun", TypeOther, "", false},
+	}
+
+	for _, tt := range tests {
+		res := c.Resolve(tt.name, tt.hints...)
+		if res.Weapon != tt.weapon || res.Type != tt.typ || res.Rarity != tt.rarity || res.Known != tt.known {
+			t.Errorf("Resolve(%q) = %s/%s/%s known=%v, want %s/%s/%s known=%v",
+				tt.name, res.Weapon, res.Type, res.Rarity, res.Known, tt.weapon, tt.typ, tt.rarity, tt.known)
+		}
+	}
+}
+
+func TestHasWear(t *testing.T) {
+	for itemType, want := range map[string]bool{
+		"Rifle":      true,
+		TypeKnife:    true,
+		TypeOther:    true,
+		TypeSticker:  false,
+		TypeAgent:    false,
+		TypeCase:     false,
+		TypeMusicKit: false,
+	} {
+		if got := HasWear(itemType); got != want {
+			t.Errorf("HasWear(%q) = %v, want %v", itemType, got, want)
+		}
+	}
+}
+
+func TestBundledCatalogLoads(t *testing.T) {
+	c, err := Load(bundledData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := c.Resolve("★ Karambit | Doppler (Factory New)"); res.Type != TypeKnife {
+		t.Errorf("bundled catalogue resolves the Karambit as %q, want %q", res.Type, TypeKnife)
+	}
+}
@@ -0,0 +1,130 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+//go:embed data/collections.json
+var bundledCollections []byte
+
+// CollectionSkin is a skin of a collection at a given rarity
+type CollectionSkin struct {
+	Weapon   string  `json:"weapon"`
+	Finish   string  `json:"finish"`
+	Rarity   string  `json:"rarity"`
+	MinFloat float64 `json:"min_float,omitempty"` // 0 and 0 mean unknown
+	MaxFloat float64 `json:"max_float,omitempty"`
+}
+
+// Collection is a set of skins that trade up into each other
+type Collection struct {
+	Name  string           `json:"name"`
+	Skins []CollectionSkin `json:"skins"`
+}
+
+// collectionsDataset is the on-disk layout of the collections dataset
+type collectionsDataset struct {
+	Rarities    []string     `json:"rarities"` // Lowest to highest
+	Collections []Collection `json:"collections"`
+}
+
+// Collections indexes which collection each skin belongs to and what it
+// can trade up into
+type Collections struct {
+	rarityRank map[string]int
+	rarities   []string
+	bySkin     map[string]collectionEntry
+	byRarity   map[string][]CollectionSkin // keyed by collection and rarity
+}
+
+// collectionEntry is a skin together with its collection
+type collectionEntry struct {
+	collection string
+	skin       CollectionSkin
+}
+
+var (
+	defaultCollections     *Collections
+	defaultCollectionsOnce sync.Once
+)
+
+// DefaultCollections returns the shared collections dataset, loaded on first
+// use from COLLECTIONS_FILE when set and from the bundled dataset otherwise.
+// The bundled dataset only covers a few collections, with float ranges where
+// they are known; point COLLECTIONS_FILE at a complete one for full search
+// coverage.
+func DefaultCollections() *Collections {
+	defaultCollectionsOnce.Do(func() {
+		data := bundledCollections
+		if path := os.Getenv("COLLECTIONS_FILE"); path != "" {
+			fileData, err := os.ReadFile(path)
+			if err != nil {
+				log.Printf("Error reading collections file %s, using the bundled dataset: %v", path, err)
+			} else {
+				data = fileData
+			}
+		}
+
+		c, err := LoadCollections(data)
+		if err != nil {
+			log.Printf("Error loading collections: %v", err)
+			c = &Collections{}
+		}
+		defaultCollections = c
+	})
+	return defaultCollections
+}
+
+// LoadCollections builds a collections index from a JSON dataset
+func LoadCollections(data []byte) (*Collections, error) {
+	var ds collectionsDataset
+	if err := json.Unmarshal(data, &ds); err != nil {
+		return nil, fmt.Errorf("failed to parse collections: %v", err)
+	}
+
+	c := &Collections{
+		rarityRank: make(map[string]int, len(ds.Rarities)),
+		rarities:   ds.Rarities,
+		bySkin:     make(map[string]collectionEntry),
+		byRarity:   make(map[string][]CollectionSkin),
+	}
+	for i, rarity := range ds.Rarities {
+		c.rarityRank[rarity] = i
+	}
+	for _, col := range ds.Collections {
+		for _, s := range col.Skins {
+			if _, ok := c.rarityRank[s.Rarity]; !ok {
+				return nil, fmt.Errorf("skin %s | %s in %s has unknown rarity %q", s.Weapon, s.Finish, col.Name, s.Rarity)
+			}
+			c.bySkin[key(s.Weapon, s.Finish)] = collectionEntry{collection: col.Name, skin: s}
+			c.byRarity[key(col.Name, s.Rarity)] = append(c.byRarity[key(col.Name, s.Rarity)], s)
+		}
+	}
+
+	return c, nil
+}
+
+// Lookup returns the collection and collection entry of a skin
+func (c *Collections) Lookup(weapon, finish string) (string, CollectionSkin, bool) {
+	e, ok := c.bySkin[key(weapon, finish)]
+	return e.collection, e.skin, ok
+}
+
+// NextRarity returns the rarity a trade-up of the given rarity produces
+func (c *Collections) NextRarity(rarity string) (string, bool) {
+	rank, ok := c.rarityRank[rarity]
+	if !ok || rank+1 >= len(c.rarities) {
+		return "", false
+	}
+	return c.rarities[rank+1], true
+}
+
+// Outcomes returns the skins of a collection at a rarity
+func (c *Collections) Outcomes(collection, rarity string) []CollectionSkin {
+	return c.byRarity[key(collection, rarity)]
+}
@@ -0,0 +1,89 @@
+package catalog
+
+import (
+	"strings"
+)
+
+// Wear names as they appear in market hash names
+var wearNames = []string{
+	"Factory New",
+	"Minimal Wear",
+	"Field-Tested",
+	"Well-Worn",
+	"Battle-Scarred",
+}
+
+// Name is a market hash name split into its parts
+type Name struct {
+	Weapon   string `json:"weapon"` // e.g. AK-47, Karambit, Sport Gloves
+	Finish   string `json:"finish"` // e.g. Redline, Doppler; empty for vanilla knives
+	Wear     string `json:"wear"`   // e.g. Field-Tested; empty when the item has no wear
+	StatTrak bool   `json:"stattrak"`
+	Souvenir bool   `json:"souvenir"`
+	Star     bool   `json:"star"` // ★ prefix used by knives and gloves
+}
+
+// ParseMarketHashName splits a market hash name such as
+// "StatTrak™ AK-47 | Redline (Field-Tested)" into weapon, finish and wear
+func ParseMarketHashName(marketHashName string) Name {
+	var name Name
+	rest := strings.TrimSpace(marketHashName)
+
+	if strings.HasPrefix(rest, "★") {
+		name.Star = true
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "★"))
+	}
+	if strings.HasPrefix(rest, "StatTrak™") {
+		name.StatTrak = true
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "StatTrak™"))
+	}
+	if strings.HasPrefix(rest, "Souvenir ") {
+		name.Souvenir = true
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "Souvenir "))
+	}
+
+	// Strip the trailing wear, e.g. "(Field-Tested)"
+	if strings.HasSuffix(rest, ")") {
+		if open := strings.LastIndex(rest, " ("); open >= 0 {
+			wear := NormalizeWear(rest[open+2 : len(rest)-1])
+			if wear != "" {
+				name.Wear = wear
+				rest = strings.TrimSpace(rest[:open])
+			}
+		}
+	}
+
+	weapon, finish, found := strings.Cut(rest, " | ")
+	name.Weapon = strings.TrimSpace(weapon)
+	if found {
+		name.Finish = strings.TrimSpace(finish)
+	}
+
+	return name
+}
+
+// NormalizeWear maps wear spellings such as "Factory-New" or "field-tested"
+// to the canonical wear name, returning an empty string when unknown
+func NormalizeWear(wear string) string {
+	key := normalizeWearKey(wear)
+	for _, name := range wearNames {
+		if normalizeWearKey(name) == key {
+			return name
+		}
+	}
+	return ""
+}
+
+func normalizeWearKey(wear string) string {
+	wear = strings.ToLower(strings.TrimSpace(wear))
+	wear = strings.ReplaceAll(wear, "-", " ")
+	return strings.Join(strings.Fields(wear), " ")
+}
+
+// key builds a case-insensitive lookup key from name parts
+func key(parts ...string) string {
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, "|")
+}
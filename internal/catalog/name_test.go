@@ -0,0 +1,42 @@
+package catalog
+
+import "testing"
+
+func TestParseMarketHashName(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Name
+	}{
+		{"AK-47 | Redline (Field-Tested)", Name{Weapon: "AK-47", Finish: "Redline", Wear: "Field-Tested"}},
+		{"StatTrak™ M4A4 | Howl (Minimal Wear)", Name{Weapon: "M4A4", Finish: "Howl", Wear: "Minimal Wear", StatTrak: true}},
+		{"Souvenir AWP | Dragon Lore (Factory New)", Name{Weapon: "AWP", Finish: "Dragon Lore", Wear: "Factory New", Souvenir: true}},
+		{"★ StatTrak™ Karambit | Doppler (Factory New)", Name{Weapon: "Karambit", Finish: "Doppler", Wear: "Factory New", StatTrak: true, Star: true}},
+		{"★ Butterfly Knife", Name{Weapon: "Butterfly Knife", Star: true}},
+		{"Sticker | Titan (Holo) | Katowice 2014", Name{Weapon: "Sticker", Finish: "Titan (Holo) | Katowice 2014"}},
+		{"Operation Bravo Case", Name{Weapon: "Operation Bravo Case"}},
+		{"Sealed Graffiti | Lambda (Blood Red)", Name{Weapon: "Sealed Graffiti", Finish: "Lambda (Blood Red)"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseMarketHashName(tt.raw); got != tt.want {
+			t.Errorf("ParseMarketHashName(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeWear(t *testing.T) {
+	tests := map[string]string{
+		"Factory New":     "Factory New",
+		"factory-new":     "Factory New",
+		" Field  Tested ": "Field-Tested",
+		"BATTLE-SCARRED":  "Battle-Scarred",
+		"Holo":            "",
+		"":                "",
+	}
+
+	for raw, want := range tests {
+		if got := NormalizeWear(raw); got != want {
+			t.Errorf("NormalizeWear(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
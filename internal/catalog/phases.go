@@ -0,0 +1,134 @@
+package catalog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Doppler phases and gems
+const (
+	PhaseRuby       = "Ruby"
+	PhaseSapphire   = "Sapphire"
+	PhaseBlackPearl = "Black Pearl"
+	PhaseEmerald    = "Emerald"
+	Phase1          = "Phase 1"
+	Phase2          = "Phase 2"
+	Phase3          = "Phase 3"
+	Phase4          = "Phase 4"
+)
+
+// phasesByPaintIndex maps Doppler and Gamma Doppler paint indexes to their phase
+var phasesByPaintIndex = map[int]string{
+	415: PhaseRuby,
+	416: PhaseSapphire,
+	417: PhaseBlackPearl,
+	418: Phase1,
+	419: Phase2,
+	420: Phase3,
+	421: Phase4,
+	568: PhaseEmerald,
+	569: Phase1,
+	570: Phase2,
+	571: Phase3,
+	572: Phase4,
+	// Glock-18 Gamma Doppler
+	1119: PhaseEmerald,
+	1120: Phase1,
+	1121: Phase2,
+	1122: Phase3,
+	1123: Phase4,
+}
+
+// phaseHints maps lowercase substrings found in skin names to a phase.
+// Longer hints are listed first so "black pearl" wins over shorter matches.
+var phaseHints = []struct {
+	hint  string
+	phase string
+}{
+	{"black pearl", PhaseBlackPearl},
+	{"blackpearl", PhaseBlackPearl},
+	{"sapphire", PhaseSapphire},
+	{"emerald", PhaseEmerald},
+	{"ruby", PhaseRuby},
+	{"phase 1", Phase1},
+	{"phase 2", Phase2},
+	{"phase 3", Phase3},
+	{"phase 4", Phase4},
+	{"phase1", Phase1},
+	{"phase2", Phase2},
+	{"phase3", Phase3},
+	{"phase4", Phase4},
+}
+
+// fadePercentPattern matches "95% Fade", "Fade 95%" and "fade_95"
+var fadePercentPattern = regexp.MustCompile(`(?i)(\d{2,3}(?:\.\d+)?)\s*%\s*fade|fade[\s_:-]*(\d{2,3}(?:\.\d+)?)\s*%?`)
+
+// HasPhases reports whether a finish comes in Doppler phases
+func HasPhases(finish string) bool {
+	finish = strings.ToLower(finish)
+	return strings.Contains(finish, "doppler")
+}
+
+// HasFadePercent reports whether a finish is graded by fade percentage
+func HasFadePercent(finish string) bool {
+	switch strings.ToLower(strings.TrimSpace(finish)) {
+	case "fade", "marble fade", "amber fade":
+		return true
+	}
+	return false
+}
+
+// PhaseForPaintIndex returns the Doppler phase of a paint index
+func PhaseForPaintIndex(paintIndex int) (string, bool) {
+	phase, ok := phasesByPaintIndex[paintIndex]
+	return phase, ok
+}
+
+// DetectPhase determines the Doppler phase of an item for a phased finish,
+// preferring the paint index and falling back to hints in the given skin
+// names. It returns an empty string when unknown.
+func DetectPhase(finish string, paintIndex int, hints ...string) string {
+	if !HasPhases(finish) {
+		return ""
+	}
+
+	if phase, ok := PhaseForPaintIndex(paintIndex); ok {
+		return phase
+	}
+
+	for _, h := range hints {
+		h = strings.ToLower(h)
+		for _, ph := range phaseHints {
+			if strings.Contains(h, ph.hint) {
+				return ph.phase
+			}
+		}
+	}
+
+	return ""
+}
+
+// DetectFadePercent extracts a fade percentage from name hints such as
+// "Fade (98% Fade)", returning 0 when none is found
+func DetectFadePercent(finish string, hints ...string) float64 {
+	if !HasFadePercent(finish) {
+		return 0
+	}
+
+	for _, h := range hints {
+		match := fadePercentPattern.FindStringSubmatch(h)
+		if match == nil {
+			continue
+		}
+		value := match[1]
+		if value == "" {
+			value = match[2]
+		}
+		if percent, err := strconv.ParseFloat(value, 64); err == nil && percent >= 80 && percent <= 100 {
+			return percent
+		}
+	}
+
+	return 0
+}
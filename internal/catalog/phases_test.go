@@ -0,0 +1,50 @@
+package catalog
+
+import "testing"
+
+func TestDetectPhase(t *testing.T) {
+	tests := []struct {
+		name       string
+		finish     string
+		paintIndex int
+		hints      []string
+		want       string
+	}{
+		{"paint index", "Doppler", 415, nil, PhaseRuby},
+		{"paint index wins over name", "Doppler", 418, []string{"Doppler Sapphire"}, Phase1},
+		{"gamma doppler index", "Gamma Doppler", 568, nil, PhaseEmerald},
+		{"name hint", "Doppler", 0, []string{"Karambit Doppler Black Pearl"}, PhaseBlackPearl},
+		{"numbered phase", "Doppler", 0, []string{"Doppler (Phase 2)"}, Phase2},
+		// Icon paths are shared between phases, so they aren't hints
+		{"no name hint", "Doppler", 0, []string{"★ Karambit | Doppler"}, ""},
+		{"unknown index", "Doppler", 1, nil, ""},
+		{"not a phased finish", "Fade", 415, []string{"Ruby"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectPhase(tt.finish, tt.paintIndex, tt.hints...); got != tt.want {
+			t.Errorf("%s: DetectPhase(%q, %d, %q) = %q, want %q", tt.name, tt.finish, tt.paintIndex, tt.hints, got, tt.want)
+		}
+	}
+}
+
+func TestDetectFadePercent(t *testing.T) {
+	tests := []struct {
+		finish string
+		hint   string
+		want   float64
+	}{
+		{"Fade", "Fade (98% Fade)", 98},
+		{"Fade", "Fade 95%", 95},
+		{"Marble Fade", "fade_100", 100},
+		{"Fade", "Fade (50% Fade)", 0},
+		{"Fade", "Fade", 0},
+		{"Doppler", "Fade 95%", 0},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFadePercent(tt.finish, tt.hint); got != tt.want {
+			t.Errorf("DetectFadePercent(%q, %q) = %v, want %v", tt.finish, tt.hint, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+//go:embed data/stickers.json
+var bundledStickers []byte
+
+// stickerWearPattern matches a trailing scrape level such as "(35% scraped)"
+var stickerWearPattern = regexp.MustCompile(`\s*\((\d+(?:\.\d+)?)%(?: scraped)?\)$`)
+
+// BundledStickerPrices returns the sticker reference prices shipped with the
+// application, used to seed the sticker catalogue
+func BundledStickerPrices() ([]models.StickerPrice, error) {
+	var prices []models.StickerPrice
+	if err := json.Unmarshal(bundledStickers, &prices); err != nil {
+		return nil, fmt.Errorf("failed to parse bundled sticker prices: %v", err)
+	}
+	return prices, nil
+}
+
+// ParseSticker parses a sticker as scraped from a marketplace. The raw value
+// is either an image URL, optionally carrying a "wear" query parameter, or a
+// sticker name, optionally followed by a scrape level such as "(35% scraped)".
+func ParseSticker(raw string, slot int) models.Sticker {
+	raw = strings.TrimSpace(raw)
+	sticker := models.Sticker{Slot: slot}
+
+	if u, err := url.Parse(raw); err == nil && u.Scheme != "" && u.Host != "" {
+		sticker.ImageURL = raw
+		if wear, err := strconv.ParseFloat(u.Query().Get("wear"), 64); err == nil {
+			sticker.Wear = clampWear(wear)
+		}
+		sticker.Name = StickerImageKey(raw)
+		return sticker
+	}
+
+	if match := stickerWearPattern.FindStringSubmatch(raw); match != nil {
+		if percent, err := strconv.ParseFloat(match[1], 64); err == nil {
+			sticker.Wear = clampWear(percent / 100)
+		}
+		raw = strings.TrimSpace(raw[:len(raw)-len(match[0])])
+	}
+	sticker.Name = raw
+
+	return sticker
+}
+
+// ParseStickers parses every sticker of an item, using the array index as
+// the slot and skipping empty slots
+func ParseStickers(raw []string) []models.Sticker {
+	var stickers []models.Sticker
+	for slot, value := range raw {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		stickers = append(stickers, ParseSticker(value, slot))
+	}
+	return stickers
+}
+
+// StickerImageKey derives a stable key from a sticker image URL: the file
+// name without its extension, e.g. "titan_holo" for ".../titan_holo.png"
+func StickerImageKey(imageURL string) string {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return ""
+	}
+	base := path.Base(u.Path)
+	if base == "." || base == "/" {
+		return ""
+	}
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+func clampWear(wear float64) float64 {
+	if wear < 0 {
+		return 0
+	}
+	if wear > 1 {
+		return 1
+	}
+	return wear
+}
@@ -0,0 +1,86 @@
+package catalog
+
+import (
+	"strings"
+)
+
+// Types of items that are not weapon skins
+const (
+	TypeSticker     = "Sticker"
+	TypePatch       = "Patch"
+	TypeGraffiti    = "Graffiti"
+	TypeMusicKit    = "Music Kit"
+	TypeCharm       = "Charm"
+	TypeAgent       = "Agent"
+	TypeCase        = "Case"
+	TypeCapsule     = "Capsule"
+	TypeKey         = "Key"
+	TypePass        = "Pass"
+	TypeCollectible = "Collectible"
+)
+
+// prefixTypes maps the "<Prefix> | <Name>" form used by non-weapon items
+var prefixTypes = map[string]string{
+	"sticker":         TypeSticker,
+	"patch":           TypePatch,
+	"sealed graffiti": TypeGraffiti,
+	"graffiti":        TypeGraffiti,
+	"music kit":       TypeMusicKit,
+	"charm":           TypeCharm,
+}
+
+// suffixTypes maps name endings of containers and other tools, checked in order
+var suffixTypes = []struct {
+	suffix   string
+	itemType string
+}{
+	{"case key", TypeKey},
+	{"capsule key", TypeKey},
+	{"key", TypeKey},
+	{"case", TypeCase},
+	{"capsule", TypeCapsule},
+	{"package", TypeCapsule},
+	{"patch pack", TypeCapsule},
+	{"pass", TypePass},
+	{"pin", TypeCollectible},
+	{"coin", TypeCollectible},
+}
+
+// HasWear reports whether items of a type have a float and wear
+func HasWear(itemType string) bool {
+	switch itemType {
+	case TypeSticker, TypePatch, TypeGraffiti, TypeMusicKit, TypeCharm,
+		TypeAgent, TypeCase, TypeCapsule, TypeKey, TypePass, TypeCollectible:
+		return false
+	}
+	return true
+}
+
+// resolveNonWeapon classifies items without wear such as stickers, cases and
+// agents, returning false for anything that looks like a weapon skin
+func (c *Catalog) resolveNonWeapon(name Name) (string, bool) {
+	if name.Wear != "" {
+		return "", false
+	}
+
+	if t, ok := prefixTypes[strings.ToLower(name.Weapon)]; ok && name.Finish != "" {
+		return t, true
+	}
+
+	// Agents are named "<Agent> | <Faction>"
+	if name.Finish != "" {
+		if _, ok := c.agentFactions[strings.ToLower(name.Finish)]; ok {
+			return TypeAgent, true
+		}
+		return "", false
+	}
+
+	lower := strings.ToLower(name.Weapon)
+	for _, s := range suffixTypes {
+		if strings.HasSuffix(lower, " "+s.suffix) {
+			return s.itemType, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// Alert delivery statuses
+const (
+	AlertDeliveryPending   = "pending"
+	AlertDeliveryDelivered = "delivered"
+	AlertDeliveryFailed    = "failed"
+)
+
+// createAlertTables creates the alert rule and delivery tables
+func (db *Database) createAlertTables() error {
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS alert_rules (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name VARCHAR(255) NOT NULL,
+			webhook_url TEXT NOT NULL,
+			secret TEXT NOT NULL DEFAULT '',
+			min_net_profit_usd DECIMAL(15,2) NOT NULL DEFAULT 0,
+			min_profit_percent DECIMAL(7,2) NOT NULL DEFAULT 0,
+			max_buy_price_usd DECIMAL(15,2) NOT NULL DEFAULT 0,
+			categories TEXT[] NOT NULL DEFAULT '{}',
+			min_float DECIMAL(18,16),
+			max_float DECIMAL(18,16),
+			skins TEXT[] NOT NULL DEFAULT '{}',
+			enabled BOOLEAN NOT NULL DEFAULT true,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating alert_rules table: %v", err)
+	}
+
+	// Every webhook is signed, so rules created before secrets were
+	// generated get one; their owners see it by recreating the rule
+	_, err = db.pool.Exec(context.Background(), `
+		UPDATE alert_rules
+		SET secret = replace(gen_random_uuid()::text || gen_random_uuid()::text, '-', '')
+		WHERE secret = ''
+	`)
+	if err != nil {
+		return fmt.Errorf("error generating alert rule secrets: %v", err)
+	}
+
+	// One delivery per rule and listing, so a listing never alerts twice
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS alert_deliveries (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			rule_id UUID NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+			item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			delivered_at TIMESTAMP,
+			UNIQUE(rule_id, item_id)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating alert_deliveries table: %v", err)
+	}
+
+	return nil
+}
+
+// InsertAlertRule inserts an alert rule and returns its ID
+func (db *Database) InsertAlertRule(rule *models.AlertRule) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		INSERT INTO alert_rules (
+			name, webhook_url, secret, min_net_profit_usd, min_profit_percent,
+			max_buy_price_usd, categories, min_float, max_float, skins, enabled, user_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12::uuid)
+		RETURNING id
+	`,
+		rule.Name, rule.WebhookURL, rule.Secret, rule.MinNetProfitUSD, rule.MinProfitPercent,
+		rule.MaxBuyPriceUSD, nonNilStrings(rule.Categories), rule.MinFloat, rule.MaxFloat,
+		nonNilStrings(rule.Skins), rule.Enabled, rule.UserID,
+	).Scan(&id)
+
+	if err != nil {
+		return "", fmt.Errorf("error inserting alert rule: %v", err)
+	}
+
+	return id, nil
+}
+
+// GetAlertRules returns alert rules, optionally only the enabled ones, of
+// one user or of everyone when userID is empty
+func (db *Database) GetAlertRules(enabledOnly bool, userID string) ([]models.AlertRule, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT id, user_id, name, webhook_url, secret, min_net_profit_usd, min_profit_percent,
+		       max_buy_price_usd, categories, min_float, max_float, skins, enabled,
+		       created_at, updated_at
+		FROM alert_rules
+		WHERE (enabled OR NOT $1)
+		  AND ($2 = '' OR user_id = NULLIF($2, '')::uuid)
+		ORDER BY created_at
+	`, enabledOnly, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying alert rules: %v", err)
+	}
+	defer rows.Close()
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		var r models.AlertRule
+		err := rows.Scan(
+			&r.ID, &r.UserID, &r.Name, &r.WebhookURL, &r.Secret, &r.MinNetProfitUSD, &r.MinProfitPercent,
+			&r.MaxBuyPriceUSD, &r.Categories, &r.MinFloat, &r.MaxFloat, &r.Skins, &r.Enabled,
+			&r.CreatedAt, &r.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning alert rule: %v", err)
+		}
+		rules = append(rules, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rules: %v", err)
+	}
+
+	return rules, nil
+}
+
+// DeleteAlertRule deletes an alert rule and its delivery history. A
+// non-empty userID only deletes the rule if that user owns it.
+func (db *Database) DeleteAlertRule(id, userID string) error {
+	tag, err := db.pool.Exec(context.Background(), `
+		DELETE FROM alert_rules WHERE id = $1::uuid AND ($2 = '' OR user_id = NULLIF($2, '')::uuid)
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting alert rule: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("alert rule %s %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// ClaimAlertDelivery records a pending delivery for a rule and listing. It
+// returns false when the listing was already alerted for the rule or is
+// being alerted; a failed delivery is claimed again so a later scrape retries
+// it.
+func (db *Database) ClaimAlertDelivery(ruleID, itemID string) (bool, error) {
+	tag, err := db.pool.Exec(context.Background(), `
+		INSERT INTO alert_deliveries (rule_id, item_id)
+		VALUES ($1::uuid, $2::uuid)
+		ON CONFLICT (rule_id, item_id) DO UPDATE SET status = $3, last_error = ''
+		WHERE alert_deliveries.status = $4
+	`, ruleID, itemID, AlertDeliveryPending, AlertDeliveryFailed)
+	if err != nil {
+		return false, fmt.Errorf("error claiming alert delivery: %v", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UpdateAlertDelivery records the outcome of a delivery attempt
+func (db *Database) UpdateAlertDelivery(ruleID, itemID, status string, attempts int, lastError string) error {
+	_, err := db.pool.Exec(context.Background(), `
+		UPDATE alert_deliveries SET
+			status = $3,
+			attempts = $4,
+			last_error = $5,
+			delivered_at = CASE WHEN $3 = 'delivered' THEN NOW() ELSE delivered_at END
+		WHERE rule_id = $1::uuid AND item_id = $2::uuid
+	`, ruleID, itemID, status, attempts, lastError)
+	if err != nil {
+		return fmt.Errorf("error updating alert delivery: %v", err)
+	}
+	return nil
+}
+
+// GetAlertDeliveries returns the most recent deliveries of a rule, or of
+// every rule when ruleID is empty
+func (db *Database) GetAlertDeliveries(ruleID string, limit int) ([]models.AlertDelivery, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT id, rule_id, item_id, status, attempts, last_error, created_at, delivered_at
+		FROM alert_deliveries
+		WHERE $1 = '' OR rule_id = NULLIF($1, '')::uuid
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying alert deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.AlertDelivery
+	for rows.Next() {
+		var d models.AlertDelivery
+		err := rows.Scan(&d.ID, &d.RuleID, &d.ItemID, &d.Status, &d.Attempts, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning alert delivery: %v", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert deliveries: %v", err)
+	}
+
+	return deliveries, nil
+}
+
+// nonNilStrings turns a nil slice into an empty one for NOT NULL array columns
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
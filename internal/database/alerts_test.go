@@ -0,0 +1,179 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// testDatabase connects to the database configured through DB_HOST and the
+// other DB_* variables, skipping the test when none is configured
+func testDatabase(t *testing.T) *Database {
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST not set")
+	}
+
+	db, err := NewDatabase()
+	if err != nil {
+		t.Fatalf("connecting to the database: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	if err := db.CreateTables(); err != nil {
+		t.Fatalf("creating tables: %v", err)
+	}
+	return db
+}
+
+func TestClaimAlertDeliveryOnce(t *testing.T) {
+	db := testDatabase(t)
+
+	marketplaceID, err := db.InsertMarketplace(&models.Marketplace{Name: "alert-test", URL: "https://example.com", Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	skinID, err := db.InsertSkin(&models.Skin{MarketHashName: "Alert Test | Claim (Factory New)", Category: "Rifle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	itemID, err := db.InsertItem(&models.Item{SkinID: skinID, MarketplaceID: marketplaceID, MarketItemID: "alert-test-1", Stickers: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ruleID, err := db.InsertAlertRule(&models.AlertRule{Name: "claim test", WebhookURL: "https://example.com/hook", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DeleteAlertRule(ruleID, "") })
+
+	claimed, err := db.ClaimAlertDelivery(ruleID, itemID)
+	if err != nil || !claimed {
+		t.Fatalf("first claim = %v, %v, want true", claimed, err)
+	}
+	claimed, err = db.ClaimAlertDelivery(ruleID, itemID)
+	if err != nil || claimed {
+		t.Fatalf("second claim = %v, %v, want false", claimed, err)
+	}
+
+	if err := db.UpdateAlertDelivery(ruleID, itemID, AlertDeliveryDelivered, 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err := db.GetAlertDeliveries(ruleID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != AlertDeliveryDelivered || deliveries[0].DeliveredAt == nil {
+		t.Errorf("deliveries = %+v, want one delivered", deliveries)
+	}
+	if claimed, err = db.ClaimAlertDelivery(ruleID, itemID); err != nil || claimed {
+		t.Fatalf("claim after delivery = %v, %v, want false", claimed, err)
+	}
+}
+
+func TestClaimAlertDeliveryAgainAfterFailure(t *testing.T) {
+	db := testDatabase(t)
+
+	marketplaceID, err := db.InsertMarketplace(&models.Marketplace{Name: "alert-test", URL: "https://example.com", Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	skinID, err := db.InsertSkin(&models.Skin{MarketHashName: "Alert Test | Retry (Factory New)", Category: "Rifle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	itemID, err := db.InsertItem(&models.Item{SkinID: skinID, MarketplaceID: marketplaceID, MarketItemID: "alert-test-2", Stickers: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ruleID, err := db.InsertAlertRule(&models.AlertRule{Name: "retry test", WebhookURL: "https://example.com/hook", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DeleteAlertRule(ruleID, "") })
+
+	if claimed, err := db.ClaimAlertDelivery(ruleID, itemID); err != nil || !claimed {
+		t.Fatalf("first claim = %v, %v, want true", claimed, err)
+	}
+	if err := db.UpdateAlertDelivery(ruleID, itemID, AlertDeliveryFailed, 5, "timeout"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed delivery is claimed once more, then pending again
+	if claimed, err := db.ClaimAlertDelivery(ruleID, itemID); err != nil || !claimed {
+		t.Fatalf("claim after failure = %v, %v, want true", claimed, err)
+	}
+	if claimed, err := db.ClaimAlertDelivery(ruleID, itemID); err != nil || claimed {
+		t.Fatalf("claim while pending = %v, %v, want false", claimed, err)
+	}
+	deliveries, err := db.GetAlertDeliveries(ruleID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != AlertDeliveryPending || deliveries[0].LastError != "" {
+		t.Errorf("deliveries = %+v, want one pending without an error", deliveries)
+	}
+}
+
+func TestAlertRuleOwnership(t *testing.T) {
+	db := testDatabase(t)
+
+	var userIDs []string
+	for _, username := range []string{"alert-owner", "alert-other"} {
+		id, err := db.InsertUser(&models.User{Username: username, PasswordHash: "-", Scopes: []string{"read"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		userIDs = append(userIDs, id)
+		t.Cleanup(func() { db.DeleteUser(id) })
+	}
+	owner, other := userIDs[0], userIDs[1]
+
+	ownRuleID, err := db.InsertAlertRule(&models.AlertRule{Name: "own rule", WebhookURL: "https://example.com/hook", Enabled: true, UserID: &owner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharedRuleID, err := db.InsertAlertRule(&models.AlertRule{Name: "shared rule", WebhookURL: "https://example.com/hook", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DeleteAlertRule(sharedRuleID, "") })
+
+	ruleIDs := func(userID string) map[string]bool {
+		t.Helper()
+		rules, err := db.GetAlertRules(false, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make(map[string]bool)
+		for _, r := range rules {
+			ids[r.ID] = true
+		}
+		return ids
+	}
+
+	if ids := ruleIDs(owner); !ids[ownRuleID] || ids[sharedRuleID] {
+		t.Errorf("owner's rules = %v, want only %s", ids, ownRuleID)
+	}
+	if ids := ruleIDs(other); ids[ownRuleID] || ids[sharedRuleID] {
+		t.Errorf("other user's rules = %v, want neither", ids)
+	}
+	if ids := ruleIDs(""); !ids[ownRuleID] || !ids[sharedRuleID] {
+		t.Errorf("every rule = %v, want both", ids)
+	}
+
+	// Another user can't delete the rule, nor the owner a shared one
+	if err := db.DeleteAlertRule(ownRuleID, other); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting another user's rule: error = %v, want ErrNotFound", err)
+	}
+	if err := db.DeleteAlertRule(sharedRuleID, owner); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting a shared rule as a user: error = %v, want ErrNotFound", err)
+	}
+	if err := db.DeleteAlertRule(ownRuleID, owner); err != nil {
+		t.Errorf("deleting own rule: %v", err)
+	}
+	if ids := ruleIDs(""); ids[ownRuleID] || !ids[sharedRuleID] {
+		t.Errorf("rules after deleting = %v, want only %s", ids, sharedRuleID)
+	}
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// createAuthTables creates the API key and session tables
+func (db *Database) createAuthTables() error {
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name VARCHAR(255) NOT NULL,
+			key_hash CHAR(64) NOT NULL UNIQUE,
+			prefix VARCHAR(16) NOT NULL,
+			scopes TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			last_used_at TIMESTAMPTZ,
+			revoked_at TIMESTAMPTZ
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating api_keys table: %v", err)
+	}
+
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS sessions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			token_hash CHAR(64) NOT NULL UNIQUE,
+			api_key_id UUID REFERENCES api_keys(id) ON DELETE CASCADE,
+			scopes TEXT[] NOT NULL DEFAULT '{}',
+			expires_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating sessions table: %v", err)
+	}
+
+	// Sessions signed in with the bootstrap admin key record its hash, so
+	// they end once the key is rotated
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE sessions ADD COLUMN IF NOT EXISTS bootstrap_key_hash CHAR(64)
+	`)
+	if err != nil {
+		return fmt.Errorf("error adding bootstrap_key_hash to sessions table: %v", err)
+	}
+
+	return nil
+}
+
+// InsertAPIKey stores a new API key by its hash and returns its ID. A nil
+// userID creates a key not tied to any user.
+func (db *Database) InsertAPIKey(userID *string, name, keyHash, prefix string, scopes []string) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		INSERT INTO api_keys (user_id, name, key_hash, prefix, scopes)
+		VALUES ($1::uuid, $2, $3, $4, $5)
+		RETURNING id
+	`, userID, name, keyHash, prefix, nonNilStrings(scopes)).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("error inserting API key: %v", err)
+	}
+	return id, nil
+}
+
+// GetActiveAPIKey returns the unrevoked API key with the given hash and
+// records that it was used. It returns nil when there is none.
+func (db *Database) GetActiveAPIKey(keyHash string) (*models.APIKey, error) {
+	var k models.APIKey
+	err := db.pool.QueryRow(context.Background(), `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		RETURNING id, user_id, name, prefix, scopes, created_at, last_used_at, revoked_at
+	`, keyHash).Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.Scopes, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying API key: %v", err)
+	}
+	return &k, nil
+}
+
+// GetAPIKeys returns every API key, newest first
+func (db *Database) GetAPIKeys() ([]models.APIKey, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT id, user_id, name, prefix, scopes, created_at, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying API keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.Scopes, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+			return nil, fmt.Errorf("error scanning API key: %v", err)
+		}
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %v", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an API key and ends the sessions signed in with it
+func (db *Database) RevokeAPIKey(id string) error {
+	tag, err := db.pool.Exec(context.Background(), `
+		UPDATE api_keys SET revoked_at = NOW() WHERE id = $1::uuid AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("error revoking API key: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("API key %s %w", id, ErrNotFound)
+	}
+
+	_, err = db.pool.Exec(context.Background(), `DELETE FROM sessions WHERE api_key_id = $1::uuid`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting sessions of revoked API key: %v", err)
+	}
+	return nil
+}
+
+// InsertSession stores a new session by its token hash. bootstrapKeyHash is
+// the hash of the bootstrap admin key the session was signed in with, or
+// empty for sessions of stored keys and users.
+func (db *Database) InsertSession(tokenHash string, apiKeyID, userID *string, bootstrapKeyHash string, scopes []string, expiresAt time.Time) error {
+	_, err := db.pool.Exec(context.Background(), `
+		INSERT INTO sessions (token_hash, api_key_id, user_id, bootstrap_key_hash, scopes, expires_at)
+		VALUES ($1, $2::uuid, $3::uuid, NULLIF($4, ''), $5, $6)
+	`, tokenHash, apiKeyID, userID, bootstrapKeyHash, nonNilStrings(scopes), expiresAt)
+	if err != nil {
+		return fmt.Errorf("error inserting session: %v", err)
+	}
+	return nil
+}
+
+// GetSession returns the unexpired session with the given token hash, or
+// nil when there is none. Sessions signed in with the bootstrap admin key
+// only count while bootstrapKeyHash is still that key's hash; those stored
+// before the hash was recorded have neither an API key nor a user, and never
+// count.
+func (db *Database) GetSession(tokenHash, bootstrapKeyHash string) (*models.Session, error) {
+	var s models.Session
+	err := db.pool.QueryRow(context.Background(), `
+		SELECT id, api_key_id, user_id, scopes, expires_at, created_at
+		FROM sessions
+		WHERE token_hash = $1 AND expires_at > NOW()
+		  AND (api_key_id IS NOT NULL OR user_id IS NOT NULL OR bootstrap_key_hash = NULLIF($2, ''))
+	`, tokenHash, bootstrapKeyHash).Scan(&s.ID, &s.APIKeyID, &s.UserID, &s.Scopes, &s.ExpiresAt, &s.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying session: %v", err)
+	}
+	return &s, nil
+}
+
+// DeleteSession ends a session
+func (db *Database) DeleteSession(tokenHash string) error {
+	_, err := db.pool.Exec(context.Background(), `DELETE FROM sessions WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return fmt.Errorf("error deleting session: %v", err)
+	}
+	return nil
+}
+
+// DeleteExpiredSessions removes sessions past their expiry
+func (db *Database) DeleteExpiredSessions() error {
+	_, err := db.pool.Exec(context.Background(), `DELETE FROM sessions WHERE expires_at <= NOW()`)
+	if err != nil {
+		return fmt.Errorf("error deleting expired sessions: %v", err)
+	}
+	return nil
+}
@@ -2,12 +2,19 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mswatii/cs2-arbitrage/internal/models"
 	"os"
+	"time"
 )
 
+// ErrNotFound is wrapped by errors for lookups, updates and deletes of
+// records that don't exist
+var ErrNotFound = errors.New("not found")
+
 type Database struct {
 	pool *pgxpool.Pool
 }
@@ -116,6 +123,132 @@ func (db *Database) CreateTables() error {
 		return fmt.Errorf("error creating items table: %v", err)
 	}
 
+	// Create base_skins table (weapon and finish shared across variants)
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS base_skins (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			weapon VARCHAR(255) NOT NULL,
+			finish VARCHAR(255) NOT NULL DEFAULT '',
+			category VARCHAR(255) NOT NULL,
+			rarity VARCHAR(50) NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			UNIQUE(weapon, finish)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating base_skins table: %v", err)
+	}
+
+	// Add variant flags and the base skin link to skins created before they existed
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE skins
+			ADD COLUMN IF NOT EXISTS base_skin_id UUID REFERENCES base_skins(id),
+			ADD COLUMN IF NOT EXISTS is_souvenir BOOLEAN NOT NULL DEFAULT false,
+			ADD COLUMN IF NOT EXISTS is_star BOOLEAN NOT NULL DEFAULT false
+	`)
+	if err != nil {
+		return fmt.Errorf("error adding variant columns to skins table: %v", err)
+	}
+
+	// Items without wear (cases, stickers, agents...) have no quality
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE skins ALTER COLUMN quality DROP NOT NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("error making skins quality nullable: %v", err)
+	}
+
+	// Add pattern attributes to items created before they existed
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE items
+			ADD COLUMN IF NOT EXISTS paint_index INTEGER,
+			ADD COLUMN IF NOT EXISTS paint_seed INTEGER,
+			ADD COLUMN IF NOT EXISTS phase VARCHAR(50),
+			ADD COLUMN IF NOT EXISTS fade_percent DECIMAL(5,2),
+			ADD COLUMN IF NOT EXISTS label_mismatch BOOLEAN NOT NULL DEFAULT false
+	`)
+	if err != nil {
+		return fmt.Errorf("error adding pattern columns to items table: %v", err)
+	}
+
+	// When the trade hold of a listing ends, parsed from tradeable
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS trade_hold_until TIMESTAMPTZ
+	`)
+	if err != nil {
+		return fmt.Errorf("error adding trade_hold_until to items table: %v", err)
+	}
+
+	// Create phase_prices table (reference prices per Doppler phase)
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS phase_prices (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			market_hash_name VARCHAR(255) NOT NULL,
+			phase VARCHAR(50) NOT NULL,
+			price_usd DECIMAL(15,2) NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			UNIQUE(market_hash_name, phase)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating phase_prices table: %v", err)
+	}
+
+	// Create sticker_prices table (sticker catalogue with reference prices)
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS sticker_prices (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name VARCHAR(255) NOT NULL UNIQUE,
+			image_url TEXT NOT NULL DEFAULT '',
+			price_usd DECIMAL(15,2) NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating sticker_prices table: %v", err)
+	}
+
+	if err := db.createAlertTables(); err != nil {
+		return err
+	}
+
+	if err := db.createTelegramTables(); err != nil {
+		return err
+	}
+
+	if err := db.createOpportunityTables(); err != nil {
+		return err
+	}
+
+	if err := db.createSteamTables(); err != nil {
+		return err
+	}
+
+	if err := db.createPortfolioTables(); err != nil {
+		return err
+	}
+
+	if err := db.createPriceHistoryTables(); err != nil {
+		return err
+	}
+
+	if err := db.createQuarantineTables(); err != nil {
+		return err
+	}
+
+	if err := db.createPhysicalItemTables(); err != nil {
+		return err
+	}
+
+	if err := db.createAuthTables(); err != nil {
+		return err
+	}
+
+	if err := db.createUserTables(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -123,12 +256,12 @@ func (db *Database) CreateTables() error {
 func (db *Database) GetSkinByMarketHashName(marketHashName string) (*models.Skin, error) {
 	skin := &models.Skin{}
 	err := db.pool.QueryRow(context.Background(), `
-		SELECT id, market_hash_name, category, sub_category, skin_name, 
-		       is_stattrak, quality, min_float, max_float, icon_url
+		SELECT id, COALESCE(base_skin_id::text, ''), market_hash_name, category, sub_category, skin_name, 
+		       is_stattrak, is_souvenir, is_star, COALESCE(quality, ''), min_float, max_float, icon_url
 		FROM skins WHERE market_hash_name = $1
 	`, marketHashName).Scan(
-		&skin.ID, &skin.MarketHashName, &skin.Category, &skin.SubCategory, &skin.SkinName,
-		&skin.IsStatTrak, &skin.Quality, &skin.MinFloat, &skin.MaxFloat, &skin.IconURL,
+		&skin.ID, &skin.BaseSkinID, &skin.MarketHashName, &skin.Category, &skin.SubCategory, &skin.SkinName,
+		&skin.IsStatTrak, &skin.IsSouvenir, &skin.IsStar, &skin.Quality, &skin.MinFloat, &skin.MaxFloat, &skin.IconURL,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("skin not found: %v", err)
@@ -142,23 +275,26 @@ func (db *Database) InsertSkin(skin *models.Skin) (string, error) {
 	err := db.pool.QueryRow(context.Background(), `
 		INSERT INTO skins (
 			market_hash_name, category, sub_category, skin_name, is_stattrak,
-			quality, min_float, max_float, icon_url
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			quality, min_float, max_float, icon_url, base_skin_id, is_souvenir, is_star
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, NULLIF($10, '')::uuid, $11, $12)
 		ON CONFLICT (market_hash_name) 
 		DO UPDATE SET 
 			category = $2,
 			sub_category = $3,
 			skin_name = $4,
 			is_stattrak = $5,
-			quality = $6,
+			quality = NULLIF($6, ''),
 			min_float = $7,
 			max_float = $8,
 			icon_url = $9,
+			base_skin_id = NULLIF($10, '')::uuid,
+			is_souvenir = $11,
+			is_star = $12,
 			updated_at = NOW()
 		RETURNING id
 	`,
 		skin.MarketHashName, skin.Category, skin.SubCategory, skin.SkinName, skin.IsStatTrak,
-		skin.Quality, skin.MinFloat, skin.MaxFloat, skin.IconURL,
+		skin.Quality, skin.MinFloat, skin.MaxFloat, skin.IconURL, skin.BaseSkinID, skin.IsSouvenir, skin.IsStar,
 	).Scan(&id)
 
 	if err != nil {
@@ -168,14 +304,127 @@ func (db *Database) InsertSkin(skin *models.Skin) (string, error) {
 	return id, nil
 }
 
+// UpsertBaseSkin inserts or updates a base skin and returns its ID
+func (db *Database) UpsertBaseSkin(base *models.BaseSkin) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		INSERT INTO base_skins (weapon, finish, category, rarity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (weapon, finish)
+		DO UPDATE SET
+			category = $3,
+			rarity = CASE WHEN $4 = '' THEN base_skins.rarity ELSE $4 END,
+			updated_at = NOW()
+		RETURNING id
+	`, base.Weapon, base.Finish, base.Category, base.Rarity).Scan(&id)
+
+	if err != nil {
+		return "", fmt.Errorf("error upserting base skin: %v", err)
+	}
+
+	return id, nil
+}
+
+// GetBaseSkin retrieves a base skin by weapon and finish
+func (db *Database) GetBaseSkin(weapon, finish string) (*models.BaseSkin, error) {
+	base := &models.BaseSkin{}
+	err := db.pool.QueryRow(context.Background(), `
+		SELECT id, weapon, finish, category, rarity, created_at, updated_at
+		FROM base_skins WHERE LOWER(weapon) = LOWER($1) AND LOWER(finish) = LOWER($2)
+	`, weapon, finish).Scan(
+		&base.ID, &base.Weapon, &base.Finish, &base.Category, &base.Rarity, &base.CreatedAt, &base.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("base skin %s | %s %w", weapon, finish, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting base skin: %v", err)
+	}
+	return base, nil
+}
+
+// GetVariantPrices summarizes current listings for every variant (wear,
+// StatTrak, Souvenir) of a base skin
+func (db *Database) GetVariantPrices(baseSkinID string) ([]models.VariantPrice, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT
+			s.id,
+			s.market_hash_name,
+			COALESCE(s.quality, ''),
+			s.is_stattrak,
+			s.is_souvenir,
+			COUNT(i.id),
+			COALESCE(MIN(i.price_usd), 0),
+			COALESCE(AVG(i.price_usd), 0),
+			COALESCE(MAX(i.steam_price_usd), 0),
+			COUNT(i.id) FILTER (WHERE i.label_mismatch)
+		FROM skins s
+		LEFT JOIN items i ON i.skin_id = s.id AND i.price_usd > 0 AND NOT i.quarantined
+		WHERE s.base_skin_id = $1
+		GROUP BY s.id
+		ORDER BY s.is_souvenir, s.is_stattrak,
+			CASE s.quality
+				WHEN 'Factory New' THEN 1
+				WHEN 'Minimal Wear' THEN 2
+				WHEN 'Field-Tested' THEN 3
+				WHEN 'Well-Worn' THEN 4
+				WHEN 'Battle-Scarred' THEN 5
+				ELSE 6
+			END
+	`, baseSkinID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying variant prices: %v", err)
+	}
+	defer rows.Close()
+
+	var variants []models.VariantPrice
+	for rows.Next() {
+		var v models.VariantPrice
+		err := rows.Scan(
+			&v.SkinID, &v.MarketHashName, &v.Quality, &v.IsStatTrak, &v.IsSouvenir,
+			&v.Listings, &v.LowestPriceUSD, &v.AveragePriceUSD, &v.SteamPriceUSD, &v.MislabeledListings,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning variant price: %v", err)
+		}
+		variants = append(variants, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating variant prices: %v", err)
+	}
+
+	return variants, nil
+}
+
+// GetItemID returns the ID of a marketplace listing's stored item, or an
+// empty string when it was never stored
+func (db *Database) GetItemID(marketplaceID, marketItemID string) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		SELECT id FROM items WHERE marketplace_id = $1::uuid AND market_item_id = $2
+	`, marketplaceID, marketItemID).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error querying item: %v", err)
+	}
+	return id, nil
+}
+
 // InsertItem inserts an item into the database
 func (db *Database) InsertItem(item *models.Item) (string, error) {
 	var id string
 	err := db.pool.QueryRow(context.Background(), `
 		INSERT INTO items (
 			skin_id, marketplace_id, float, stickers, price, price_failed,
-			price_usd, steam_price_usd, tradeable, is_fast_sell, market_item_id
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			price_usd, steam_price_usd, tradeable, is_fast_sell, market_item_id,
+			paint_index, paint_seed, phase, fade_percent, label_mismatch, trade_hold_until,
+			asset_id, physical_item_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+			NULLIF($12::INTEGER, 0), NULLIF($13::INTEGER, 0), NULLIF($14::VARCHAR, ''), NULLIF($15::DECIMAL, 0), $16, $17,
+			NULLIF($18::VARCHAR, ''), NULLIF($19, '')::uuid)
 		ON CONFLICT (marketplace_id, market_item_id) 
 		DO UPDATE SET 
 			skin_id = $1,
@@ -187,11 +436,22 @@ func (db *Database) InsertItem(item *models.Item) (string, error) {
 			steam_price_usd = $8,
 			tradeable = $9,
 			is_fast_sell = $10,
+			paint_index = NULLIF($12::INTEGER, 0),
+			paint_seed = NULLIF($13::INTEGER, 0),
+			phase = NULLIF($14::VARCHAR, ''),
+			fade_percent = NULLIF($15::DECIMAL, 0),
+			label_mismatch = $16,
+			trade_hold_until = $17,
+			asset_id = COALESCE(NULLIF($18::VARCHAR, ''), items.asset_id),
+			physical_item_id = COALESCE(NULLIF($19, '')::uuid, items.physical_item_id),
+			quarantined = false,
 			updated_at = NOW()
 		RETURNING id
 	`,
 		item.SkinID, item.MarketplaceID, item.Float, item.Stickers, item.Price, item.PriceFailed,
 		item.PriceUSD, item.SteamPriceUSD, item.Tradeable, item.IsFastSell, item.MarketItemID,
+		item.PaintIndex, item.PaintSeed, item.Phase, item.FadePercent, item.LabelMismatch,
+		item.TradeHoldUntil, item.AssetID, item.PhysicalItemID,
 	).Scan(&id)
 
 	if err != nil {
@@ -225,57 +485,165 @@ func (db *Database) InsertMarketplace(marketplace *models.Marketplace) (string,
 	return id, nil
 }
 
-// ExecuteQuery executes a SQL query and returns the results
-func (db *Database) ExecuteQuery(query string, args ...interface{}) ([]struct {
+// SeedStickerPrices inserts sticker prices that are not in the catalogue yet,
+// leaving existing prices untouched
+func (db *Database) SeedStickerPrices(prices []models.StickerPrice) error {
+	for _, p := range prices {
+		_, err := db.pool.Exec(context.Background(), `
+			INSERT INTO sticker_prices (name, image_url, price_usd)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (name) DO NOTHING
+		`, p.Name, p.ImageURL, p.PriceUSD)
+		if err != nil {
+			return fmt.Errorf("error seeding sticker price %s: %v", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// UpsertStickerPrice inserts or updates a sticker reference price
+func (db *Database) UpsertStickerPrice(price *models.StickerPrice) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		INSERT INTO sticker_prices (name, image_url, price_usd)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name)
+		DO UPDATE SET
+			image_url = CASE WHEN $2 = '' THEN sticker_prices.image_url ELSE $2 END,
+			price_usd = $3,
+			updated_at = NOW()
+		RETURNING id
+	`, price.Name, price.ImageURL, price.PriceUSD).Scan(&id)
+
+	if err != nil {
+		return "", fmt.Errorf("error upserting sticker price: %v", err)
+	}
+
+	return id, nil
+}
+
+// GetStickerPrices returns the whole sticker catalogue
+func (db *Database) GetStickerPrices() ([]models.StickerPrice, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT id, name, image_url, price_usd, updated_at
+		FROM sticker_prices ORDER BY price_usd DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sticker prices: %v", err)
+	}
+	defer rows.Close()
+
+	var prices []models.StickerPrice
+	for rows.Next() {
+		var p models.StickerPrice
+		if err := rows.Scan(&p.ID, &p.Name, &p.ImageURL, &p.PriceUSD, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning sticker price: %v", err)
+		}
+		prices = append(prices, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sticker prices: %v", err)
+	}
+
+	return prices, nil
+}
+
+// UpsertPhasePrice inserts or updates the reference price of a Doppler phase
+func (db *Database) UpsertPhasePrice(price *models.PhasePrice) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		INSERT INTO phase_prices (market_hash_name, phase, price_usd)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (market_hash_name, phase)
+		DO UPDATE SET
+			price_usd = $3,
+			updated_at = NOW()
+		RETURNING id
+	`, price.MarketHashName, price.Phase, price.PriceUSD).Scan(&id)
+
+	if err != nil {
+		return "", fmt.Errorf("error upserting phase price: %v", err)
+	}
+
+	return id, nil
+}
+
+// GetPhasePrices returns all phase reference prices
+func (db *Database) GetPhasePrices() ([]models.PhasePrice, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT id, market_hash_name, phase, price_usd, updated_at
+		FROM phase_prices ORDER BY market_hash_name, phase
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying phase prices: %v", err)
+	}
+	defer rows.Close()
+
+	var prices []models.PhasePrice
+	for rows.Next() {
+		var p models.PhasePrice
+		if err := rows.Scan(&p.ID, &p.MarketHashName, &p.Phase, &p.PriceUSD, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning phase price: %v", err)
+		}
+		prices = append(prices, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating phase prices: %v", err)
+	}
+
+	return prices, nil
+}
+
+// ArbitrageRow is a single row returned by an arbitrage query
+type ArbitrageRow struct {
 	MarketHashName string
 	BuyPriceUSD    float64
 	SellPriceUSD   float64
 	ProfitUSD      float64
 	ProfitPercent  float64
 	Marketplace    string
-	Float          float64
+	Float          *float64
 	Quality        string
 	IconURL        string
 	Category       string
 	IsStatTrak     bool
 	Stickers       []string
-}, error) {
+	MinFloat       *float64
+	MaxFloat       *float64
+	PaintIndex     int
+	PaintSeed      int
+	Phase          string
+	FadePercent    float64
+	PriceSource    string // "steam" or "phase"
+	BaseSkinID     string
+	IsSouvenir     bool
+	LabelMismatch  bool
+	ItemID         string
+	MarketItemID   string
+
+	// Steam market figures, nil when not fetched yet
+	SteamLowestPriceUSD *float64
+	SteamMedianPriceUSD *float64
+	SteamDailyVolume    *int
+	SteamListingCount   *int
+
+	TradeHoldUntil *time.Time
+}
+
+// ExecuteQuery executes a SQL query and returns the results
+func (db *Database) ExecuteQuery(query string, args ...interface{}) ([]ArbitrageRow, error) {
 	rows, err := db.pool.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error executing query: %v", err)
 	}
 	defer rows.Close()
 
-	var results []struct {
-		MarketHashName string
-		BuyPriceUSD    float64
-		SellPriceUSD   float64
-		ProfitUSD      float64
-		ProfitPercent  float64
-		Marketplace    string
-		Float          float64
-		Quality        string
-		IconURL        string
-		Category       string
-		IsStatTrak     bool
-		Stickers       []string
-	}
+	var results []ArbitrageRow
 
 	for rows.Next() {
-		var result struct {
-			MarketHashName string
-			BuyPriceUSD    float64
-			SellPriceUSD   float64
-			ProfitUSD      float64
-			ProfitPercent  float64
-			Marketplace    string
-			Float          float64
-			Quality        string
-			IconURL        string
-			Category       string
-			IsStatTrak     bool
-			Stickers       []string
-		}
+		var result ArbitrageRow
 
 		err := rows.Scan(
 			&result.MarketHashName,
@@ -290,6 +658,23 @@ func (db *Database) ExecuteQuery(query string, args ...interface{}) ([]struct {
 			&result.Category,
 			&result.IsStatTrak,
 			&result.Stickers,
+			&result.MinFloat,
+			&result.MaxFloat,
+			&result.PaintIndex,
+			&result.PaintSeed,
+			&result.Phase,
+			&result.FadePercent,
+			&result.PriceSource,
+			&result.BaseSkinID,
+			&result.IsSouvenir,
+			&result.LabelMismatch,
+			&result.ItemID,
+			&result.MarketItemID,
+			&result.SteamLowestPriceUSD,
+			&result.SteamMedianPriceUSD,
+			&result.SteamDailyVolume,
+			&result.SteamListingCount,
+			&result.TradeHoldUntil,
 		)
 
 		if err != nil {
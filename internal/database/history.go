@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// createPriceHistoryTables creates the listing price history table
+func (db *Database) createPriceHistoryTables() error {
+	// Prices are kept in the marketplace currency so a skin's distribution
+	// isn't skewed by exchange rate moves
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS price_history (
+			id BIGSERIAL PRIMARY KEY,
+			item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+			skin_id UUID NOT NULL REFERENCES skins(id),
+			marketplace_id UUID NOT NULL REFERENCES marketplaces(id),
+			price DECIMAL(15,2) NOT NULL,
+			price_usd DECIMAL(15,2),
+			observed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating price_history table: %v", err)
+	}
+
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE INDEX IF NOT EXISTS price_history_skin_idx
+		ON price_history (skin_id, marketplace_id, observed_at DESC)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating price_history index: %v", err)
+	}
+
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE INDEX IF NOT EXISTS price_history_item_idx
+		ON price_history (item_id, observed_at DESC)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating price_history item index: %v", err)
+	}
+
+	return nil
+}
+
+// RecordPriceObservation appends a listing's price to the price history,
+// unless it is unchanged since the listing's last observation within the
+// past day. Re-scraping an unchanged listing therefore adds at most one
+// observation a day.
+func (db *Database) RecordPriceObservation(item *models.Item) error {
+	if item.ID == "" || item.Price <= 0 {
+		return nil
+	}
+
+	_, err := db.pool.Exec(context.Background(), `
+		INSERT INTO price_history (item_id, skin_id, marketplace_id, price, price_usd, physical_item_id)
+		SELECT $1::uuid, $2::uuid, $3::uuid, $4, NULLIF($5::DECIMAL, 0), NULLIF($6, '')::uuid
+		WHERE NOT EXISTS (
+			SELECT 1 FROM (
+				SELECT price, observed_at
+				FROM price_history
+				WHERE item_id = $1::uuid
+				ORDER BY observed_at DESC
+				LIMIT 1
+			) last
+			WHERE last.price = $4 AND last.observed_at > NOW() - INTERVAL '1 day'
+		)
+	`, item.ID, item.SkinID, item.MarketplaceID, item.Price, item.PriceUSD, item.PhysicalItemID)
+	if err != nil {
+		return fmt.Errorf("error recording price observation: %v", err)
+	}
+	return nil
+}
+
+// PriceHistoryListing is a recent listing together with recent prices of
+// the same skin on the same marketplace, excluding the listing itself and
+// earlier listings of the same physical item
+type PriceHistoryListing struct {
+	ItemID         string
+	MarketItemID   string
+	MarketHashName string
+	Category       string
+	Quality        string
+	IconURL        string
+	IsStatTrak     bool
+	Float          *float64
+	Stickers       []string
+
+	Marketplace    string
+	SaleFeePercent float64
+	Price          float64 // Marketplace currency
+	PriceUSD       float64
+	SteamPriceUSD  float64
+
+	// History holds the latest price of each recent listing, newest first
+	History []float64
+}
+
+// GetPriceHistoryListings returns the listings scraped within the last day,
+// each with the latest prices of up to window other listings of its skin on
+// its marketplace. Each listing, or physical item when known, counts once so
+// a long-lived or often repriced listing can't dominate the distribution.
+func (db *Database) GetPriceHistoryListings(window int) ([]PriceHistoryListing, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT i.id, i.market_item_id, s.market_hash_name, s.category, COALESCE(s.quality, ''),
+		       s.icon_url, s.is_stattrak, i.float, COALESCE(i.stickers, '{}'),
+		       m.name, COALESCE(f.sale_fee_percent, 0),
+		       i.price, COALESCE(i.price_usd, 0), COALESCE(i.steam_price_usd, 0),
+		       h.prices
+		FROM items i
+		JOIN skins s ON s.id = i.skin_id
+		JOIN marketplaces m ON m.id = i.marketplace_id
+		LEFT JOIN marketplace_fees f ON f.marketplace_id = m.id
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(ARRAY_AGG(recent.price ORDER BY recent.observed_at DESC), '{}') AS prices
+			FROM (
+				SELECT latest.price, latest.observed_at
+				FROM (
+					SELECT DISTINCT ON (COALESCE(ph.physical_item_id, ph.item_id))
+					       ph.price::float8 AS price, ph.observed_at
+					FROM price_history ph
+					JOIN items hi ON hi.id = ph.item_id AND NOT hi.quarantined
+					WHERE ph.skin_id = i.skin_id
+					  AND ph.marketplace_id = i.marketplace_id
+					  AND ph.item_id <> i.id
+					  AND (i.physical_item_id IS NULL OR ph.physical_item_id IS DISTINCT FROM i.physical_item_id)
+					ORDER BY COALESCE(ph.physical_item_id, ph.item_id), ph.observed_at DESC
+				) latest
+				ORDER BY latest.observed_at DESC
+				LIMIT $1
+			) recent
+		) h
+		WHERE i.price > 0 AND NOT i.quarantined AND i.updated_at > NOW() - INTERVAL '1 day'
+	`, window)
+	if err != nil {
+		return nil, fmt.Errorf("error querying price history listings: %v", err)
+	}
+	defer rows.Close()
+
+	var listings []PriceHistoryListing
+	for rows.Next() {
+		var l PriceHistoryListing
+		err := rows.Scan(
+			&l.ItemID, &l.MarketItemID, &l.MarketHashName, &l.Category, &l.Quality,
+			&l.IconURL, &l.IsStatTrak, &l.Float, &l.Stickers,
+			&l.Marketplace, &l.SaleFeePercent,
+			&l.Price, &l.PriceUSD, &l.SteamPriceUSD,
+			&l.History,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning price history listing: %v", err)
+		}
+		listings = append(listings, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price history listings: %v", err)
+	}
+
+	return listings, nil
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// UpsertMarketplaceFee stores the fees of a marketplace, replacing any
+// previous ones
+func (db *Database) UpsertMarketplaceFee(fee *models.MarketplaceFee) error {
+	tag, err := db.pool.Exec(context.Background(), `
+		UPDATE marketplace_fees SET
+			listing_fee_percent = $2,
+			sale_fee_percent = $3,
+			fast_sell_fee_percent = $4
+		WHERE marketplace_id = $1::uuid
+	`, fee.MarketplaceID, fee.ListingFeePercent, fee.SaleFeePercent, fee.FastSellFeePercent)
+	if err != nil {
+		return fmt.Errorf("error updating marketplace fee: %v", err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	_, err = db.pool.Exec(context.Background(), `
+		INSERT INTO marketplace_fees (
+			marketplace_id, listing_fee_percent, sale_fee_percent, fast_sell_fee_percent
+		) VALUES ($1::uuid, $2, $3, $4)
+	`, fee.MarketplaceID, fee.ListingFeePercent, fee.SaleFeePercent, fee.FastSellFeePercent)
+	if err != nil {
+		return fmt.Errorf("error inserting marketplace fee: %v", err)
+	}
+	return nil
+}
+
+// MarketAsk is the cheapest recent listing of a skin on one marketplace,
+// with the skin's Steam prices for comparison
+type MarketAsk struct {
+	SkinID         string
+	MarketHashName string
+	Category       string
+	Quality        string
+	IconURL        string
+	IsStatTrak     bool
+
+	// Float band of the listings when grouping by float, nil otherwise
+	FloatBandMin *float64
+	FloatBandMax *float64
+
+	MarketplaceID      string
+	Marketplace        string
+	ListingFeePercent  float64
+	SaleFeePercent     float64
+	LowestPriceUSD     float64
+	ListingCount       int
+	LowestMarketItemID string
+
+	// Steam side: the lowest Steam listing when known, otherwise the Steam
+	// price reported alongside the listings
+	SteamPriceUSD float64
+	DailyVolume   *int
+}
+
+// GetMarketAsks returns the lowest ask of every skin on every marketplace
+// among listings scraped within the last day. A positive floatBandWidth
+// splits each skin into float bands of that width, with items without a
+// float in a band of their own.
+func (db *Database) GetMarketAsks(floatBandWidth float64) ([]MarketAsk, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		WITH banded AS (
+			SELECT i.*,
+			       CASE WHEN $1::float8 > 0 AND i.float IS NOT NULL
+			            THEN FLOOR(i.float::float8 / $1::float8) END AS band
+			FROM items i
+			WHERE i.price_usd > 0 AND NOT i.quarantined AND i.updated_at > NOW() - INTERVAL '1 day'
+		),
+		recent AS (
+			SELECT skin_id, marketplace_id, price_usd, steam_price_usd, market_item_id, band,
+			       ROW_NUMBER() OVER (PARTITION BY skin_id, band, marketplace_id ORDER BY price_usd) AS rank,
+			       COUNT(*) OVER (PARTITION BY skin_id, band, marketplace_id) AS listing_count,
+			       MAX(steam_price_usd) OVER (PARTITION BY skin_id) AS steam_price_usd_max
+			FROM banded
+		)
+		SELECT s.id, s.market_hash_name, s.category, COALESCE(s.quality, ''), s.icon_url, s.is_stattrak,
+		       r.band * $1::float8, (r.band + 1) * $1::float8,
+		       m.id, m.name,
+		       COALESCE(f.listing_fee_percent, 0), COALESCE(f.sale_fee_percent, 0),
+		       r.price_usd, r.listing_count, r.market_item_id,
+		       COALESCE(NULLIF(st.lowest_price_usd, 0), r.steam_price_usd_max, 0),
+		       st.daily_volume
+		FROM recent r
+		JOIN skins s ON s.id = r.skin_id
+		JOIN marketplaces m ON m.id = r.marketplace_id
+		LEFT JOIN marketplace_fees f ON f.marketplace_id = m.id
+		LEFT JOIN steam_price_stats st ON st.skin_id = s.id
+		WHERE r.rank = 1
+		ORDER BY s.market_hash_name, r.band NULLS FIRST, m.name
+	`, floatBandWidth)
+	if err != nil {
+		return nil, fmt.Errorf("error querying market asks: %v", err)
+	}
+	defer rows.Close()
+
+	var asks []MarketAsk
+	for rows.Next() {
+		var a MarketAsk
+		err := rows.Scan(
+			&a.SkinID, &a.MarketHashName, &a.Category, &a.Quality, &a.IconURL, &a.IsStatTrak,
+			&a.FloatBandMin, &a.FloatBandMax,
+			&a.MarketplaceID, &a.Marketplace,
+			&a.ListingFeePercent, &a.SaleFeePercent,
+			&a.LowestPriceUSD, &a.ListingCount, &a.LowestMarketItemID,
+			&a.SteamPriceUSD, &a.DailyVolume,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning market ask: %v", err)
+		}
+		asks = append(asks, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating market asks: %v", err)
+	}
+
+	return asks, nil
+}
+
+// TradeUpCandidate is a recent listing that could go into a trade-up contract
+type TradeUpCandidate struct {
+	ItemID         string
+	MarketHashName string
+	Marketplace    string
+	PriceUSD       float64
+	Float          float64
+	MinFloat       *float64
+	MaxFloat       *float64
+	IsStatTrak     bool
+}
+
+// GetTradeUpCandidates returns listings scraped within the last day that
+// have a float and are not souvenirs, which can't be traded up
+func (db *Database) GetTradeUpCandidates() ([]TradeUpCandidate, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT i.id, s.market_hash_name, m.name, i.price_usd, i.float,
+		       s.min_float, s.max_float, s.is_stattrak
+		FROM items i
+		JOIN skins s ON s.id = i.skin_id
+		JOIN marketplaces m ON m.id = i.marketplace_id
+		WHERE i.price_usd > 0
+		  AND i.float IS NOT NULL
+		  AND NOT i.quarantined
+		  AND NOT s.is_souvenir
+		  AND i.updated_at > NOW() - INTERVAL '1 day'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying trade-up candidates: %v", err)
+	}
+	defer rows.Close()
+
+	var candidates []TradeUpCandidate
+	for rows.Next() {
+		var c TradeUpCandidate
+		err := rows.Scan(&c.ItemID, &c.MarketHashName, &c.Marketplace, &c.PriceUSD, &c.Float, &c.MinFloat, &c.MaxFloat, &c.IsStatTrak)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning trade-up candidate: %v", err)
+		}
+		candidates = append(candidates, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trade-up candidates: %v", err)
+	}
+
+	return candidates, nil
+}
+
+// GetTradeUpCandidatesByID returns the given listings as trade-up inputs,
+// whatever their age. Quarantined listings are left out, as are those
+// without a float since their output float can't be worked out.
+func (db *Database) GetTradeUpCandidatesByID(itemIDs []string) ([]TradeUpCandidate, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT i.id, s.market_hash_name, m.name, i.price_usd, i.float,
+		       s.min_float, s.max_float, s.is_stattrak
+		FROM items i
+		JOIN skins s ON s.id = i.skin_id
+		JOIN marketplaces m ON m.id = i.marketplace_id
+		WHERE i.id::text = ANY($1) AND i.float IS NOT NULL AND NOT i.quarantined
+	`, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error querying trade-up inputs: %v", err)
+	}
+	defer rows.Close()
+
+	var candidates []TradeUpCandidate
+	for rows.Next() {
+		var c TradeUpCandidate
+		err := rows.Scan(&c.ItemID, &c.MarketHashName, &c.Marketplace, &c.PriceUSD, &c.Float, &c.MinFloat, &c.MaxFloat, &c.IsStatTrak)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning trade-up input: %v", err)
+		}
+		candidates = append(candidates, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trade-up inputs: %v", err)
+	}
+
+	return candidates, nil
+}
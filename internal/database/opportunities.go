@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// createOpportunityTables creates the opportunity history table
+func (db *Database) createOpportunityTables() error {
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS opportunities (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+			marketplace_id UUID NOT NULL REFERENCES marketplaces(id),
+			market_hash_name VARCHAR(255) NOT NULL,
+			category VARCHAR(100) NOT NULL DEFAULT '',
+			open_buy_price_usd DECIMAL(15,2) NOT NULL,
+			open_sell_price_usd DECIMAL(15,2) NOT NULL,
+			buy_price_usd DECIMAL(15,2) NOT NULL,
+			sell_price_usd DECIMAL(15,2) NOT NULL,
+			profit_usd DECIMAL(15,2) NOT NULL,
+			profit_percent DECIMAL(10,2) NOT NULL,
+			profit_with_stickers_percent DECIMAL(10,2) NOT NULL DEFAULT 0,
+			peak_profit_usd DECIMAL(15,2) NOT NULL,
+			peak_profit_percent DECIMAL(10,2) NOT NULL,
+			first_seen TIMESTAMP NOT NULL DEFAULT NOW(),
+			last_seen TIMESTAMP NOT NULL DEFAULT NOW(),
+			closed_at TIMESTAMP,
+			close_reason VARCHAR(50) NOT NULL DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating opportunities table: %v", err)
+	}
+
+	// Older tables only recorded the plain profit
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE opportunities
+			ADD COLUMN IF NOT EXISTS profit_with_stickers_percent DECIMAL(10,2) NOT NULL DEFAULT 0
+	`)
+	if err != nil {
+		return fmt.Errorf("error migrating opportunities table: %v", err)
+	}
+
+	// At most one open opportunity per listing
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE UNIQUE INDEX IF NOT EXISTS opportunities_open_item_idx
+		ON opportunities (item_id) WHERE closed_at IS NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating opportunities index: %v", err)
+	}
+
+	return nil
+}
+
+// UpsertOpenOpportunity opens an opportunity for a listing, or refreshes the
+// latest prices, last_seen and peak profit of the one already open
+func (db *Database) UpsertOpenOpportunity(rec *models.OpportunityRecord) error {
+	_, err := db.pool.Exec(context.Background(), `
+		INSERT INTO opportunities (
+			item_id, marketplace_id, market_hash_name, category,
+			open_buy_price_usd, open_sell_price_usd, buy_price_usd, sell_price_usd,
+			profit_usd, profit_percent, profit_with_stickers_percent, peak_profit_usd, peak_profit_percent
+		) VALUES ($1::uuid, $2::uuid, $3, $4, $5, $6, $5, $6, $7, $8, $9, $7, $8)
+		ON CONFLICT (item_id) WHERE closed_at IS NULL
+		DO UPDATE SET
+			buy_price_usd = EXCLUDED.buy_price_usd,
+			sell_price_usd = EXCLUDED.sell_price_usd,
+			profit_usd = EXCLUDED.profit_usd,
+			profit_percent = EXCLUDED.profit_percent,
+			profit_with_stickers_percent = EXCLUDED.profit_with_stickers_percent,
+			peak_profit_usd = GREATEST(opportunities.peak_profit_usd, EXCLUDED.profit_usd),
+			peak_profit_percent = GREATEST(opportunities.peak_profit_percent, EXCLUDED.profit_percent),
+			last_seen = NOW()
+	`,
+		rec.ItemID, rec.MarketplaceID, rec.MarketHashName, rec.Category,
+		rec.BuyPriceUSD, rec.SellPriceUSD, rec.ProfitUSD, rec.ProfitPercent, rec.ProfitWithStickersPercent,
+	)
+	if err != nil {
+		return fmt.Errorf("error upserting opportunity: %v", err)
+	}
+	return nil
+}
+
+// GetOpenOpportunities returns the open opportunities of a marketplace, or
+// of every marketplace when marketplaceID is empty
+func (db *Database) GetOpenOpportunities(marketplaceID string) ([]models.OpportunityRecord, error) {
+	return db.queryOpportunities(`
+		WHERE closed_at IS NULL AND ($1 = '' OR marketplace_id = NULLIF($1, '')::uuid)
+		ORDER BY first_seen
+	`, marketplaceID)
+}
+
+// CloseOpportunity closes an open opportunity with a reason
+func (db *Database) CloseOpportunity(id, reason string) error {
+	_, err := db.pool.Exec(context.Background(), `
+		UPDATE opportunities SET closed_at = NOW(), close_reason = $2
+		WHERE id = $1::uuid AND closed_at IS NULL
+	`, id, reason)
+	if err != nil {
+		return fmt.Errorf("error closing opportunity: %v", err)
+	}
+	return nil
+}
+
+// OpportunityHistoryFilter narrows GetOpportunityHistory
+type OpportunityHistoryFilter struct {
+	Status         string // open, closed or empty for both
+	MarketHashName string // Substring match, case-insensitive
+	Since          time.Time
+	Limit          int
+}
+
+// GetOpportunityHistory returns stored opportunities, most recent first
+func (db *Database) GetOpportunityHistory(filter OpportunityHistoryFilter) ([]models.OpportunityRecord, error) {
+	return db.queryOpportunities(`
+		WHERE ($1 = '' OR ($1 = 'open' AND closed_at IS NULL) OR ($1 = 'closed' AND closed_at IS NOT NULL))
+		  AND ($2 = '' OR market_hash_name ILIKE '%' || $2 || '%')
+		  AND first_seen >= $3
+		ORDER BY first_seen DESC
+		LIMIT $4
+	`, filter.Status, filter.MarketHashName, filter.Since, filter.Limit)
+}
+
+// queryOpportunities selects opportunities with the given WHERE/ORDER clause
+func (db *Database) queryOpportunities(clause string, args ...interface{}) ([]models.OpportunityRecord, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT id, item_id, marketplace_id, market_hash_name, category,
+		       open_buy_price_usd, open_sell_price_usd, buy_price_usd, sell_price_usd,
+		       profit_usd, profit_percent, profit_with_stickers_percent, peak_profit_usd, peak_profit_percent,
+		       first_seen, last_seen, closed_at, close_reason,
+		       EXTRACT(EPOCH FROM COALESCE(closed_at, NOW()) - first_seen)::float8
+		FROM opportunities
+	`+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying opportunities: %v", err)
+	}
+	defer rows.Close()
+
+	var records []models.OpportunityRecord
+	for rows.Next() {
+		var r models.OpportunityRecord
+		err := rows.Scan(
+			&r.ID, &r.ItemID, &r.MarketplaceID, &r.MarketHashName, &r.Category,
+			&r.OpenBuyPriceUSD, &r.OpenSellPriceUSD, &r.BuyPriceUSD, &r.SellPriceUSD,
+			&r.ProfitUSD, &r.ProfitPercent, &r.ProfitWithStickersPercent, &r.PeakProfitUSD, &r.PeakProfitPercent,
+			&r.FirstSeen, &r.LastSeen, &r.ClosedAt, &r.CloseReason, &r.DurationSeconds,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning opportunity: %v", err)
+		}
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating opportunities: %v", err)
+	}
+
+	return records, nil
+}
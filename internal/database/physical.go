@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// createPhysicalItemTables creates the physical items that link relistings
+// of the same item across listing IDs
+func (db *Database) createPhysicalItemTables() error {
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS physical_items (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			fingerprint VARCHAR(64) NOT NULL UNIQUE,
+			skin_id UUID NOT NULL REFERENCES skins(id),
+			first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating physical_items table: %v", err)
+	}
+
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE items
+			ADD COLUMN IF NOT EXISTS asset_id VARCHAR(64),
+			ADD COLUMN IF NOT EXISTS physical_item_id UUID REFERENCES physical_items(id)
+	`)
+	if err != nil {
+		return fmt.Errorf("error adding physical item columns to items table: %v", err)
+	}
+
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE price_history ADD COLUMN IF NOT EXISTS physical_item_id UUID REFERENCES physical_items(id)
+	`)
+	if err != nil {
+		return fmt.Errorf("error adding physical_item_id to price_history table: %v", err)
+	}
+
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE INDEX IF NOT EXISTS items_physical_item_idx ON items (physical_item_id)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating items physical item index: %v", err)
+	}
+
+	return nil
+}
+
+// LinkPhysicalItem returns the physical item with the given fingerprint,
+// creating it on first sight, and marks it as seen now
+func (db *Database) LinkPhysicalItem(fingerprint, skinID string) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		INSERT INTO physical_items (fingerprint, skin_id)
+		VALUES ($1, $2::uuid)
+		ON CONFLICT (fingerprint) DO UPDATE SET last_seen_at = NOW()
+		RETURNING id
+	`, fingerprint, skinID).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("error linking physical item: %v", err)
+	}
+	return id, nil
+}
+
+// PhysicalListing is one listing of a physical item
+type PhysicalListing struct {
+	ItemID       string    `json:"item_id"`
+	MarketItemID string    `json:"market_item_id"`
+	Marketplace  string    `json:"marketplace"`
+	Price        float64   `json:"price"`
+	PriceUSD     float64   `json:"price_usd"`
+	FirstSeenAt  time.Time `json:"first_seen_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+}
+
+// PricePoint is a price observed for a physical item
+type PricePoint struct {
+	MarketItemID string    `json:"market_item_id"`
+	Price        float64   `json:"price"`
+	PriceUSD     *float64  `json:"price_usd"`
+	ObservedAt   time.Time `json:"observed_at"`
+}
+
+// PhysicalItemRecord is a physical item with every listing it appeared
+// under and its price history across them
+type PhysicalItemRecord struct {
+	ID             string            `json:"id"`
+	MarketHashName string            `json:"market_hash_name"`
+	Float          *float64          `json:"float"`
+	FirstSeenAt    time.Time         `json:"first_seen_at"`
+	LastSeenAt     time.Time         `json:"last_seen_at"`
+	Listings       []PhysicalListing `json:"listings"`
+	History        []PricePoint      `json:"history"`
+}
+
+// GetPhysicalItem returns a physical item by its ID, or by the ID of any of
+// its listings when itemID is set. It returns nil when not found.
+func (db *Database) GetPhysicalItem(physicalItemID, itemID string) (*PhysicalItemRecord, error) {
+	var rec PhysicalItemRecord
+	err := db.pool.QueryRow(context.Background(), `
+		SELECT p.id, s.market_hash_name,
+		       (SELECT i.float FROM items i WHERE i.physical_item_id = p.id AND i.float IS NOT NULL LIMIT 1),
+		       p.first_seen_at, p.last_seen_at
+		FROM physical_items p
+		JOIN skins s ON s.id = p.skin_id
+		WHERE p.id = COALESCE(
+			NULLIF($1, '')::uuid,
+			(SELECT physical_item_id FROM items WHERE id = NULLIF($2, '')::uuid)
+		)
+	`, physicalItemID, itemID).Scan(&rec.ID, &rec.MarketHashName, &rec.Float, &rec.FirstSeenAt, &rec.LastSeenAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying physical item: %v", err)
+	}
+
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT i.id, i.market_item_id, m.name, i.price, COALESCE(i.price_usd, 0), i.created_at, i.updated_at
+		FROM items i
+		JOIN marketplaces m ON m.id = i.marketplace_id
+		WHERE i.physical_item_id = $1::uuid
+		ORDER BY i.created_at
+	`, rec.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying physical item listings: %v", err)
+	}
+	for rows.Next() {
+		var l PhysicalListing
+		if err := rows.Scan(&l.ItemID, &l.MarketItemID, &l.Marketplace, &l.Price, &l.PriceUSD, &l.FirstSeenAt, &l.LastSeenAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning physical item listing: %v", err)
+		}
+		rec.Listings = append(rec.Listings, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating physical item listings: %v", err)
+	}
+
+	// Observations recorded before the listing was linked count too
+	rows, err = db.pool.Query(context.Background(), `
+		SELECT i.market_item_id, ph.price, ph.price_usd, ph.observed_at
+		FROM price_history ph
+		JOIN items i ON i.id = ph.item_id
+		WHERE ph.physical_item_id = $1::uuid OR i.physical_item_id = $1::uuid
+		ORDER BY ph.observed_at
+	`, rec.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying physical item history: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p PricePoint
+		if err := rows.Scan(&p.MarketItemID, &p.Price, &p.PriceUSD, &p.ObservedAt); err != nil {
+			return nil, fmt.Errorf("error scanning physical item history: %v", err)
+		}
+		rec.History = append(rec.History, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating physical item history: %v", err)
+	}
+
+	return &rec, nil
+}
+
+// RelistedItem summarizes a physical item listed under several IDs
+type RelistedItem struct {
+	PhysicalItemID string    `json:"physical_item_id"`
+	MarketHashName string    `json:"market_hash_name"`
+	Listings       int       `json:"listings"`
+	FirstSeenAt    time.Time `json:"first_seen_at"`
+	LastSeenAt     time.Time `json:"last_seen_at"`
+	FirstPriceUSD  float64   `json:"first_price_usd"`
+	LatestPriceUSD float64   `json:"latest_price_usd"`
+}
+
+// GetRelistedItems returns physical items that appeared under more than one
+// listing, most recently seen first
+func (db *Database) GetRelistedItems(limit int) ([]RelistedItem, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT p.id, s.market_hash_name, COUNT(i.id), p.first_seen_at, p.last_seen_at,
+		       (ARRAY_AGG(COALESCE(i.price_usd, 0) ORDER BY i.created_at))[1],
+		       (ARRAY_AGG(COALESCE(i.price_usd, 0) ORDER BY i.updated_at DESC))[1]
+		FROM physical_items p
+		JOIN skins s ON s.id = p.skin_id
+		JOIN items i ON i.physical_item_id = p.id
+		GROUP BY p.id, s.market_hash_name
+		HAVING COUNT(i.id) > 1
+		ORDER BY p.last_seen_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying relisted items: %v", err)
+	}
+	defer rows.Close()
+
+	var items []RelistedItem
+	for rows.Next() {
+		var r RelistedItem
+		err := rows.Scan(&r.PhysicalItemID, &r.MarketHashName, &r.Listings, &r.FirstSeenAt, &r.LastSeenAt,
+			&r.FirstPriceUSD, &r.LatestPriceUSD)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning relisted item: %v", err)
+		}
+		items = append(items, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating relisted items: %v", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,312 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// portfolioColumns are the columns scanned by scanPortfolioItem
+const portfolioColumns = `
+	id, item_id::text, skin_id::text, market_hash_name, float, status,
+	buy_price_usd, buy_price_irr, buy_rate_irr, purchased_at, trade_hold_until,
+	list_price_usd, listed_at, sale_price_usd, sale_fee_usd, sale_rate_irr, sold_at,
+	notes, created_at, updated_at
+`
+
+// createPortfolioTables creates the portfolio table
+func (db *Database) createPortfolioTables() error {
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS portfolio_items (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			item_id UUID REFERENCES items(id) ON DELETE SET NULL,
+			skin_id UUID REFERENCES skins(id) ON DELETE SET NULL,
+			market_hash_name VARCHAR(255) NOT NULL,
+			float DECIMAL(18,16),
+			status VARCHAR(20) NOT NULL,
+			buy_price_usd DECIMAL(15,2) NOT NULL,
+			buy_price_irr DECIMAL(20,2) NOT NULL DEFAULT 0,
+			buy_rate_irr DECIMAL(15,2) NOT NULL DEFAULT 0,
+			purchased_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			trade_hold_until TIMESTAMPTZ,
+			list_price_usd DECIMAL(15,2),
+			listed_at TIMESTAMPTZ,
+			sale_price_usd DECIMAL(15,2),
+			sale_fee_usd DECIMAL(15,2),
+			sale_rate_irr DECIMAL(15,2),
+			sold_at TIMESTAMPTZ,
+			notes TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating portfolio_items table: %v", err)
+	}
+	return nil
+}
+
+// InsertPortfolioItem records a purchase and returns its ID
+func (db *Database) InsertPortfolioItem(p *models.PortfolioItem) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		INSERT INTO portfolio_items (
+			item_id, skin_id, market_hash_name, float, status,
+			buy_price_usd, buy_price_irr, buy_rate_irr, purchased_at, trade_hold_until, notes
+		) VALUES ($1::uuid, $2::uuid, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`,
+		p.ItemID, p.SkinID, p.MarketHashName, p.Float, p.Status,
+		p.BuyPriceUSD, p.BuyPriceIRR, p.BuyRateIRR, p.PurchasedAt, p.TradeHoldUntil, p.Notes,
+	).Scan(&id)
+
+	if err != nil {
+		return "", fmt.Errorf("error inserting portfolio item: %v", err)
+	}
+
+	return id, nil
+}
+
+// UpdatePortfolioItem saves the status, listing and sale fields of an item
+func (db *Database) UpdatePortfolioItem(p *models.PortfolioItem) error {
+	tag, err := db.pool.Exec(context.Background(), `
+		UPDATE portfolio_items SET
+			status = $2,
+			trade_hold_until = $3,
+			list_price_usd = $4,
+			listed_at = $5,
+			sale_price_usd = $6,
+			sale_fee_usd = $7,
+			sale_rate_irr = $8,
+			sold_at = $9,
+			notes = $10,
+			updated_at = NOW()
+		WHERE id = $1::uuid
+	`,
+		p.ID, p.Status, p.TradeHoldUntil, p.ListPriceUSD, p.ListedAt,
+		p.SalePriceUSD, p.SaleFeeUSD, p.SaleRateIRR, p.SoldAt, p.Notes,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating portfolio item: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("portfolio item %s %w", p.ID, ErrNotFound)
+	}
+	return nil
+}
+
+// DeletePortfolioItem removes an item recorded by mistake
+func (db *Database) DeletePortfolioItem(id string) error {
+	tag, err := db.pool.Exec(context.Background(), `DELETE FROM portfolio_items WHERE id = $1::uuid`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting portfolio item: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("portfolio item %s %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// GetPortfolioItem retrieves a portfolio item by ID, or nil when missing
+func (db *Database) GetPortfolioItem(id string) (*models.PortfolioItem, error) {
+	row := db.pool.QueryRow(context.Background(), `SELECT `+portfolioColumns+` FROM portfolio_items WHERE id = $1::uuid`, id)
+	p, err := scanPortfolioItem(row)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting portfolio item: %v", err)
+	}
+	return p, nil
+}
+
+// GetPortfolioItems returns portfolio items, newest first, optionally only
+// those with the given status
+func (db *Database) GetPortfolioItems(status string) ([]models.PortfolioItem, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT `+portfolioColumns+`
+		FROM portfolio_items
+		WHERE $1 = '' OR status = $1
+		ORDER BY purchased_at DESC
+	`, status)
+	if err != nil {
+		return nil, fmt.Errorf("error querying portfolio items: %v", err)
+	}
+	defer rows.Close()
+
+	var items []models.PortfolioItem
+	for rows.Next() {
+		p, err := scanPortfolioItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning portfolio item: %v", err)
+		}
+		items = append(items, *p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating portfolio items: %v", err)
+	}
+
+	return items, nil
+}
+
+// scanPortfolioItem scans a row selected with portfolioColumns
+func scanPortfolioItem(row pgx.Row) (*models.PortfolioItem, error) {
+	var p models.PortfolioItem
+	err := row.Scan(
+		&p.ID, &p.ItemID, &p.SkinID, &p.MarketHashName, &p.Float, &p.Status,
+		&p.BuyPriceUSD, &p.BuyPriceIRR, &p.BuyRateIRR, &p.PurchasedAt, &p.TradeHoldUntil,
+		&p.ListPriceUSD, &p.ListedAt, &p.SalePriceUSD, &p.SaleFeeUSD, &p.SaleRateIRR, &p.SoldAt,
+		&p.Notes, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListingForPurchase is the listing data needed to record a purchase
+type ListingForPurchase struct {
+	ItemID         string
+	SkinID         string
+	MarketHashName string
+	Float          *float64
+	PriceUSD       float64
+	PriceIRR       float64
+	TradeHoldUntil *time.Time
+}
+
+// GetListingForPurchase retrieves a listing by item ID, or nil when missing
+func (db *Database) GetListingForPurchase(itemID string) (*ListingForPurchase, error) {
+	var l ListingForPurchase
+	err := db.pool.QueryRow(context.Background(), `
+		SELECT i.id, i.skin_id, s.market_hash_name, i.float,
+		       COALESCE(i.price_usd, 0), i.price, i.trade_hold_until
+		FROM items i
+		JOIN skins s ON s.id = i.skin_id
+		WHERE i.id = $1::uuid
+	`, itemID).Scan(&l.ItemID, &l.SkinID, &l.MarketHashName, &l.Float, &l.PriceUSD, &l.PriceIRR, &l.TradeHoldUntil)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting listing: %v", err)
+	}
+	return &l, nil
+}
+
+// GetReferencePrices returns the latest Steam reference price in USD of each
+// market hash name: the Steam median when known, otherwise the Steam price of
+// the most recently scraped listing
+func (db *Database) GetReferencePrices(marketHashNames []string) (map[string]float64, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT s.market_hash_name,
+		       COALESCE(NULLIF(st.median_price_usd, 0), latest.steam_price_usd, 0)
+		FROM skins s
+		LEFT JOIN steam_price_stats st ON st.skin_id = s.id
+		LEFT JOIN LATERAL (
+			SELECT i.steam_price_usd FROM items i
+			WHERE i.skin_id = s.id AND i.steam_price_usd > 0 AND NOT i.quarantined
+			ORDER BY i.updated_at DESC
+			LIMIT 1
+		) latest ON true
+		WHERE s.market_hash_name = ANY($1)
+	`, marketHashNames)
+	if err != nil {
+		return nil, fmt.Errorf("error querying reference prices: %v", err)
+	}
+	defer rows.Close()
+
+	prices := make(map[string]float64)
+	for rows.Next() {
+		var name string
+		var price float64
+		if err := rows.Scan(&name, &price); err != nil {
+			return nil, fmt.Errorf("error scanning reference price: %v", err)
+		}
+		prices[name] = price
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reference prices: %v", err)
+	}
+
+	return prices, nil
+}
+
+// MarketPrice is the cheapest recent listing of an item on a marketplace
+type MarketPrice struct {
+	MarketHashName string
+	SkinID         string
+	Marketplace    string
+	SaleFeePercent float64
+	LowestPriceUSD float64
+	ListingCount   int
+}
+
+// GetMarketPrices returns, for each market hash name, the lowest price and
+// listing count on every marketplace among listings scraped in the last day
+func (db *Database) GetMarketPrices(marketHashNames []string) ([]MarketPrice, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT s.market_hash_name, s.id, m.name,
+		       COALESCE(MAX(f.sale_fee_percent), 0),
+		       MIN(i.price_usd), COUNT(*)
+		FROM items i
+		JOIN skins s ON s.id = i.skin_id
+		JOIN marketplaces m ON m.id = i.marketplace_id
+		LEFT JOIN marketplace_fees f ON f.marketplace_id = m.id
+		WHERE s.market_hash_name = ANY($1)
+		  AND i.price_usd > 0
+		  AND NOT i.quarantined
+		  AND i.updated_at > NOW() - INTERVAL '1 day'
+		GROUP BY s.market_hash_name, s.id, m.name
+	`, marketHashNames)
+	if err != nil {
+		return nil, fmt.Errorf("error querying market prices: %v", err)
+	}
+	defer rows.Close()
+
+	var prices []MarketPrice
+	for rows.Next() {
+		var p MarketPrice
+		if err := rows.Scan(&p.MarketHashName, &p.SkinID, &p.Marketplace, &p.SaleFeePercent, &p.LowestPriceUSD, &p.ListingCount); err != nil {
+			return nil, fmt.Errorf("error scanning market price: %v", err)
+		}
+		prices = append(prices, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating market prices: %v", err)
+	}
+
+	return prices, nil
+}
+
+// GetSkinIDs maps market hash names to skin IDs for the names that exist
+func (db *Database) GetSkinIDs(marketHashNames []string) (map[string]string, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT market_hash_name, id FROM skins WHERE market_hash_name = ANY($1)
+	`, marketHashNames)
+	if err != nil {
+		return nil, fmt.Errorf("error querying skin IDs: %v", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]string)
+	for rows.Next() {
+		var name, id string
+		if err := rows.Scan(&name, &id); err != nil {
+			return nil, fmt.Errorf("error scanning skin ID: %v", err)
+		}
+		ids[name] = id
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating skin IDs: %v", err)
+	}
+
+	return ids, nil
+}
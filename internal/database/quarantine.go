@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// createQuarantineTables creates the table of listings that failed validation
+func (db *Database) createQuarantineTables() error {
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS quarantined_items (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			marketplace_id UUID NOT NULL REFERENCES marketplaces(id),
+			market_item_id VARCHAR(255) NOT NULL,
+			market_hash_name VARCHAR(255) NOT NULL,
+			skin_id UUID REFERENCES skins(id),
+			price DECIMAL(15,2) NOT NULL DEFAULT 0,
+			price_usd DECIMAL(15,2) NOT NULL DEFAULT 0,
+			steam_price_usd DECIMAL(15,2) NOT NULL DEFAULT 0,
+			float DECIMAL(18,16),
+			rules TEXT[] NOT NULL DEFAULT '{}',
+			reasons TEXT[] NOT NULL DEFAULT '{}',
+			raw JSONB,
+			times_seen INTEGER NOT NULL DEFAULT 1,
+			first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE(marketplace_id, market_item_id)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating quarantined_items table: %v", err)
+	}
+
+	// Listings stored by an earlier scrape are flagged rather than deleted
+	// when they get quarantined, keeping their history
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE items ADD COLUMN IF NOT EXISTS quarantined BOOLEAN NOT NULL DEFAULT false
+	`)
+	if err != nil {
+		return fmt.Errorf("error adding quarantined column to items: %v", err)
+	}
+	return nil
+}
+
+// QuarantineItem records a listing that failed validation, refreshing the
+// entry when the listing was already quarantined. A stored item for the
+// listing is flagged as quarantined in the same transaction so it stops
+// being offered as tradable and priced; its ID is returned, or an empty
+// string when the listing was never stored. Its updated_at is left alone so
+// it doesn't look freshly scraped.
+func (db *Database) QuarantineItem(q *models.QuarantinedItem) (string, error) {
+	tx, err := db.pool.Begin(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), `
+		INSERT INTO quarantined_items (
+			marketplace_id, market_item_id, market_hash_name, skin_id, price, price_usd,
+			steam_price_usd, float, rules, reasons, raw
+		) VALUES ($1::uuid, $2, $3, $4::uuid, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (marketplace_id, market_item_id)
+		DO UPDATE SET
+			market_hash_name = EXCLUDED.market_hash_name,
+			skin_id = EXCLUDED.skin_id,
+			price = EXCLUDED.price,
+			price_usd = EXCLUDED.price_usd,
+			steam_price_usd = EXCLUDED.steam_price_usd,
+			float = EXCLUDED.float,
+			rules = EXCLUDED.rules,
+			reasons = EXCLUDED.reasons,
+			raw = EXCLUDED.raw,
+			times_seen = quarantined_items.times_seen + 1,
+			last_seen_at = NOW()
+	`,
+		q.MarketplaceID, q.MarketItemID, q.MarketHashName, q.SkinID, q.Price, q.PriceUSD,
+		q.SteamPriceUSD, q.Float, nonNilStrings(q.Rules), nonNilStrings(q.Reasons), q.Raw,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error quarantining item: %v", err)
+	}
+
+	var itemID string
+	err = tx.QueryRow(context.Background(), `
+		UPDATE items SET quarantined = true
+		WHERE marketplace_id = $1::uuid AND market_item_id = $2
+		RETURNING id
+	`, q.MarketplaceID, q.MarketItemID).Scan(&itemID)
+	if err != nil && err != pgx.ErrNoRows {
+		return "", fmt.Errorf("error flagging quarantined item: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return "", fmt.Errorf("error committing quarantined item: %v", err)
+	}
+	return itemID, nil
+}
+
+// ReleaseQuarantinedItem removes a listing from quarantine once it passes
+// validation
+func (db *Database) ReleaseQuarantinedItem(marketplaceID, marketItemID string) error {
+	_, err := db.pool.Exec(context.Background(), `
+		DELETE FROM quarantined_items WHERE marketplace_id = $1::uuid AND market_item_id = $2
+	`, marketplaceID, marketItemID)
+	if err != nil {
+		return fmt.Errorf("error releasing quarantined item: %v", err)
+	}
+	return nil
+}
+
+// GetQuarantinedItems returns the most recently seen quarantined listings,
+// optionally only those that failed a given rule
+func (db *Database) GetQuarantinedItems(rule string, limit int) ([]models.QuarantinedItem, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT id, marketplace_id, market_item_id, market_hash_name, skin_id, price, price_usd,
+		       steam_price_usd, float, rules, reasons, raw, times_seen, first_seen_at, last_seen_at
+		FROM quarantined_items
+		WHERE $1 = '' OR $1 = ANY(rules)
+		ORDER BY last_seen_at DESC
+		LIMIT $2
+	`, rule, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying quarantined items: %v", err)
+	}
+	defer rows.Close()
+
+	var items []models.QuarantinedItem
+	for rows.Next() {
+		var q models.QuarantinedItem
+		err := rows.Scan(
+			&q.ID, &q.MarketplaceID, &q.MarketItemID, &q.MarketHashName, &q.SkinID, &q.Price, &q.PriceUSD,
+			&q.SteamPriceUSD, &q.Float, &q.Rules, &q.Reasons, &q.Raw, &q.TimesSeen, &q.FirstSeenAt, &q.LastSeenAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning quarantined item: %v", err)
+		}
+		items = append(items, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating quarantined items: %v", err)
+	}
+
+	return items, nil
+}
+
+// GetQuarantineCounts returns how many quarantined listings failed each rule
+func (db *Database) GetQuarantineCounts() (map[string]int, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT rule, COUNT(*) FROM quarantined_items, UNNEST(rules) AS rule GROUP BY rule
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying quarantine counts: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var rule string
+		var count int
+		if err := rows.Scan(&rule, &count); err != nil {
+			return nil, fmt.Errorf("error scanning quarantine count: %v", err)
+		}
+		counts[rule] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating quarantine counts: %v", err)
+	}
+
+	return counts, nil
+}
+
+// GetSteamReferencePrice returns a skin's typical Steam price: the Steam
+// median when known, otherwise the median Steam price reported alongside
+// its listings over the last week. It returns 0 when there is no history.
+func (db *Database) GetSteamReferencePrice(skinID string) (float64, error) {
+	var price float64
+	err := db.pool.QueryRow(context.Background(), `
+		SELECT COALESCE(
+			(SELECT NULLIF(median_price_usd, 0)::float8 FROM steam_price_stats WHERE skin_id = $1::uuid),
+			(SELECT PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY steam_price_usd::float8)
+			 FROM items
+			 WHERE skin_id = $1::uuid AND steam_price_usd > 0 AND NOT quarantined
+			   AND updated_at > NOW() - INTERVAL '7 days'),
+			0
+		)
+	`, skinID).Scan(&price)
+	if err != nil {
+		return 0, fmt.Errorf("error querying Steam reference price: %v", err)
+	}
+	return price, nil
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// createSteamTables creates the Steam market statistics table
+func (db *Database) createSteamTables() error {
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS steam_price_stats (
+			skin_id UUID PRIMARY KEY REFERENCES skins(id) ON DELETE CASCADE,
+			lowest_price_usd DECIMAL(15,2) NOT NULL DEFAULT 0,
+			median_price_usd DECIMAL(15,2) NOT NULL DEFAULT 0,
+			daily_volume INTEGER,
+			listing_count INTEGER NOT NULL DEFAULT -1,
+			updated_at TIMESTAMP,
+			failed_at TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating steam_price_stats table: %v", err)
+	}
+
+	// The volume and update time are unknown (NULL) until a fetch succeeds.
+	// Older tables stored failed fetches as zeroed rows, which read as
+	// "never sells".
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE steam_price_stats
+			ALTER COLUMN daily_volume DROP NOT NULL,
+			ALTER COLUMN daily_volume DROP DEFAULT,
+			ALTER COLUMN updated_at DROP NOT NULL,
+			ALTER COLUMN updated_at DROP DEFAULT,
+			ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP
+	`)
+	if err != nil {
+		return fmt.Errorf("error migrating steam_price_stats table: %v", err)
+	}
+	_, err = db.pool.Exec(context.Background(), `
+		UPDATE steam_price_stats SET daily_volume = NULL
+		WHERE daily_volume = 0 AND listing_count = -1 AND lowest_price_usd = 0 AND median_price_usd = 0
+	`)
+	if err != nil {
+		return fmt.Errorf("error clearing failed steam_price_stats rows: %v", err)
+	}
+	return nil
+}
+
+// UpsertSteamPriceStats stores the latest Steam market figures of a skin
+func (db *Database) UpsertSteamPriceStats(stats *models.SteamPriceStats) error {
+	_, err := db.pool.Exec(context.Background(), `
+		INSERT INTO steam_price_stats (
+			skin_id, lowest_price_usd, median_price_usd, daily_volume, listing_count, updated_at
+		) VALUES ($1::uuid, $2, $3, $4, $5, NOW())
+		ON CONFLICT (skin_id) DO UPDATE SET
+			lowest_price_usd = EXCLUDED.lowest_price_usd,
+			median_price_usd = EXCLUDED.median_price_usd,
+			daily_volume = EXCLUDED.daily_volume,
+			listing_count = EXCLUDED.listing_count,
+			updated_at = NOW(),
+			failed_at = NULL
+	`, stats.SkinID, stats.LowestPriceUSD, stats.MedianPriceUSD, stats.DailyVolume, stats.ListingCount)
+	if err != nil {
+		return fmt.Errorf("error upserting steam price stats: %v", err)
+	}
+	return nil
+}
+
+// MarkSteamStatsFailed records a failed fetch of a skin's Steam figures so it
+// isn't retried until it goes stale, keeping any figures fetched before and
+// when they were fetched. A skin never fetched gets a row with an unknown
+// volume and update time.
+func (db *Database) MarkSteamStatsFailed(skinID string) error {
+	_, err := db.pool.Exec(context.Background(), `
+		INSERT INTO steam_price_stats (skin_id, failed_at)
+		VALUES ($1::uuid, NOW())
+		ON CONFLICT (skin_id) DO UPDATE SET failed_at = NOW()
+	`, skinID)
+	if err != nil {
+		return fmt.Errorf("error recording failed steam stats fetch: %v", err)
+	}
+	return nil
+}
+
+// GetSkinsNeedingSteamStats returns skins with recently scraped listings whose
+// Steam figures were neither fetched nor attempted within maxAge, stalest
+// first
+func (db *Database) GetSkinsNeedingSteamStats(maxAge time.Duration, limit int) ([]models.SteamPriceStats, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT s.id, s.market_hash_name
+		FROM skins s
+		LEFT JOIN steam_price_stats st ON st.skin_id = s.id
+		WHERE EXISTS (
+			SELECT 1 FROM items i
+			WHERE i.skin_id = s.id AND i.updated_at > NOW() - INTERVAL '1 day'
+		)
+		AND (GREATEST(st.updated_at, st.failed_at) IS NULL OR GREATEST(st.updated_at, st.failed_at) < $1)
+		ORDER BY GREATEST(st.updated_at, st.failed_at) NULLS FIRST
+		LIMIT $2
+	`, time.Now().Add(-maxAge), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying skins needing steam stats: %v", err)
+	}
+	defer rows.Close()
+
+	var skins []models.SteamPriceStats
+	for rows.Next() {
+		var s models.SteamPriceStats
+		if err := rows.Scan(&s.SkinID, &s.MarketHashName); err != nil {
+			return nil, fmt.Errorf("error scanning skin: %v", err)
+		}
+		skins = append(skins, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating skins: %v", err)
+	}
+
+	return skins, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Telegram notification statuses
+const (
+	TelegramNotificationPending = "pending"
+	TelegramNotificationSent    = "sent"
+	TelegramNotificationFailed  = "failed"
+)
+
+// createTelegramTables creates the Telegram mute and notification tables
+func (db *Database) createTelegramTables() error {
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS telegram_mutes (
+			chat_id BIGINT NOT NULL,
+			category VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (chat_id, category)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating telegram_mutes table: %v", err)
+	}
+
+	// One message per chat and listing, so a listing is never announced
+	// twice, unless sending it failed
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS telegram_notifications (
+			chat_id BIGINT NOT NULL,
+			item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (chat_id, item_id)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating telegram_notifications table: %v", err)
+	}
+
+	// Older tables have no status; their messages count as claimed
+	_, err = db.pool.Exec(context.Background(), `
+		ALTER TABLE telegram_notifications
+			ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending'
+	`)
+	if err != nil {
+		return fmt.Errorf("error migrating telegram_notifications table: %v", err)
+	}
+
+	return nil
+}
+
+// GetTelegramMutes returns the muted categories of every chat, lowercased
+func (db *Database) GetTelegramMutes() (map[int64][]string, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT chat_id, category FROM telegram_mutes ORDER BY chat_id, category
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying telegram mutes: %v", err)
+	}
+	defer rows.Close()
+
+	mutes := make(map[int64][]string)
+	for rows.Next() {
+		var chatID int64
+		var category string
+		if err := rows.Scan(&chatID, &category); err != nil {
+			return nil, fmt.Errorf("error scanning telegram mute: %v", err)
+		}
+		mutes[chatID] = append(mutes[chatID], category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating telegram mutes: %v", err)
+	}
+
+	return mutes, nil
+}
+
+// MuteTelegramCategory stops notifications of a category in a chat
+func (db *Database) MuteTelegramCategory(chatID int64, category string) error {
+	_, err := db.pool.Exec(context.Background(), `
+		INSERT INTO telegram_mutes (chat_id, category)
+		VALUES ($1, $2)
+		ON CONFLICT (chat_id, category) DO NOTHING
+	`, chatID, strings.ToLower(category))
+	if err != nil {
+		return fmt.Errorf("error muting telegram category: %v", err)
+	}
+	return nil
+}
+
+// UnmuteTelegramCategory resumes notifications of a category in a chat
+func (db *Database) UnmuteTelegramCategory(chatID int64, category string) error {
+	_, err := db.pool.Exec(context.Background(), `
+		DELETE FROM telegram_mutes WHERE chat_id = $1 AND category = $2
+	`, chatID, strings.ToLower(category))
+	if err != nil {
+		return fmt.Errorf("error unmuting telegram category: %v", err)
+	}
+	return nil
+}
+
+// ClaimTelegramNotification records that a listing is being announced in a
+// chat. It returns false when the chat was already notified of the listing
+// or the message is still being sent; a failed message can be claimed again.
+func (db *Database) ClaimTelegramNotification(chatID int64, itemID string) (bool, error) {
+	tag, err := db.pool.Exec(context.Background(), `
+		INSERT INTO telegram_notifications (chat_id, item_id)
+		VALUES ($1, $2::uuid)
+		ON CONFLICT (chat_id, item_id) DO UPDATE SET status = $3
+		WHERE telegram_notifications.status = $4
+	`, chatID, itemID, TelegramNotificationPending, TelegramNotificationFailed)
+	if err != nil {
+		return false, fmt.Errorf("error claiming telegram notification: %v", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UpdateTelegramNotification records whether a listing's message was sent
+func (db *Database) UpdateTelegramNotification(chatID int64, itemID, status string) error {
+	_, err := db.pool.Exec(context.Background(), `
+		UPDATE telegram_notifications SET status = $3
+		WHERE chat_id = $1 AND item_id = $2::uuid
+	`, chatID, itemID, status)
+	if err != nil {
+		return fmt.Errorf("error updating telegram notification: %v", err)
+	}
+	return nil
+}
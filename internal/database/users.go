@@ -0,0 +1,371 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// createUserTables creates user accounts with their presets and watchlists,
+// and links alert rules, API keys and sessions to users
+func (db *Database) createUserTables() error {
+	_, err := db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS users (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			username VARCHAR(255) NOT NULL UNIQUE,
+			password_hash TEXT NOT NULL,
+			scopes TEXT[] NOT NULL DEFAULT '{read,write}',
+			display_currency VARCHAR(10) NOT NULL DEFAULT 'USD',
+			default_min_profit_percent DECIMAL(7,2),
+			max_buy_price_usd DECIMAL(15,2),
+			steam_sale_fee_percent DECIMAL(5,2),
+			marketplace_fee_percent JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating users table: %v", err)
+	}
+
+	// Users created before the write scope managed their own settings with
+	// read, so they keep doing so with write. This runs once, while the
+	// column still has the old default, so it can't undo a later choice.
+	var scopesDefault string
+	err = db.pool.QueryRow(context.Background(), `
+		SELECT COALESCE(column_default, '') FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'scopes'
+	`).Scan(&scopesDefault)
+	if err != nil {
+		return fmt.Errorf("error reading users scopes default: %v", err)
+	}
+	if !strings.Contains(scopesDefault, "write") {
+		_, err = db.pool.Exec(context.Background(), `
+			UPDATE users SET scopes = array_append(scopes, 'write')
+			WHERE 'read' = ANY(scopes) AND NOT 'write' = ANY(scopes)
+		`)
+		if err != nil {
+			return fmt.Errorf("error granting users the write scope: %v", err)
+		}
+		_, err = db.pool.Exec(context.Background(), `
+			ALTER TABLE users ALTER COLUMN scopes SET DEFAULT '{read,write}'
+		`)
+		if err != nil {
+			return fmt.Errorf("error changing users scopes default: %v", err)
+		}
+	}
+
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS filter_presets (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			filters JSONB NOT NULL DEFAULT '{}',
+			is_default BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE(user_id, name)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating filter_presets table: %v", err)
+	}
+
+	_, err = db.pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS watchlist_items (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			market_hash_name VARCHAR(255) NOT NULL,
+			max_price_usd DECIMAL(15,2),
+			note TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE(user_id, market_hash_name)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating watchlist_items table: %v", err)
+	}
+
+	for _, table := range []string{"alert_rules", "api_keys", "sessions"} {
+		_, err = db.pool.Exec(context.Background(), `
+			ALTER TABLE `+table+` ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users(id) ON DELETE CASCADE
+		`)
+		if err != nil {
+			return fmt.Errorf("error adding user_id to %s table: %v", table, err)
+		}
+	}
+
+	return nil
+}
+
+// InsertUser creates a user and returns its ID
+func (db *Database) InsertUser(user *models.User) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		INSERT INTO users (username, password_hash, scopes)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, user.Username, user.PasswordHash, nonNilStrings(user.Scopes)).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("error inserting user: %v", err)
+	}
+	return id, nil
+}
+
+// userColumns are the columns scanned by scanUser
+const userColumns = `
+	id, username, password_hash, scopes, display_currency, default_min_profit_percent,
+	max_buy_price_usd, steam_sale_fee_percent, marketplace_fee_percent, created_at, updated_at
+`
+
+// scanUser scans a row selected with userColumns
+func scanUser(row pgx.Row) (*models.User, error) {
+	var u models.User
+	err := row.Scan(
+		&u.ID, &u.Username, &u.PasswordHash, &u.Scopes, &u.Settings.DisplayCurrency, &u.Settings.DefaultMinProfitPercent,
+		&u.Settings.MaxBuyPriceUSD, &u.Settings.SteamSaleFeePercent, &u.Settings.MarketplaceFeePercent, &u.CreatedAt, &u.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetUser returns a user by ID, or nil when not found
+func (db *Database) GetUser(id string) (*models.User, error) {
+	u, err := scanUser(db.pool.QueryRow(context.Background(), `
+		SELECT `+userColumns+` FROM users WHERE id = $1::uuid
+	`, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying user: %v", err)
+	}
+	return u, nil
+}
+
+// GetUserByUsername returns a user by username, or nil when not found
+func (db *Database) GetUserByUsername(username string) (*models.User, error) {
+	u, err := scanUser(db.pool.QueryRow(context.Background(), `
+		SELECT `+userColumns+` FROM users WHERE username = $1
+	`, username))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying user: %v", err)
+	}
+	return u, nil
+}
+
+// GetUsers returns every user ordered by username
+func (db *Database) GetUsers() ([]models.User, error) {
+	rows, err := db.pool.Query(context.Background(), `SELECT `+userColumns+` FROM users ORDER BY username`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying users: %v", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user: %v", err)
+		}
+		users = append(users, *u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %v", err)
+	}
+
+	return users, nil
+}
+
+// DeleteUser deletes a user along with their presets, watchlist, alert
+// rules, API keys and sessions
+func (db *Database) DeleteUser(id string) error {
+	tag, err := db.pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1::uuid`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting user: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user %s %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// UpdateUserSettings replaces a user's settings
+func (db *Database) UpdateUserSettings(id string, settings *models.UserSettings) error {
+	feePercent := settings.MarketplaceFeePercent
+	if feePercent == nil {
+		feePercent = map[string]float64{}
+	}
+
+	_, err := db.pool.Exec(context.Background(), `
+		UPDATE users SET
+			display_currency = $2,
+			default_min_profit_percent = $3,
+			max_buy_price_usd = $4,
+			steam_sale_fee_percent = $5,
+			marketplace_fee_percent = $6,
+			updated_at = NOW()
+		WHERE id = $1::uuid
+	`, id, settings.DisplayCurrency, settings.DefaultMinProfitPercent, settings.MaxBuyPriceUSD,
+		settings.SteamSaleFeePercent, feePercent)
+	if err != nil {
+		return fmt.Errorf("error updating user settings: %v", err)
+	}
+	return nil
+}
+
+// UpsertFilterPreset saves a preset under its name, replacing any preset of
+// the same name. Making a preset the default clears the previous default.
+func (db *Database) UpsertFilterPreset(preset *models.FilterPreset) (string, error) {
+	tx, err := db.pool.Begin(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if preset.IsDefault {
+		_, err = tx.Exec(context.Background(), `
+			UPDATE filter_presets SET is_default = false WHERE user_id = $1::uuid AND name <> $2
+		`, preset.UserID, preset.Name)
+		if err != nil {
+			return "", fmt.Errorf("error clearing default preset: %v", err)
+		}
+	}
+
+	filters := preset.Filters
+	if filters == nil {
+		filters = map[string]string{}
+	}
+
+	var id string
+	err = tx.QueryRow(context.Background(), `
+		INSERT INTO filter_presets (user_id, name, filters, is_default)
+		VALUES ($1::uuid, $2, $3, $4)
+		ON CONFLICT (user_id, name) DO UPDATE SET
+			filters = EXCLUDED.filters,
+			is_default = EXCLUDED.is_default,
+			updated_at = NOW()
+		RETURNING id
+	`, preset.UserID, preset.Name, filters, preset.IsDefault).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("error saving filter preset: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return "", fmt.Errorf("error committing filter preset: %v", err)
+	}
+	return id, nil
+}
+
+// GetFilterPresets returns a user's presets ordered by name
+func (db *Database) GetFilterPresets(userID string) ([]models.FilterPreset, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT id, user_id, name, filters, is_default, created_at, updated_at
+		FROM filter_presets
+		WHERE user_id = $1::uuid
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying filter presets: %v", err)
+	}
+	defer rows.Close()
+
+	var presets []models.FilterPreset
+	for rows.Next() {
+		var p models.FilterPreset
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.Filters, &p.IsDefault, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning filter preset: %v", err)
+		}
+		presets = append(presets, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating filter presets: %v", err)
+	}
+
+	return presets, nil
+}
+
+// DeleteFilterPreset deletes one of a user's presets
+func (db *Database) DeleteFilterPreset(userID, id string) error {
+	tag, err := db.pool.Exec(context.Background(), `
+		DELETE FROM filter_presets WHERE id = $1::uuid AND user_id = $2::uuid
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting filter preset: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("filter preset %s %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// UpsertWatchlistItem adds a skin to a user's watchlist, updating the target
+// price and note when it is already watched
+func (db *Database) UpsertWatchlistItem(item *models.WatchlistItem) (string, error) {
+	var id string
+	err := db.pool.QueryRow(context.Background(), `
+		INSERT INTO watchlist_items (user_id, market_hash_name, max_price_usd, note)
+		VALUES ($1::uuid, $2, $3, $4)
+		ON CONFLICT (user_id, market_hash_name) DO UPDATE SET
+			max_price_usd = EXCLUDED.max_price_usd,
+			note = EXCLUDED.note
+		RETURNING id
+	`, item.UserID, item.MarketHashName, item.MaxPriceUSD, item.Note).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("error saving watchlist item: %v", err)
+	}
+	return id, nil
+}
+
+// GetWatchlist returns a user's watched skins ordered by name
+func (db *Database) GetWatchlist(userID string) ([]models.WatchlistItem, error) {
+	rows, err := db.pool.Query(context.Background(), `
+		SELECT id, user_id, market_hash_name, max_price_usd, note, created_at
+		FROM watchlist_items
+		WHERE user_id = $1::uuid
+		ORDER BY market_hash_name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying watchlist: %v", err)
+	}
+	defer rows.Close()
+
+	var items []models.WatchlistItem
+	for rows.Next() {
+		var w models.WatchlistItem
+		if err := rows.Scan(&w.ID, &w.UserID, &w.MarketHashName, &w.MaxPriceUSD, &w.Note, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning watchlist item: %v", err)
+		}
+		items = append(items, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watchlist: %v", err)
+	}
+
+	return items, nil
+}
+
+// DeleteWatchlistItem removes a skin from a user's watchlist
+func (db *Database) DeleteWatchlistItem(userID, id string) error {
+	tag, err := db.pool.Exec(context.Background(), `
+		DELETE FROM watchlist_items WHERE id = $1::uuid AND user_id = $2::uuid
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting watchlist item: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("watchlist item %s %w", id, ErrNotFound)
+	}
+	return nil
+}
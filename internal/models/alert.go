@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertRule describes which new opportunities should trigger a webhook
+type AlertRule struct {
+	ID               string    `json:"id" db:"id"`
+	UserID           *string   `json:"user_id" db:"user_id"` // Owner, nil for rules shared by everyone
+	Name             string    `json:"name" db:"name"`
+	WebhookURL       string    `json:"webhook_url" db:"webhook_url"`
+	Secret           string    `json:"secret,omitempty" db:"secret"` // Used to sign payloads
+	MinNetProfitUSD  float64   `json:"min_net_profit_usd" db:"min_net_profit_usd"`
+	MinProfitPercent float64   `json:"min_profit_percent" db:"min_profit_percent"`
+	MaxBuyPriceUSD   float64   `json:"max_buy_price_usd" db:"max_buy_price_usd"` // 0 means no limit
+	Categories       []string  `json:"categories" db:"categories"`               // Empty means any category
+	MinFloat         *float64  `json:"min_float" db:"min_float"`
+	MaxFloat         *float64  `json:"max_float" db:"max_float"`
+	Skins            []string  `json:"skins" db:"skins"` // Market hash names or base names such as "AK-47 | Redline"
+	Enabled          bool      `json:"enabled" db:"enabled"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AlertDelivery records a webhook sent (or being sent) for a rule and listing
+type AlertDelivery struct {
+	ID          string     `json:"id" db:"id"`
+	RuleID      string     `json:"rule_id" db:"rule_id"`
+	ItemID      string     `json:"item_id" db:"item_id"`
+	Status      string     `json:"status" db:"status"` // pending, delivered, failed
+	Attempts    int        `json:"attempts" db:"attempts"`
+	LastError   string     `json:"last_error" db:"last_error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at" db:"delivered_at"`
+}
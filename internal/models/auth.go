@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKey is a key granting access to the HTTP API. Only a hash of the key
+// is stored; the key itself is shown once when created.
+type APIKey struct {
+	ID         string     `json:"id" db:"id"`
+	UserID     *string    `json:"user_id" db:"user_id"` // Owner, nil for keys not tied to a user
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"` // First characters of the key, to tell keys apart
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// Session is a web UI login, created by signing in with an API key or a
+// username and password
+type Session struct {
+	ID        string    `json:"id" db:"id"`
+	APIKeyID  *string   `json:"api_key_id" db:"api_key_id"` // nil when signed in with a password or the bootstrap admin key
+	UserID    *string   `json:"user_id" db:"user_id"`
+	Scopes    []string  `json:"scopes" db:"scopes"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
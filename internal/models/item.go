@@ -1,25 +1,74 @@
 package models
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
 )
 
 // Item represents a specific instance of a skin in a marketplace
 type Item struct {
-	ID            string    `json:"id" db:"id"`
-	SkinID        string    `json:"skin_id" db:"skin_id"`
-	MarketplaceID string    `json:"marketplace_id" db:"marketplace_id"`
-	Float         float64   `json:"float" db:"float"`
-	Stickers      []string  `json:"stickers" db:"stickers"`
-	Price         float64   `json:"price" db:"price"`                     // Price in marketplace currency
-	PriceFailed   float64   `json:"price_failed" db:"price_failed"`       // Original price before discount
-	PriceUSD      float64   `json:"price_usd" db:"price_usd"`             // Converted price in USD
-	SteamPriceUSD float64   `json:"steam_price_usd" db:"steam_price_usd"` // Steam market price in USD
-	Tradeable     string    `json:"tradeable" db:"tradeable"`
-	IsFastSell    bool      `json:"is_fast_sell" db:"is_fast_sell"`
-	MarketItemID  string    `json:"market_item_id" db:"market_item_id"` // Original ID in the marketplace
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	ID             string     `json:"id" db:"id"`
+	SkinID         string     `json:"skin_id" db:"skin_id"`
+	MarketplaceID  string     `json:"marketplace_id" db:"marketplace_id"`
+	Float          *float64   `json:"float" db:"float"` // nil for items without wear
+	Stickers       []string   `json:"stickers" db:"stickers"`
+	Price          float64    `json:"price" db:"price"`                     // Price in marketplace currency
+	PriceFailed    float64    `json:"price_failed" db:"price_failed"`       // Original price before discount
+	PriceUSD       float64    `json:"price_usd" db:"price_usd"`             // Converted price in USD
+	SteamPriceUSD  float64    `json:"steam_price_usd" db:"steam_price_usd"` // Steam market price in USD
+	Tradeable      string     `json:"tradeable" db:"tradeable"`
+	TradeHoldUntil *time.Time `json:"trade_hold_until" db:"trade_hold_until"` // nil when tradeable now
+	IsFastSell     bool       `json:"is_fast_sell" db:"is_fast_sell"`
+	MarketItemID   string     `json:"market_item_id" db:"market_item_id"`     // Original ID in the marketplace
+	PaintIndex     int        `json:"paint_index" db:"paint_index"`           // Finish paint index, 0 if unknown
+	PaintSeed      int        `json:"paint_seed" db:"paint_seed"`             // Pattern seed, 0 if unknown
+	Phase          string     `json:"phase" db:"phase"`                       // Doppler phase or gem, e.g. Ruby, Phase 2
+	FadePercent    float64    `json:"fade_percent" db:"fade_percent"`         // Fade percentage, 0 if unknown
+	LabelMismatch  bool       `json:"label_mismatch" db:"label_mismatch"`     // Marketplace flags disagree with the name
+	AssetID        string     `json:"asset_id" db:"asset_id"`                 // Steam asset ID, empty when the marketplace hides it
+	PhysicalItemID string     `json:"physical_item_id" db:"physical_item_id"` // Links relistings of the same item
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Fingerprint identifies the physical item behind a listing, so that a
+// removed and relisted item links to its earlier listings. The Steam asset ID
+// is used when the marketplace reports it; otherwise the skin, exact float,
+// pattern seed and stickers. Floats are unique in practice, but a marketplace
+// that rounds them, or leaves out the seed, can make two copies of a skin
+// share a fingerprint and be taken for relistings of one item. Items without
+// a float (cases, stickers...) are interchangeable and have no fingerprint,
+// and neither have skins whose float is missing, which marketplaces report
+// as 0 since no skin reaches exactly 0.
+func (i *Item) Fingerprint() string {
+	var parts []string
+	switch {
+	case i.AssetID != "":
+		parts = []string{"asset", i.AssetID}
+	case i.Float != nil && *i.Float > 0 && i.SkinID != "":
+		stickers := slices.Clone(i.Stickers)
+		slices.Sort(stickers)
+		parts = []string{
+			"skin", i.SkinID,
+			strconv.FormatFloat(*i.Float, 'g', -1, 64),
+			strconv.Itoa(i.PaintSeed),
+			strings.Join(stickers, "\x1f"),
+		}
+	default:
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
 }
 
 // CSGOSkinItem represents the structure returned by csgoskin.ir API
@@ -39,4 +88,69 @@ type CSGOSkinItem struct {
 	PriceSteam     string   `json:"price_steam"`
 	IconMedium     string   `json:"icon_medium"`
 	Stickers       []string `json:"stickers"`
+
+	// Pattern details, only present for some listings
+	PaintIndex LenientInt `json:"paintindex"`
+	PaintSeed  LenientInt `json:"paintseed"`
+
+	// Steam asset ID, only present for some listings
+	AssetID LenientID `json:"assetid"`
+}
+
+// CSGOSkinListingURL returns the page of a csgoskin.ir listing. The URL
+// format can be overridden with CSGOSKIN_LISTING_URL, where %s is the item ID.
+func CSGOSkinListingURL(marketItemID string) string {
+	return fmt.Sprintf(config.String("CSGOSKIN_LISTING_URL", "https://csgoskin.ir/item/%s"), marketItemID)
+}
+
+// LenientInt is an integer decoded from a JSON number or a string holding
+// one. Empty strings, null and anything unparsable decode as 0, so a bad
+// optional field doesn't fail the whole page it arrives in.
+type LenientInt int
+
+// UnmarshalJSON implements json.Unmarshaler
+func (n *LenientInt) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*n = 0
+	switch v := raw.(type) {
+	case float64:
+		*n = LenientInt(v)
+	case string:
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			*n = LenientInt(parsed)
+		}
+	}
+	return nil
+}
+
+// LenientID is a numeric ID decoded from a JSON number or a string holding
+// one, kept as a string so large IDs don't lose precision. Zero, null and
+// anything that isn't a positive integer decode as an empty string.
+type LenientID string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (id *LenientID) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	*id = ""
+	var text string
+	switch v := raw.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = strings.TrimSpace(v)
+	}
+	if parsed, err := strconv.ParseUint(text, 10, 64); err == nil && parsed > 0 {
+		*id = LenientID(strconv.FormatUint(parsed, 10))
+	}
+	return nil
 }
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLenientInt(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want LenientInt
+	}{
+		{`418`, 418},
+		{`"418"`, 418},
+		{`" 661 "`, 661},
+		{`"418.0"`, 418},
+		{`""`, 0},
+		{`null`, 0},
+		{`"unknown"`, 0},
+		{`false`, 0},
+	}
+
+	for _, tt := range tests {
+		var item CSGOSkinItem
+		if err := json.Unmarshal([]byte(`{"itemid": "1", "paintindex": `+tt.raw+`}`), &item); err != nil {
+			t.Errorf("paintindex %s: %v", tt.raw, err)
+			continue
+		}
+		if item.PaintIndex != tt.want {
+			t.Errorf("paintindex %s = %d, want %d", tt.raw, item.PaintIndex, tt.want)
+		}
+	}
+
+	// A missing field leaves it unset
+	var item CSGOSkinItem
+	if err := json.Unmarshal([]byte(`{"itemid": "1"}`), &item); err != nil || item.PaintSeed != 0 {
+		t.Errorf("missing paintseed = %d, %v, want 0", item.PaintSeed, err)
+	}
+}
+
+func TestCSGOSkinListingURL(t *testing.T) {
+	if got := CSGOSkinListingURL("123"); got != "https://csgoskin.ir/item/123" {
+		t.Errorf("CSGOSkinListingURL = %q", got)
+	}
+
+	t.Setenv("CSGOSKIN_LISTING_URL", "https://mirror.example.com/items/%s?ref=arb")
+	if got := CSGOSkinListingURL("123"); got != "https://mirror.example.com/items/123?ref=arb" {
+		t.Errorf("CSGOSkinListingURL with an override = %q", got)
+	}
+}
+
+func TestLenientID(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want LenientID
+	}{
+		{`38350177019`, "38350177019"},
+		{`"38350177019"`, "38350177019"},
+		{`" 42 "`, "42"},
+		{`18446744073709551615`, "18446744073709551615"},
+		{`0`, ""},
+		{`"-5"`, ""},
+		{`"12.5"`, ""},
+		{`""`, ""},
+		{`null`, ""},
+		{`true`, ""},
+	}
+
+	for _, tt := range tests {
+		var item CSGOSkinItem
+		if err := json.Unmarshal([]byte(`{"itemid": "1", "assetid": `+tt.raw+`}`), &item); err != nil {
+			t.Errorf("assetid %s: %v", tt.raw, err)
+			continue
+		}
+		if item.AssetID != tt.want {
+			t.Errorf("assetid %s = %q, want %q", tt.raw, item.AssetID, tt.want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	wear := 0.123456789
+	otherWear := 0.123456788
+	base := Item{SkinID: "skin", Float: &wear, PaintSeed: 661, Stickers: []string{"Crown (Foil)", "Titan | Katowice 2014"}}
+	fingerprint := base.Fingerprint()
+	if fingerprint == "" {
+		t.Fatal("item with a float has no fingerprint")
+	}
+
+	with := func(modify func(*Item)) string {
+		item := base
+		modify(&item)
+		return item.Fingerprint()
+	}
+
+	same := map[string]string{
+		"relisted elsewhere": with(func(i *Item) { i.MarketplaceID, i.MarketItemID, i.Price = "other", "2", 10 }),
+		"reordered stickers": with(func(i *Item) { i.Stickers = []string{"Titan | Katowice 2014", "Crown (Foil)"} }),
+	}
+	for name, got := range same {
+		if got != fingerprint {
+			t.Errorf("%s: fingerprint changed", name)
+		}
+	}
+
+	different := map[string]string{
+		"other skin":          with(func(i *Item) { i.SkinID = "other" }),
+		"other float":         with(func(i *Item) { i.Float = &otherWear }),
+		"other seed":          with(func(i *Item) { i.PaintSeed = 662 }),
+		"sticker scraped off": with(func(i *Item) { i.Stickers = i.Stickers[:1] }),
+		"asset ID":            with(func(i *Item) { i.AssetID = "38350177019" }),
+	}
+	for name, got := range different {
+		if got == fingerprint || got == "" {
+			t.Errorf("%s: fingerprint %q", name, got)
+		}
+	}
+
+	// The asset ID identifies the item on its own
+	first := with(func(i *Item) { i.AssetID = "38350177019" })
+	if with(func(i *Item) { i.AssetID, i.Float, i.SkinID = "38350177019", nil, "" }) != first {
+		t.Error("asset ID fingerprint depends on other fields")
+	}
+
+	zero := 0.0
+	for name, item := range map[string]Item{"no float": {SkinID: "skin"}, "zero float": {SkinID: "skin", Float: &zero}, "no skin": {Float: &wear}} {
+		if got := item.Fingerprint(); got != "" {
+			t.Errorf("%s: fingerprint %q, want none", name, got)
+		}
+	}
+}
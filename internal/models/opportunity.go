@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Reasons an opportunity closed
+const (
+	CloseReasonSold              = "sold"                // The listing disappeared from the marketplace
+	CloseReasonPriceRaised       = "price_raised"        // The seller raised the price
+	CloseReasonSteamPriceDropped = "steam_price_dropped" // The reference sell price fell
+	CloseReasonBelowThreshold    = "below_threshold"     // Profit fell below the threshold for another reason
+	CloseReasonInvalidated       = "invalidated"         // The listing was quarantined or could not be processed
+)
+
+// OpportunityRecord is the stored history of one arbitrage opportunity, from
+// the scrape it first appeared in until it closed
+type OpportunityRecord struct {
+	ID                        string     `json:"id" db:"id"`
+	ItemID                    string     `json:"item_id" db:"item_id"`
+	MarketplaceID             string     `json:"marketplace_id" db:"marketplace_id"`
+	MarketHashName            string     `json:"market_hash_name" db:"market_hash_name"`
+	Category                  string     `json:"category" db:"category"`
+	OpenBuyPriceUSD           float64    `json:"open_buy_price_usd" db:"open_buy_price_usd"`
+	OpenSellPriceUSD          float64    `json:"open_sell_price_usd" db:"open_sell_price_usd"`
+	BuyPriceUSD               float64    `json:"buy_price_usd" db:"buy_price_usd"` // Latest prices
+	SellPriceUSD              float64    `json:"sell_price_usd" db:"sell_price_usd"`
+	ProfitUSD                 float64    `json:"profit_usd" db:"profit_usd"`
+	ProfitPercent             float64    `json:"profit_percent" db:"profit_percent"`
+	ProfitWithStickersPercent float64    `json:"profit_with_stickers_percent" db:"profit_with_stickers_percent"`
+	PeakProfitUSD             float64    `json:"peak_profit_usd" db:"peak_profit_usd"`
+	PeakProfitPercent         float64    `json:"peak_profit_percent" db:"peak_profit_percent"`
+	FirstSeen                 time.Time  `json:"first_seen" db:"first_seen"`
+	LastSeen                  time.Time  `json:"last_seen" db:"last_seen"`
+	ClosedAt                  *time.Time `json:"closed_at" db:"closed_at"`
+	CloseReason               string     `json:"close_reason" db:"close_reason"`
+	DurationSeconds           float64    `json:"duration_seconds" db:"-"` // Until closing, or until now while open
+}
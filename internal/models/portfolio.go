@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// Portfolio item statuses, in the order an item moves through them
+const (
+	PortfolioTradeHold = "trade_hold" // Bought, not yet tradeable
+	PortfolioHeld      = "held"       // Tradeable, not listed
+	PortfolioListed    = "listed"     // Listed for sale
+	PortfolioSold      = "sold"
+)
+
+// PortfolioItem is an item we bought, from purchase until it is sold
+type PortfolioItem struct {
+	ID             string     `json:"id" db:"id"`
+	ItemID         *string    `json:"item_id" db:"item_id"` // Listing it was bought from, nil for manual entries
+	SkinID         *string    `json:"skin_id" db:"skin_id"`
+	MarketHashName string     `json:"market_hash_name" db:"market_hash_name"`
+	Float          *float64   `json:"float" db:"float"`
+	Status         string     `json:"status" db:"status"`
+	BuyPriceUSD    float64    `json:"buy_price_usd" db:"buy_price_usd"`
+	BuyPriceIRR    float64    `json:"buy_price_irr" db:"buy_price_irr"`
+	BuyRateIRR     float64    `json:"buy_rate_irr" db:"buy_rate_irr"` // Rial per USDT when bought
+	PurchasedAt    time.Time  `json:"purchased_at" db:"purchased_at"`
+	TradeHoldUntil *time.Time `json:"trade_hold_until" db:"trade_hold_until"`
+	ListPriceUSD   *float64   `json:"list_price_usd" db:"list_price_usd"`
+	ListedAt       *time.Time `json:"listed_at" db:"listed_at"`
+	SalePriceUSD   *float64   `json:"sale_price_usd" db:"sale_price_usd"`
+	SaleFeeUSD     *float64   `json:"sale_fee_usd" db:"sale_fee_usd"`
+	SaleRateIRR    *float64   `json:"sale_rate_irr" db:"sale_rate_irr"` // Rial per USDT when sold
+	SoldAt         *time.Time `json:"sold_at" db:"sold_at"`
+	Notes          string     `json:"notes" db:"notes"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// QuarantinedItem is a scraped listing that failed validation and was kept
+// out of the items table
+type QuarantinedItem struct {
+	ID             string          `json:"id" db:"id"`
+	MarketplaceID  string          `json:"marketplace_id" db:"marketplace_id"`
+	MarketItemID   string          `json:"market_item_id" db:"market_item_id"`
+	MarketHashName string          `json:"market_hash_name" db:"market_hash_name"`
+	SkinID         *string         `json:"skin_id" db:"skin_id"`
+	Price          float64         `json:"price" db:"price"` // Marketplace currency
+	PriceUSD       float64         `json:"price_usd" db:"price_usd"`
+	SteamPriceUSD  float64         `json:"steam_price_usd" db:"steam_price_usd"`
+	Float          *float64        `json:"float" db:"float"`
+	Rules          []string        `json:"rules" db:"rules"`     // Rules that failed, e.g. wear_mismatch
+	Reasons        []string        `json:"reasons" db:"reasons"` // Human readable, one per failure
+	Raw            json.RawMessage `json:"raw" db:"raw"`         // Listing as scraped
+	TimesSeen      int             `json:"times_seen" db:"times_seen"`
+	FirstSeenAt    time.Time       `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt     time.Time       `json:"last_seen_at" db:"last_seen_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// ScrapeSummary describes a finished scrape of one marketplace
+type ScrapeSummary struct {
+	MarketplaceID string
+	// SeenItemIDs are the stored items listed during the scrape
+	SeenItemIDs []string
+	// InvalidItemIDs are stored items still listed whose listing was
+	// quarantined or failed to process in this scrape
+	InvalidItemIDs []string
+	// Complete is false when the scrape stopped before the last page, in
+	// which case unseen listings may still be listed
+	Complete bool
+}
@@ -4,17 +4,40 @@ import (
 	"time"
 )
 
+// BaseSkin is a weapon and finish shared by every variant of a skin, across
+// wears and StatTrak/Souvenir versions (e.g. AK-47 | Redline)
+type BaseSkin struct {
+	ID        string    `json:"id" db:"id"`
+	Weapon    string    `json:"weapon" db:"weapon"`
+	Finish    string    `json:"finish" db:"finish"`
+	Category  string    `json:"category" db:"category"`
+	Rarity    string    `json:"rarity" db:"rarity"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Name returns the display name of the base skin, e.g. "AK-47 | Redline"
+func (b *BaseSkin) Name() string {
+	if b.Finish == "" {
+		return b.Weapon
+	}
+	return b.Weapon + " | " + b.Finish
+}
+
 // Skin represents the core information about a CS2 skin
 type Skin struct {
 	ID             string    `json:"id" db:"id"`
+	BaseSkinID     string    `json:"base_skin_id" db:"base_skin_id"`
 	MarketHashName string    `json:"market_hash_name" db:"market_hash_name"`
-	Category       string    `json:"category" db:"category"`         // e.g. Rifle, Knife, Pistol
+	Category       string    `json:"category" db:"category"`         // e.g. Rifle, Knife, Pistol, Case, Sticker
 	SubCategory    string    `json:"sub_category" db:"sub_category"` // e.g. AK-47, Karambit, USP-S
 	SkinName       string    `json:"skin_name" db:"skin_name"`       // e.g. Asiimov, Fade, Doppler
 	IsStatTrak     bool      `json:"is_stattrak" db:"is_stattrak"`
-	Quality        string    `json:"quality" db:"quality"`     // Factory New, Minimal Wear, etc.
-	MinFloat       float64   `json:"min_float" db:"min_float"` // Minimum possible float value
-	MaxFloat       float64   `json:"max_float" db:"max_float"` // Maximum possible float value
+	IsSouvenir     bool      `json:"is_souvenir" db:"is_souvenir"`
+	IsStar         bool      `json:"is_star" db:"is_star"`     // ★ knives and gloves
+	Quality        string    `json:"quality" db:"quality"`     // Factory New, Minimal Wear, etc.; empty for items without wear
+	MinFloat       *float64  `json:"min_float" db:"min_float"` // Minimum possible float value, nil for items without wear
+	MaxFloat       *float64  `json:"max_float" db:"max_float"` // Maximum possible float value, nil for items without wear
 	IconURL        string    `json:"icon_url" db:"icon_url"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
@@ -37,3 +60,27 @@ func GetWearCategory(floatValue float64) string {
 		return "Unknown"
 	}
 }
+
+// VariantPrice summarizes current listings of one variant of a base skin
+type VariantPrice struct {
+	SkinID             string  `json:"skin_id"`
+	MarketHashName     string  `json:"market_hash_name"`
+	Quality            string  `json:"quality"`
+	IsStatTrak         bool    `json:"is_stattrak"`
+	IsSouvenir         bool    `json:"is_souvenir"`
+	Listings           int     `json:"listings"`
+	LowestPriceUSD     float64 `json:"lowest_price_usd"`
+	AveragePriceUSD    float64 `json:"average_price_usd"`
+	SteamPriceUSD      float64 `json:"steam_price_usd"`
+	MislabeledListings int     `json:"mislabeled_listings"`
+}
+
+// PhasePrice is a reference price for one Doppler phase of a skin, since the
+// Steam market lists every phase under the same market hash name
+type PhasePrice struct {
+	ID             string    `json:"id" db:"id"`
+	MarketHashName string    `json:"market_hash_name" db:"market_hash_name"`
+	Phase          string    `json:"phase" db:"phase"`
+	PriceUSD       float64   `json:"price_usd" db:"price_usd"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// SteamPriceStats are the Steam Community Market figures for a skin, used
+// to judge whether the sell side actually trades
+type SteamPriceStats struct {
+	SkinID         string    `json:"skin_id" db:"skin_id"`
+	MarketHashName string    `json:"market_hash_name" db:"market_hash_name"`
+	LowestPriceUSD float64   `json:"lowest_price_usd" db:"lowest_price_usd"` // Cheapest current listing
+	MedianPriceUSD float64   `json:"median_price_usd" db:"median_price_usd"` // Median sale price over the last day
+	DailyVolume    *int      `json:"daily_volume" db:"daily_volume"`         // Units sold over the last day, nil if unknown
+	ListingCount   int       `json:"listing_count" db:"listing_count"`       // Units currently listed, -1 if unknown
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Sticker is a sticker applied to an item, parsed from the marketplace data
+type Sticker struct {
+	Slot     int     `json:"slot"`                // Position on the weapon, 0-based
+	Name     string  `json:"name"`                // e.g. Sticker | Titan (Holo) | Katowice 2014
+	ImageURL string  `json:"image_url,omitempty"` // Image URL when the source provides one
+	Wear     float64 `json:"wear"`                // Scrape level, 0 is pristine and 1 fully scraped
+	PriceUSD float64 `json:"price_usd"`           // Reference price of the sticker, 0 if unknown
+}
+
+// StickerPrice is a sticker catalogue entry with its reference price
+type StickerPrice struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	ImageURL  string    `json:"image_url" db:"image_url"`
+	PriceUSD  float64   `json:"price_usd" db:"price_usd"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// Display currencies a user can choose
+const (
+	CurrencyUSD = "USD"
+	CurrencyIRR = "IRR"
+)
+
+// User is a trader with their own settings, presets, watchlist and alerts
+type User struct {
+	ID           string       `json:"id" db:"id"`
+	Username     string       `json:"username" db:"username"`
+	PasswordHash string       `json:"-" db:"password_hash"`
+	Scopes       []string     `json:"scopes" db:"scopes"`
+	Settings     UserSettings `json:"settings"`
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// UserSettings are a user's defaults, applied to /api/arbitrage unless the
+// request overrides them. nil means the global default.
+type UserSettings struct {
+	DisplayCurrency         string             `json:"display_currency" db:"display_currency"`
+	DefaultMinProfitPercent *float64           `json:"default_min_profit_percent" db:"default_min_profit_percent"`
+	MaxBuyPriceUSD          *float64           `json:"max_buy_price_usd" db:"max_buy_price_usd"` // Capital per item
+	SteamSaleFeePercent     *float64           `json:"steam_sale_fee_percent" db:"steam_sale_fee_percent"`
+	MarketplaceFeePercent   map[string]float64 `json:"marketplace_fee_percent" db:"marketplace_fee_percent"` // Sale fee by marketplace name
+}
+
+// FilterPreset is a saved set of /api/arbitrage query parameters
+type FilterPreset struct {
+	ID        string            `json:"id" db:"id"`
+	UserID    string            `json:"user_id" db:"user_id"`
+	Name      string            `json:"name" db:"name"`
+	Filters   map[string]string `json:"filters" db:"filters"` // e.g. {"min_profit": "15", "direction": "both"}
+	IsDefault bool              `json:"is_default" db:"is_default"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// WatchlistItem is a skin a user follows
+type WatchlistItem struct {
+	ID             string    `json:"id" db:"id"`
+	UserID         string    `json:"user_id" db:"user_id"`
+	MarketHashName string    `json:"market_hash_name" db:"market_hash_name"`
+	MaxPriceUSD    *float64  `json:"max_price_usd" db:"max_price_usd"` // Target buy price, nil for any
+	Note           string    `json:"note" db:"note"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
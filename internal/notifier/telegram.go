@@ -0,0 +1,437 @@
+package notifier
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
+)
+
+// Limits of the /top command and of photo captions
+const (
+	defaultTopCount     = 5
+	maxTopCount         = 20
+	maxCaptionLength    = 1024
+	pollTimeoutSeconds  = 10
+	pollErrorRetryDelay = 5 * time.Second
+)
+
+// TelegramStore is the storage the notifier needs. *database.Database
+// satisfies it, and tests supply their own.
+type TelegramStore interface {
+	GetTelegramMutes() (map[int64][]string, error)
+	MuteTelegramCategory(chatID int64, category string) error
+	UnmuteTelegramCategory(chatID int64, category string) error
+	ClaimTelegramNotification(chatID int64, itemID string) (bool, error)
+	UpdateTelegramNotification(chatID int64, itemID, status string) error
+}
+
+// Telegram announces new opportunities to the configured chats and answers
+// bot commands. It implements scraper.Observer.
+type Telegram struct {
+	db               TelegramStore
+	find             func(filter arbitrage.Filter) ([]arbitrage.Opportunity, error) // Current opportunities for /top
+	api              TelegramAPI
+	chatIDs          []int64
+	minNetProfitUSD  float64
+	minProfitPercent float64
+	queue            chan telegramMessage
+
+	mu    sync.Mutex
+	muted map[int64]map[string]bool
+}
+
+// telegramMessage is a queued message for one chat
+type telegramMessage struct {
+	chatID   int64
+	itemID   string // Listing announced, empty for command replies
+	photoURL string
+	text     string
+}
+
+// NewTelegram creates a Telegram notifier sending to chatIDs through api.
+// Only listings with at least minNetProfitUSD and minProfitPercent are sent.
+func NewTelegram(db *database.Database, api TelegramAPI, chatIDs []int64, minNetProfitUSD, minProfitPercent float64) (*Telegram, error) {
+	find := func(filter arbitrage.Filter) ([]arbitrage.Opportunity, error) {
+		return arbitrage.Find(db, filter)
+	}
+	return newTelegram(db, find, api, chatIDs, minNetProfitUSD, minProfitPercent)
+}
+
+// newTelegram creates a Telegram notifier on any store and opportunity source
+func newTelegram(db TelegramStore, find func(arbitrage.Filter) ([]arbitrage.Opportunity, error), api TelegramAPI, chatIDs []int64, minNetProfitUSD, minProfitPercent float64) (*Telegram, error) {
+	mutes, err := db.GetTelegramMutes()
+	if err != nil {
+		return nil, err
+	}
+
+	muted := make(map[int64]map[string]bool)
+	for chatID, categories := range mutes {
+		muted[chatID] = make(map[string]bool)
+		for _, category := range categories {
+			muted[chatID][category] = true
+		}
+	}
+
+	return &Telegram{
+		db:               db,
+		find:             find,
+		api:              api,
+		chatIDs:          chatIDs,
+		minNetProfitUSD:  minNetProfitUSD,
+		minProfitPercent: minProfitPercent,
+		queue:            make(chan telegramMessage, 1000),
+		muted:            muted,
+	}, nil
+}
+
+// TelegramFromEnv creates a Telegram notifier from TELEGRAM_BOT_TOKEN and the
+// comma-separated TELEGRAM_CHAT_IDS. It returns nil when no bot is configured.
+func TelegramFromEnv(db *database.Database, client httputil.Doer) (*Telegram, error) {
+	token := config.String("TELEGRAM_BOT_TOKEN", "")
+	if token == "" {
+		return nil, nil
+	}
+
+	var chatIDs []int64
+	for _, raw := range strings.Split(config.String("TELEGRAM_CHAT_IDS", ""), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		chatID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chat ID %q in TELEGRAM_CHAT_IDS: %v", raw, err)
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+	if len(chatIDs) == 0 {
+		return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN is set but TELEGRAM_CHAT_IDS is empty")
+	}
+
+	api := NewTelegramClient(config.String("TELEGRAM_API_URL", DefaultTelegramAPIURL), token, client)
+	return NewTelegram(db, api, chatIDs,
+		config.Float("TELEGRAM_MIN_NET_PROFIT_USD", 5),
+		config.Float("TELEGRAM_MIN_PROFIT_PERCENT", 10),
+	)
+}
+
+// Start launches the sender and the command poller
+func (t *Telegram) Start() {
+	go t.sender()
+	go t.poll()
+}
+
+// ItemProcessed announces a stored listing to every chat that has not muted
+// its category, once per chat. Messages that failed to send, including those
+// dropped on a full queue, are tried again when a later scrape sees the
+// listing.
+func (t *Telegram) ItemProcessed(item *models.Item, opp *arbitrage.Opportunity) {
+	if opp == nil || opp.NetProfitUSD < t.minNetProfitUSD || opp.NetProfitPercent < t.minProfitPercent {
+		return
+	}
+
+	text := FormatOpportunity(opp)
+	for _, chatID := range t.chatIDs {
+		if t.isMuted(chatID, opp.Category) {
+			continue
+		}
+
+		claimed, err := t.db.ClaimTelegramNotification(chatID, item.ID)
+		if err != nil {
+			log.Printf("Error recording Telegram notification: %v", err)
+			continue
+		}
+		if !claimed {
+			continue // Already announced in this chat
+		}
+
+		t.enqueue(telegramMessage{chatID: chatID, itemID: item.ID, photoURL: opp.IconURL, text: text})
+	}
+}
+
+// ScrapeStarted is a no-op
+func (t *Telegram) ScrapeStarted(marketplaceID string) {}
+
+// ScrapeCompleted is a no-op; listings are announced as they are stored
+func (t *Telegram) ScrapeCompleted(summary *models.ScrapeSummary) {}
+
+// FormatOpportunity renders an opportunity as an HTML Telegram message
+func FormatOpportunity(opp *arbitrage.Opportunity) string {
+	var b strings.Builder
+
+	name := opp.MarketHashName
+	if opp.Phase != "" {
+		name += " (" + opp.Phase + ")"
+	}
+	fmt.Fprintf(&b, "<b>%s</b>\n", html.EscapeString(name))
+
+	details := []string{html.EscapeString(opp.Category)}
+	if opp.Float != nil {
+		details = append(details, fmt.Sprintf("Float %.6f", *opp.Float))
+	}
+	if opp.PaintSeed > 0 {
+		details = append(details, fmt.Sprintf("Seed %d", opp.PaintSeed))
+	}
+	if opp.FadePercent > 0 {
+		details = append(details, fmt.Sprintf("%.0f%% fade", opp.FadePercent))
+	}
+	fmt.Fprintf(&b, "%s\n\n", strings.Join(details, " · "))
+
+	fmt.Fprintf(&b, "Buy: <b>$%.2f</b> on %s\n", opp.BuyPriceUSD, html.EscapeString(opp.Marketplace))
+	fmt.Fprintf(&b, "Sell: <b>$%.2f</b> on Steam\n", opp.SellPriceUSD)
+	fmt.Fprintf(&b, "Net profit: <b>$%.2f (%.1f%%)</b> after $%.2f fees\n", opp.NetProfitUSD, opp.NetProfitPercent, opp.SaleFeeUSD)
+
+	if len(opp.StickerDetails) > 0 {
+		var stickers []string
+		for _, sticker := range opp.StickerDetails {
+			stickers = append(stickers, html.EscapeString(sticker.Name))
+		}
+		fmt.Fprintf(&b, "\nStickers: %s", strings.Join(stickers, ", "))
+		if opp.StickerValueUSD > 0 {
+			fmt.Fprintf(&b, " ($%.2f)", opp.StickerValueUSD)
+		}
+		b.WriteString("\n")
+	}
+
+	if opp.MarketItemID != "" {
+		fmt.Fprintf(&b, "\n<a href=\"%s\">View listing</a>", html.EscapeString(models.CSGOSkinListingURL(opp.MarketItemID)))
+	}
+
+	return b.String()
+}
+
+// enqueue queues a message without blocking the scraper
+func (t *Telegram) enqueue(msg telegramMessage) {
+	select {
+	case t.queue <- msg:
+	default:
+		log.Printf("Telegram queue full, dropping message to chat %d", msg.chatID)
+		t.recordSent(msg, false)
+	}
+}
+
+// sender delivers queued messages, as a photo with caption when possible and
+// as text when the photo can't be sent, e.g. when Telegram can't fetch it
+func (t *Telegram) sender() {
+	for msg := range t.queue {
+		sent := false
+		if msg.photoURL != "" && len(msg.text) <= maxCaptionLength {
+			if err := t.api.SendPhoto(msg.chatID, msg.photoURL, msg.text); err != nil {
+				log.Printf("Error sending Telegram photo to chat %d, sending text instead: %v", msg.chatID, err)
+			} else {
+				sent = true
+			}
+		}
+		if !sent {
+			if err := t.api.SendMessage(msg.chatID, msg.text); err != nil {
+				log.Printf("Error sending Telegram message to chat %d: %v", msg.chatID, err)
+			} else {
+				sent = true
+			}
+		}
+		t.recordSent(msg, sent)
+	}
+}
+
+// recordSent records whether a listing's message was sent, so a failed one
+// can be claimed again
+func (t *Telegram) recordSent(msg telegramMessage, sent bool) {
+	if msg.itemID == "" {
+		return
+	}
+	status := database.TelegramNotificationSent
+	if !sent {
+		status = database.TelegramNotificationFailed
+	}
+	if err := t.db.UpdateTelegramNotification(msg.chatID, msg.itemID, status); err != nil {
+		log.Printf("Error recording Telegram notification: %v", err)
+	}
+}
+
+// poll long-polls for bot commands from the configured chats
+func (t *Telegram) poll() {
+	var offset int64
+	for {
+		updates, err := t.api.GetUpdates(offset, pollTimeoutSeconds)
+		if err != nil {
+			log.Printf("Error polling Telegram updates: %v", err)
+			time.Sleep(pollErrorRetryDelay)
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message == nil || !t.isConfiguredChat(update.Message.Chat.ID) {
+				continue
+			}
+			if reply := t.HandleCommand(update.Message.Chat.ID, update.Message.Text); reply != "" {
+				t.enqueue(telegramMessage{chatID: update.Message.Chat.ID, text: reply})
+			}
+		}
+	}
+}
+
+// HandleCommand runs a bot command and returns the reply, or an empty string
+// when the text is not a command
+func (t *Telegram) HandleCommand(chatID int64, text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+
+	// Commands in groups may be addressed as /top@botname
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@")
+	arg := strings.Join(fields[1:], " ")
+
+	switch command {
+	case "/top":
+		return t.topOpportunities(chatID, arg)
+	case "/mute":
+		if arg == "" {
+			return "Usage: /mute <category>"
+		}
+		if err := t.setMuted(chatID, arg, true); err != nil {
+			log.Printf("Error muting category: %v", err)
+			return "Failed to mute category"
+		}
+		return fmt.Sprintf("Muted %s", html.EscapeString(arg))
+	case "/unmute":
+		if arg == "" {
+			return "Usage: /unmute <category>"
+		}
+		if err := t.setMuted(chatID, arg, false); err != nil {
+			log.Printf("Error unmuting category: %v", err)
+			return "Failed to unmute category"
+		}
+		return fmt.Sprintf("Unmuted %s", html.EscapeString(arg))
+	case "/muted":
+		categories := t.mutedCategories(chatID)
+		if len(categories) == 0 {
+			return "No categories are muted"
+		}
+		return "Muted: " + html.EscapeString(strings.Join(categories, ", "))
+	case "/start", "/help":
+		return "/top [n] - best current opportunities\n" +
+			"/mute &lt;category&gt; - stop alerts for a category, e.g. /mute Knife\n" +
+			"/unmute &lt;category&gt; - resume alerts for a category\n" +
+			"/muted - list muted categories"
+	default:
+		return "Unknown command, try /help"
+	}
+}
+
+// topOpportunities lists the best current opportunities by net profit,
+// skipping categories muted in the chat
+func (t *Telegram) topOpportunities(chatID int64, arg string) string {
+	count := defaultTopCount
+	if arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			return "Usage: /top [n]"
+		}
+		count = n
+	}
+	if count > maxTopCount {
+		count = maxTopCount
+	}
+
+	opportunities, err := t.find(arbitrage.Filter{MinProfitPercent: t.minProfitPercent})
+	if err != nil {
+		log.Printf("Error finding opportunities for Telegram: %v", err)
+		return "Failed to load opportunities"
+	}
+
+	sort.Slice(opportunities, func(i, j int) bool {
+		return opportunities[i].NetProfitUSD > opportunities[j].NetProfitUSD
+	})
+
+	var lines []string
+	for i := range opportunities {
+		opp := &opportunities[i]
+		if opp.NetProfitUSD <= 0 || t.isMuted(chatID, opp.Category) {
+			continue
+		}
+
+		line := fmt.Sprintf("%d. <b>%s</b> $%.2f → $%.2f, net $%.2f (%.1f%%)",
+			len(lines)+1, html.EscapeString(opp.MarketHashName), opp.BuyPriceUSD, opp.SellPriceUSD, opp.NetProfitUSD, opp.NetProfitPercent)
+		if opp.MarketItemID != "" {
+			line += fmt.Sprintf(" <a href=\"%s\">link</a>", html.EscapeString(models.CSGOSkinListingURL(opp.MarketItemID)))
+		}
+		lines = append(lines, line)
+
+		if len(lines) == count {
+			break
+		}
+	}
+
+	if len(lines) == 0 {
+		return "No opportunities right now"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isConfiguredChat reports whether commands from a chat should be answered
+func (t *Telegram) isConfiguredChat(chatID int64) bool {
+	for _, id := range t.chatIDs {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// isMuted reports whether a chat muted a category
+func (t *Telegram) isMuted(chatID int64, category string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.muted[chatID][strings.ToLower(category)]
+}
+
+// setMuted mutes or unmutes a category in a chat and persists the change
+func (t *Telegram) setMuted(chatID int64, category string, muted bool) error {
+	var err error
+	if muted {
+		err = t.db.MuteTelegramCategory(chatID, category)
+	} else {
+		err = t.db.UnmuteTelegramCategory(chatID, category)
+	}
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.muted[chatID] == nil {
+		t.muted[chatID] = make(map[string]bool)
+	}
+	if muted {
+		t.muted[chatID][strings.ToLower(category)] = true
+	} else {
+		delete(t.muted[chatID], strings.ToLower(category))
+	}
+	return nil
+}
+
+// mutedCategories returns the categories muted in a chat
+func (t *Telegram) mutedCategories(chatID int64) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var categories []string
+	for category := range t.muted[chatID] {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
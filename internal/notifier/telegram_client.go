@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
+)
+
+// DefaultTelegramAPIURL is the Bot API endpoint used unless TELEGRAM_API_URL
+// points elsewhere, such as a local fake
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// TelegramAPI is the subset of the Telegram Bot API the notifier uses
+type TelegramAPI interface {
+	SendMessage(chatID int64, text string) error
+	SendPhoto(chatID int64, photoURL, caption string) error
+	GetUpdates(offset int64, timeoutSeconds int) ([]TelegramUpdate, error)
+}
+
+// TelegramUpdate is an incoming update from getUpdates
+type TelegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *TelegramMessage `json:"message"`
+}
+
+// TelegramMessage is a message sent to the bot
+type TelegramMessage struct {
+	MessageID int64  `json:"message_id"`
+	Text      string `json:"text"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// TelegramClient calls the Telegram Bot API over HTTP
+type TelegramClient struct {
+	baseURL string
+	token   string
+	client  httputil.Doer
+}
+
+// NewTelegramClient creates a Bot API client for the given bot token
+func NewTelegramClient(baseURL, token string, client httputil.Doer) *TelegramClient {
+	return &TelegramClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  client,
+	}
+}
+
+// telegramResponse is the envelope of every Bot API response
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// SendMessage sends an HTML-formatted text message
+func (c *TelegramClient) SendMessage(chatID int64, text string) error {
+	_, err := c.call("sendMessage", map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	})
+	return err
+}
+
+// SendPhoto sends a photo by URL with an HTML-formatted caption
+func (c *TelegramClient) SendPhoto(chatID int64, photoURL, caption string) error {
+	_, err := c.call("sendPhoto", map[string]interface{}{
+		"chat_id":    chatID,
+		"photo":      photoURL,
+		"caption":    caption,
+		"parse_mode": "HTML",
+	})
+	return err
+}
+
+// GetUpdates long-polls for updates after offset
+func (c *TelegramClient) GetUpdates(offset int64, timeoutSeconds int) ([]TelegramUpdate, error) {
+	result, err := c.call("getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeoutSeconds,
+		"allowed_updates": []string{"message"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []TelegramUpdate
+	if err := json.Unmarshal(result, &updates); err != nil {
+		return nil, fmt.Errorf("failed to parse telegram updates: %v", err)
+	}
+	return updates, nil
+}
+
+// call invokes a Bot API method and returns its result
+func (c *TelegramClient) call(method string, params map[string]interface{}) (json.RawMessage, error) {
+	url := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	_, body, err := httputil.PostJSON(c.client, url, params, nil)
+	if err != nil {
+		// Keep the bot token out of logs
+		return nil, fmt.Errorf("telegram %s failed: %v", method, strings.ReplaceAll(err.Error(), c.token, "<token>"))
+	}
+
+	var resp telegramResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse telegram %s response: %v", method, err)
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("telegram %s failed: %s", method, resp.Description)
+	}
+	return resp.Result, nil
+}
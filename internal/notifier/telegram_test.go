@@ -0,0 +1,378 @@
+package notifier
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// fakeTelegramStore keeps mutes and notifications in memory, claiming each
+// chat and listing once like the telegram_notifications primary key, or
+// again after a failed send
+type fakeTelegramStore struct {
+	mu       sync.Mutex
+	mutes    map[int64][]string
+	notified map[int64]map[string]string // Notification status by chat and item
+}
+
+func newFakeTelegramStore() *fakeTelegramStore {
+	return &fakeTelegramStore{mutes: map[int64][]string{}, notified: map[int64]map[string]string{}}
+}
+
+func (s *fakeTelegramStore) GetTelegramMutes() (map[int64][]string, error) {
+	return s.mutes, nil
+}
+
+func (s *fakeTelegramStore) MuteTelegramCategory(chatID int64, category string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mutes[chatID] = append(s.mutes[chatID], strings.ToLower(category))
+	return nil
+}
+
+func (s *fakeTelegramStore) UnmuteTelegramCategory(chatID int64, category string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var kept []string
+	for _, c := range s.mutes[chatID] {
+		if c != strings.ToLower(category) {
+			kept = append(kept, c)
+		}
+	}
+	s.mutes[chatID] = kept
+	return nil
+}
+
+func (s *fakeTelegramStore) ClaimTelegramNotification(chatID int64, itemID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.notified[chatID] == nil {
+		s.notified[chatID] = map[string]string{}
+	}
+	if status, ok := s.notified[chatID][itemID]; ok && status != database.TelegramNotificationFailed {
+		return false, nil
+	}
+	s.notified[chatID][itemID] = database.TelegramNotificationPending
+	return true, nil
+}
+
+func (s *fakeTelegramStore) UpdateTelegramNotification(chatID int64, itemID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notified[chatID][itemID] = status
+	return nil
+}
+
+// status returns the notification status of a chat and item
+func (s *fakeTelegramStore) status(chatID int64, itemID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notified[chatID][itemID]
+}
+
+// sentMessage is a message sent through the fake API
+type sentMessage struct {
+	chatID   int64
+	photoURL string
+	text     string
+}
+
+// fakeTelegramAPI records sent messages and serves scripted updates. Sends
+// fail while failPhotos or failMessages is set.
+type fakeTelegramAPI struct {
+	sent    chan sentMessage
+	updates chan []TelegramUpdate
+
+	mu           sync.Mutex
+	failPhotos   bool
+	failMessages bool
+}
+
+// fail makes photo and text sends fail or succeed
+func (a *fakeTelegramAPI) fail(photos, messages bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.failPhotos, a.failMessages = photos, messages
+}
+
+func newFakeTelegramAPI() *fakeTelegramAPI {
+	return &fakeTelegramAPI{sent: make(chan sentMessage, 100), updates: make(chan []TelegramUpdate, 10)}
+}
+
+func (a *fakeTelegramAPI) SendMessage(chatID int64, text string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failMessages {
+		return errors.New("send failed")
+	}
+	a.sent <- sentMessage{chatID: chatID, text: text}
+	return nil
+}
+
+func (a *fakeTelegramAPI) SendPhoto(chatID int64, photoURL, caption string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failPhotos {
+		return errors.New("wrong file identifier/HTTP URL specified")
+	}
+	a.sent <- sentMessage{chatID: chatID, photoURL: photoURL, text: caption}
+	return nil
+}
+
+func (a *fakeTelegramAPI) GetUpdates(offset int64, timeoutSeconds int) ([]TelegramUpdate, error) {
+	return <-a.updates, nil
+}
+
+// next returns the next sent message
+func (a *fakeTelegramAPI) next(t *testing.T) sentMessage {
+	t.Helper()
+	select {
+	case msg := <-a.sent:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message sent")
+		return sentMessage{}
+	}
+}
+
+// expectNone fails when a message is sent shortly
+func (a *fakeTelegramAPI) expectNone(t *testing.T) {
+	t.Helper()
+	select {
+	case msg := <-a.sent:
+		t.Fatalf("unexpected message %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func testOpportunities() []arbitrage.Opportunity {
+	return []arbitrage.Opportunity{
+		{ItemID: "a", MarketHashName: "AK-47 | Redline (Field-Tested)", Category: "Rifle", BuyPriceUSD: 10, SellPriceUSD: 14, NetProfitUSD: 2, NetProfitPercent: 20},
+		{ItemID: "b", MarketHashName: "★ Karambit | Fade (Factory New)", Category: "Knife", BuyPriceUSD: 1000, SellPriceUSD: 1300, NetProfitUSD: 130, NetProfitPercent: 13},
+		{ItemID: "c", MarketHashName: "AWP | Asiimov (Field-Tested)", Category: "Sniper Rifle", BuyPriceUSD: 80, SellPriceUSD: 110, NetProfitUSD: 15, NetProfitPercent: 18.75},
+		{ItemID: "d", MarketHashName: "Glock-18 | Fade (Factory New)", Category: "Pistol", BuyPriceUSD: 900, SellPriceUSD: 950, NetProfitUSD: -40, NetProfitPercent: -4.4},
+	}
+}
+
+func newTestTelegram(t *testing.T, store *fakeTelegramStore, api *fakeTelegramAPI, chatIDs ...int64) *Telegram {
+	t.Helper()
+	find := func(filter arbitrage.Filter) ([]arbitrage.Opportunity, error) {
+		return testOpportunities(), nil
+	}
+	tg, err := newTelegram(store, find, api, chatIDs, 5, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tg
+}
+
+func TestTopCommand(t *testing.T) {
+	tg := newTestTelegram(t, newFakeTelegramStore(), newFakeTelegramAPI(), 1)
+
+	reply := tg.HandleCommand(1, "/top")
+	lines := strings.Split(reply, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("/top listed %d lines, want 3 profitable ones:\n%s", len(lines), reply)
+	}
+	// Best net profit first, losses left out
+	for i, want := range []string{"Karambit", "AWP", "AK-47"} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d = %q, want %s", i+1, lines[i], want)
+		}
+	}
+	if !strings.HasPrefix(lines[0], "1. ") || !strings.Contains(lines[0], "net $130.00") {
+		t.Errorf("line 1 = %q, want numbered with its net profit", lines[0])
+	}
+
+	if reply := tg.HandleCommand(1, "/top@arbitrage_bot 2"); len(strings.Split(reply, "\n")) != 2 {
+		t.Errorf("/top 2 = %q, want 2 lines", reply)
+	}
+	for _, arg := range []string{"0", "-1", "many"} {
+		if reply := tg.HandleCommand(1, "/top "+arg); reply != "Usage: /top [n]" {
+			t.Errorf("/top %s = %q, want usage", arg, reply)
+		}
+	}
+}
+
+func TestMuteCommands(t *testing.T) {
+	store := newFakeTelegramStore()
+	tg := newTestTelegram(t, store, newFakeTelegramAPI(), 1, 2)
+
+	if reply := tg.HandleCommand(1, "/muted"); reply != "No categories are muted" {
+		t.Errorf("/muted = %q", reply)
+	}
+	if reply := tg.HandleCommand(1, "/mute"); reply != "Usage: /mute <category>" {
+		t.Errorf("/mute without category = %q", reply)
+	}
+	if reply := tg.HandleCommand(1, "/mute Knife"); reply != "Muted Knife" {
+		t.Errorf("/mute Knife = %q", reply)
+	}
+	if got := store.mutes[1]; len(got) != 1 || got[0] != "knife" {
+		t.Errorf("stored mutes = %v, want [knife]", got)
+	}
+
+	if reply := tg.HandleCommand(1, "/muted"); reply != "Muted: knife" {
+		t.Errorf("/muted = %q", reply)
+	}
+	if reply := tg.HandleCommand(2, "/muted"); reply != "No categories are muted" {
+		t.Errorf("/muted in another chat = %q", reply)
+	}
+
+	// Muted categories are left out of /top
+	if reply := tg.HandleCommand(1, "/top"); strings.Contains(reply, "Karambit") {
+		t.Errorf("/top lists a muted category:\n%s", reply)
+	}
+	if reply := tg.HandleCommand(2, "/top"); !strings.Contains(reply, "Karambit") {
+		t.Errorf("/top in another chat misses Karambit:\n%s", reply)
+	}
+
+	if reply := tg.HandleCommand(1, "/unmute KNIFE"); reply != "Unmuted KNIFE" {
+		t.Errorf("/unmute KNIFE = %q", reply)
+	}
+	if reply := tg.HandleCommand(1, "/muted"); reply != "No categories are muted" {
+		t.Errorf("/muted after unmuting = %q", reply)
+	}
+	if len(store.mutes[1]) != 0 {
+		t.Errorf("stored mutes = %v, want none", store.mutes[1])
+	}
+}
+
+func TestOtherCommands(t *testing.T) {
+	tg := newTestTelegram(t, newFakeTelegramStore(), newFakeTelegramAPI(), 1)
+
+	if reply := tg.HandleCommand(1, "hello"); reply != "" {
+		t.Errorf("plain text = %q, want no reply", reply)
+	}
+	if reply := tg.HandleCommand(1, "/frobnicate"); reply != "Unknown command, try /help" {
+		t.Errorf("unknown command = %q", reply)
+	}
+	if reply := tg.HandleCommand(1, "/help"); !strings.Contains(reply, "/mute") {
+		t.Errorf("/help = %q", reply)
+	}
+}
+
+func TestItemProcessedAnnouncesOncePerChat(t *testing.T) {
+	store := newFakeTelegramStore()
+	store.mutes[2] = []string{"knife"}
+	api := newFakeTelegramAPI()
+	tg := newTestTelegram(t, store, api, 1, 2)
+	tg.Start()
+
+	knife := testOpportunities()[1]
+	knife.IconURL = "https://example.com/karambit.png"
+	item := &models.Item{ID: knife.ItemID}
+
+	tg.ItemProcessed(item, &knife)
+	tg.ItemProcessed(item, &knife) // Seen again in the next scrape
+
+	msg := api.next(t)
+	if msg.chatID != 1 || msg.photoURL != knife.IconURL || !strings.Contains(msg.text, "Karambit") {
+		t.Errorf("message = %+v, want the Karambit photo in chat 1", msg)
+	}
+	api.expectNone(t)
+
+	// Below the thresholds, or not an opportunity
+	rifle := testOpportunities()[0]
+	rifle.NetProfitUSD = 1
+	tg.ItemProcessed(&models.Item{ID: rifle.ItemID}, &rifle)
+	tg.ItemProcessed(&models.Item{ID: "e"}, nil)
+	api.expectNone(t)
+}
+
+func TestItemProcessedFallsBackToText(t *testing.T) {
+	store := newFakeTelegramStore()
+	api := newFakeTelegramAPI()
+	api.fail(true, false)
+	tg := newTestTelegram(t, store, api, 1)
+	tg.Start()
+
+	knife := testOpportunities()[1]
+	knife.IconURL = "https://example.com/missing.png"
+	tg.ItemProcessed(&models.Item{ID: knife.ItemID}, &knife)
+
+	msg := api.next(t)
+	if msg.photoURL != "" || !strings.Contains(msg.text, "Karambit") {
+		t.Errorf("message = %+v, want the Karambit as text", msg)
+	}
+	waitForStatus(t, store, 1, knife.ItemID, database.TelegramNotificationSent)
+}
+
+func TestItemProcessedRetriesFailedSends(t *testing.T) {
+	store := newFakeTelegramStore()
+	api := newFakeTelegramAPI()
+	api.fail(true, true)
+	tg := newTestTelegram(t, store, api, 1)
+	tg.Start()
+
+	knife := testOpportunities()[1]
+	knife.IconURL = "https://example.com/karambit.png"
+	item := &models.Item{ID: knife.ItemID}
+
+	tg.ItemProcessed(item, &knife)
+	waitForStatus(t, store, 1, knife.ItemID, database.TelegramNotificationFailed)
+	api.expectNone(t)
+
+	// The next scrape sees the listing again once Telegram is reachable
+	api.fail(false, false)
+	tg.ItemProcessed(item, &knife)
+	if msg := api.next(t); msg.photoURL != knife.IconURL {
+		t.Errorf("retried message = %+v, want the Karambit photo", msg)
+	}
+	waitForStatus(t, store, 1, knife.ItemID, database.TelegramNotificationSent)
+
+	tg.ItemProcessed(item, &knife)
+	api.expectNone(t)
+}
+
+func TestFullQueueRecordsFailure(t *testing.T) {
+	store := newFakeTelegramStore()
+	tg := newTestTelegram(t, store, newFakeTelegramAPI(), 1)
+	tg.queue = make(chan telegramMessage) // No sender, so every message is dropped
+
+	knife := testOpportunities()[1]
+	tg.ItemProcessed(&models.Item{ID: knife.ItemID}, &knife)
+	if status := store.status(1, knife.ItemID); status != database.TelegramNotificationFailed {
+		t.Errorf("status = %q, want failed so a later scrape retries", status)
+	}
+}
+
+// waitForStatus waits until the sender records a notification status
+func waitForStatus(t *testing.T, store *fakeTelegramStore, chatID int64, itemID, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for store.status(chatID, itemID) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("status = %q, want %q", store.status(chatID, itemID), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPollAnswersConfiguredChats(t *testing.T) {
+	api := newFakeTelegramAPI()
+	tg := newTestTelegram(t, newFakeTelegramStore(), api, 1)
+	tg.Start()
+
+	update := func(id, chatID int64, text string) TelegramUpdate {
+		msg := &TelegramMessage{MessageID: id, Text: text}
+		msg.Chat.ID = chatID
+		return TelegramUpdate{UpdateID: id, Message: msg}
+	}
+	api.updates <- []TelegramUpdate{
+		update(1, 99, "/top"), // Not a configured chat
+		update(2, 1, "just chatting"),
+		update(3, 1, "/top 1"),
+	}
+
+	msg := api.next(t)
+	if msg.chatID != 1 || msg.photoURL != "" || !strings.Contains(msg.text, "Karambit") {
+		t.Errorf("reply = %+v, want the top opportunity in chat 1", msg)
+	}
+	api.expectNone(t)
+}
@@ -0,0 +1,148 @@
+package portfolio
+
+import (
+	"sort"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/steam"
+)
+
+// SteamVenue names Steam among the places an item can be sold
+const SteamVenue = "Steam"
+
+// VenueQuote is what an item would fetch on one marketplace when listed at
+// the current lowest price there
+type VenueQuote struct {
+	Venue        string  `json:"venue"`
+	PriceUSD     float64 `json:"price_usd"`
+	FeePercent   float64 `json:"fee_percent"`
+	NetUSD       float64 `json:"net_usd"`
+	ListingCount int     `json:"listing_count,omitempty"`
+}
+
+// InventoryItem is the valuation of every unit of one market hash name
+type InventoryItem struct {
+	MarketHashName string       `json:"market_hash_name"`
+	SkinID         string       `json:"skin_id,omitempty"`
+	Matched        bool         `json:"matched"` // A skins row exists for the name
+	IconURL        string       `json:"icon_url"`
+	Quantity       int          `json:"quantity"`
+	Tradable       int          `json:"tradable"` // Units that can be traded now
+	Marketable     bool         `json:"marketable"`
+	AssetIDs       []string     `json:"asset_ids"`
+	Quotes         []VenueQuote `json:"quotes"` // Best net first
+	BestVenue      string       `json:"best_venue,omitempty"`
+	BestNetUSD     float64      `json:"best_net_usd"`    // Per unit
+	TotalValueUSD  float64      `json:"total_value_usd"` // Best net times quantity
+}
+
+// InventoryValuation values a whole Steam inventory
+type InventoryValuation struct {
+	Items           []InventoryItem `json:"items"`
+	AssetCount      int             `json:"asset_count"`
+	MatchedCount    int             `json:"matched_count"`   // Distinct names matched to skins
+	UnmatchedCount  int             `json:"unmatched_count"` // Distinct names without a skins row
+	UnpricedCount   int             `json:"unpriced_count"`  // Distinct names without any price
+	TotalValueUSD   float64         `json:"total_value_usd"`
+	TotalValueIRR   float64         `json:"total_value_irr"`
+	ExchangeRateIRR float64         `json:"exchange_rate_irr"`
+}
+
+// ValueInventory matches inventory assets to skins by market hash name and
+// values them at the latest prices on Steam and each scraped marketplace,
+// recommending where each item nets the most
+func ValueInventory(db *database.Database, assets []steam.InventoryAsset) (*InventoryValuation, error) {
+	byName := make(map[string]*InventoryItem)
+	var names []string
+	for _, asset := range assets {
+		if asset.MarketHashName == "" {
+			continue
+		}
+		item, ok := byName[asset.MarketHashName]
+		if !ok {
+			item = &InventoryItem{MarketHashName: asset.MarketHashName, IconURL: asset.IconURL}
+			byName[asset.MarketHashName] = item
+			names = append(names, asset.MarketHashName)
+		}
+		item.Quantity += asset.Amount
+		item.AssetIDs = append(item.AssetIDs, asset.AssetID)
+		if asset.Tradable {
+			item.Tradable += asset.Amount
+		}
+		item.Marketable = item.Marketable || asset.Marketable
+	}
+
+	valuation := &InventoryValuation{
+		Items:           []InventoryItem{},
+		AssetCount:      len(assets),
+		ExchangeRateIRR: usdToIRRRate(),
+	}
+	if len(names) == 0 {
+		return valuation, nil
+	}
+
+	skinIDs, err := db.GetSkinIDs(names)
+	if err != nil {
+		return nil, err
+	}
+	steamPrices, err := db.GetReferencePrices(names)
+	if err != nil {
+		return nil, err
+	}
+	marketPrices, err := db.GetMarketPrices(names)
+	if err != nil {
+		return nil, err
+	}
+
+	steamFee := arbitrage.SteamSaleFeePercent()
+	for name, item := range byName {
+		item.SkinID, item.Matched = skinIDs[name]
+		if price := steamPrices[name]; price > 0 {
+			item.Quotes = append(item.Quotes, VenueQuote{
+				Venue:      SteamVenue,
+				PriceUSD:   price,
+				FeePercent: steamFee,
+				NetUSD:     price * (1 - steamFee/100),
+			})
+		}
+	}
+	for _, mp := range marketPrices {
+		item := byName[mp.MarketHashName]
+		item.Quotes = append(item.Quotes, VenueQuote{
+			Venue:        mp.Marketplace,
+			PriceUSD:     mp.LowestPriceUSD,
+			FeePercent:   mp.SaleFeePercent,
+			NetUSD:       mp.LowestPriceUSD * (1 - mp.SaleFeePercent/100),
+			ListingCount: mp.ListingCount,
+		})
+	}
+
+	for _, name := range names {
+		item := byName[name]
+		sort.Slice(item.Quotes, func(i, j int) bool { return item.Quotes[i].NetUSD > item.Quotes[j].NetUSD })
+
+		if item.Matched {
+			valuation.MatchedCount++
+		} else {
+			valuation.UnmatchedCount++
+		}
+		if len(item.Quotes) == 0 {
+			valuation.UnpricedCount++
+		} else {
+			item.BestVenue = item.Quotes[0].Venue
+			item.BestNetUSD = item.Quotes[0].NetUSD
+			item.TotalValueUSD = item.BestNetUSD * float64(item.Quantity)
+		}
+
+		valuation.TotalValueUSD += item.TotalValueUSD
+		valuation.Items = append(valuation.Items, *item)
+	}
+
+	sort.Slice(valuation.Items, func(i, j int) bool {
+		return valuation.Items[i].TotalValueUSD > valuation.Items[j].TotalValueUSD
+	})
+	valuation.TotalValueIRR = valuation.TotalValueUSD * valuation.ExchangeRateIRR
+
+	return valuation, nil
+}
@@ -0,0 +1,114 @@
+package portfolio
+
+import (
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// Valuation is the P&L of one portfolio item. Sold items have realized P&L at
+// the exchange rate of the sale; the rest are valued at the latest Steam
+// reference price after the sale fee, at the current exchange rate.
+type Valuation struct {
+	models.PortfolioItem
+	ReferencePriceUSD float64 `json:"reference_price_usd"` // 0 when unknown
+	ValueUSD          float64 `json:"value_usd"`           // Net proceeds, realized or expected
+	ProfitUSD         float64 `json:"profit_usd"`
+	ProfitIRR         float64 `json:"profit_irr"`
+	ProfitPercent     float64 `json:"profit_percent"`
+	Realized          bool    `json:"realized"`
+}
+
+// Summary totals the P&L of the portfolio
+type Summary struct {
+	Items               []Valuation    `json:"items"`
+	Count               int            `json:"count"`
+	CountByStatus       map[string]int `json:"count_by_status"`
+	InvestedUSD         float64        `json:"invested_usd"` // Cost of unsold items
+	InvestedIRR         float64        `json:"invested_irr"`
+	UnrealizedValueUSD  float64        `json:"unrealized_value_usd"`
+	UnrealizedProfitUSD float64        `json:"unrealized_profit_usd"`
+	UnrealizedProfitIRR float64        `json:"unrealized_profit_irr"`
+	RealizedProfitUSD   float64        `json:"realized_profit_usd"`
+	RealizedProfitIRR   float64        `json:"realized_profit_irr"`
+	UnpricedCount       int            `json:"unpriced_count"` // Unsold items without a reference price
+	ExchangeRateIRR     float64        `json:"exchange_rate_irr"`
+	SaleFeePercent      float64        `json:"sale_fee_percent"`
+}
+
+// Summarize values portfolio items and totals realized and unrealized P&L
+func Summarize(db Store, items []models.PortfolioItem) (*Summary, error) {
+	var names []string
+	for _, p := range items {
+		if p.Status != models.PortfolioSold {
+			names = append(names, p.MarketHashName)
+		}
+	}
+
+	prices := map[string]float64{}
+	if len(names) > 0 {
+		var err error
+		if prices, err = db.GetReferencePrices(names); err != nil {
+			return nil, err
+		}
+	}
+
+	summary := &Summary{
+		Items:           []Valuation{},
+		CountByStatus:   make(map[string]int),
+		ExchangeRateIRR: usdToIRRRate(),
+		SaleFeePercent:  arbitrage.SteamSaleFeePercent(),
+	}
+
+	for _, p := range items {
+		v := Valuation{PortfolioItem: p}
+		summary.CountByStatus[p.Status]++
+
+		if p.Status == models.PortfolioSold && p.SalePriceUSD != nil {
+			v.Realized = true
+			v.ValueUSD = *p.SalePriceUSD
+			if p.SaleFeeUSD != nil {
+				v.ValueUSD -= *p.SaleFeeUSD
+			}
+			v.ProfitUSD = v.ValueUSD - p.BuyPriceUSD
+
+			saleRate := summary.ExchangeRateIRR
+			if p.SaleRateIRR != nil {
+				saleRate = *p.SaleRateIRR
+			}
+			v.ProfitIRR = v.ValueUSD*saleRate - p.BuyPriceIRR
+
+			summary.RealizedProfitUSD += v.ProfitUSD
+			summary.RealizedProfitIRR += v.ProfitIRR
+		} else {
+			summary.InvestedUSD += p.BuyPriceUSD
+			summary.InvestedIRR += p.BuyPriceIRR
+
+			v.ReferencePriceUSD = prices[p.MarketHashName]
+			if p.ListPriceUSD != nil {
+				// A listed item is expected to sell at its asking price
+				v.ReferencePriceUSD = *p.ListPriceUSD
+			}
+			if v.ReferencePriceUSD <= 0 {
+				summary.UnpricedCount++
+				summary.Items = append(summary.Items, v)
+				continue
+			}
+
+			v.ValueUSD = v.ReferencePriceUSD * (1 - summary.SaleFeePercent/100)
+			v.ProfitUSD = v.ValueUSD - p.BuyPriceUSD
+			v.ProfitIRR = v.ValueUSD*summary.ExchangeRateIRR - p.BuyPriceIRR
+
+			summary.UnrealizedValueUSD += v.ValueUSD
+			summary.UnrealizedProfitUSD += v.ProfitUSD
+			summary.UnrealizedProfitIRR += v.ProfitIRR
+		}
+
+		if p.BuyPriceUSD > 0 {
+			v.ProfitPercent = v.ProfitUSD / p.BuyPriceUSD * 100
+		}
+		summary.Items = append(summary.Items, v)
+	}
+
+	summary.Count = len(summary.Items)
+	return summary, nil
+}
@@ -0,0 +1,237 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/internal/scraper"
+)
+
+// Errors callers can map to client errors
+var (
+	ErrNotFound = errors.New("portfolio item not found")
+	ErrInvalid  = errors.New("invalid portfolio request")
+)
+
+// Store is the storage the portfolio is kept in
+type Store interface {
+	InsertPortfolioItem(p *models.PortfolioItem) (string, error)
+	UpdatePortfolioItem(p *models.PortfolioItem) error
+	GetPortfolioItem(id string) (*models.PortfolioItem, error)
+	GetPortfolioItems(status string) ([]models.PortfolioItem, error)
+	GetListingForPurchase(itemID string) (*database.ListingForPurchase, error)
+	GetSkinIDs(marketHashNames []string) (map[string]string, error)
+	GetReferencePrices(marketHashNames []string) (map[string]float64, error)
+}
+
+// usdToIRRRate returns the current exchange rate, replaced in tests
+var usdToIRRRate = scraper.GetUSDTtoIRRRate
+
+// Purchase describes a bought item. Either ItemID links it to a scraped
+// listing, whose name, float, price and trade hold are used as defaults, or
+// MarketHashName and a buy price describe a manual entry.
+type Purchase struct {
+	ItemID         string     `json:"item_id"`
+	MarketHashName string     `json:"market_hash_name"`
+	Float          *float64   `json:"float"`
+	BuyPriceUSD    float64    `json:"buy_price_usd"`
+	BuyPriceIRR    float64    `json:"buy_price_irr"`
+	PurchasedAt    *time.Time `json:"purchased_at"`
+	TradeHoldUntil *time.Time `json:"trade_hold_until"`
+	Notes          string     `json:"notes"`
+}
+
+// RecordPurchase stores a purchase. Missing USD or IRR prices are converted
+// with the current exchange rate.
+func RecordPurchase(db Store, purchase Purchase) (*models.PortfolioItem, error) {
+	rate := usdToIRRRate()
+	now := time.Now()
+
+	p := &models.PortfolioItem{
+		MarketHashName: purchase.MarketHashName,
+		Float:          purchase.Float,
+		BuyPriceUSD:    purchase.BuyPriceUSD,
+		BuyPriceIRR:    purchase.BuyPriceIRR,
+		BuyRateIRR:     rate,
+		PurchasedAt:    now,
+		TradeHoldUntil: purchase.TradeHoldUntil,
+		Notes:          purchase.Notes,
+	}
+	if purchase.PurchasedAt != nil {
+		p.PurchasedAt = *purchase.PurchasedAt
+	}
+
+	if purchase.ItemID != "" {
+		listing, err := db.GetListingForPurchase(purchase.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		if listing == nil {
+			return nil, fmt.Errorf("%w: listing %s not found", ErrInvalid, purchase.ItemID)
+		}
+
+		p.ItemID = &listing.ItemID
+		p.SkinID = &listing.SkinID
+		p.MarketHashName = listing.MarketHashName
+		if p.Float == nil {
+			p.Float = listing.Float
+		}
+		if p.BuyPriceUSD == 0 && p.BuyPriceIRR == 0 {
+			p.BuyPriceUSD = listing.PriceUSD
+			p.BuyPriceIRR = listing.PriceIRR
+		}
+		if p.TradeHoldUntil == nil {
+			p.TradeHoldUntil = listing.TradeHoldUntil
+		}
+	}
+
+	if p.MarketHashName == "" {
+		return nil, fmt.Errorf("%w: market_hash_name or item_id is required", ErrInvalid)
+	}
+	if p.SkinID == nil {
+		// Link manual entries to their skin when the name is known
+		skinIDs, err := db.GetSkinIDs([]string{p.MarketHashName})
+		if err != nil {
+			return nil, err
+		}
+		if skinID, ok := skinIDs[p.MarketHashName]; ok {
+			p.SkinID = &skinID
+		}
+	}
+	if p.BuyPriceUSD <= 0 && p.BuyPriceIRR <= 0 {
+		return nil, fmt.Errorf("%w: buy_price_usd or buy_price_irr is required", ErrInvalid)
+	}
+	if p.BuyPriceUSD <= 0 {
+		p.BuyPriceUSD = p.BuyPriceIRR / rate
+	}
+	if p.BuyPriceIRR <= 0 {
+		p.BuyPriceIRR = p.BuyPriceUSD * rate
+	}
+
+	p.Status = models.PortfolioHeld
+	if p.TradeHoldUntil != nil && p.TradeHoldUntil.After(now) {
+		p.Status = models.PortfolioTradeHold
+	}
+
+	id, err := db.InsertPortfolioItem(p)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = id
+	return p, nil
+}
+
+// MarkListed records that an item was listed for sale at priceUSD
+func MarkListed(db Store, id string, priceUSD float64) (*models.PortfolioItem, error) {
+	if priceUSD <= 0 {
+		return nil, fmt.Errorf("%w: price_usd must be positive", ErrInvalid)
+	}
+
+	p, err := load(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == models.PortfolioSold {
+		return nil, fmt.Errorf("%w: item is already sold", ErrInvalid)
+	}
+	if p.Status == models.PortfolioTradeHold {
+		return nil, fmt.Errorf("%w: item is trade-locked until %s", ErrInvalid, p.TradeHoldUntil.Format(time.RFC3339))
+	}
+
+	now := time.Now()
+	p.Status = models.PortfolioListed
+	p.ListPriceUSD = &priceUSD
+	p.ListedAt = &now
+
+	if err := update(db, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// MarkSold records a sale. A nil fee defaults to the Steam sale fee.
+func MarkSold(db Store, id string, salePriceUSD float64, saleFeeUSD *float64) (*models.PortfolioItem, error) {
+	if salePriceUSD <= 0 {
+		return nil, fmt.Errorf("%w: sale_price_usd must be positive", ErrInvalid)
+	}
+	if saleFeeUSD != nil && *saleFeeUSD < 0 {
+		return nil, fmt.Errorf("%w: sale_fee_usd must not be negative", ErrInvalid)
+	}
+
+	p, err := load(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == models.PortfolioSold {
+		return nil, fmt.Errorf("%w: item is already sold", ErrInvalid)
+	}
+
+	if saleFeeUSD == nil {
+		fee := salePriceUSD * arbitrage.SteamSaleFeePercent() / 100
+		saleFeeUSD = &fee
+	}
+	now := time.Now()
+	rate := usdToIRRRate()
+
+	p.Status = models.PortfolioSold
+	p.SalePriceUSD = &salePriceUSD
+	p.SaleFeeUSD = saleFeeUSD
+	p.SaleRateIRR = &rate
+	p.SoldAt = &now
+
+	if err := update(db, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Items returns the portfolio with trade holds that have ended moved to held
+func Items(db Store, status string) ([]models.PortfolioItem, error) {
+	items, err := db.GetPortfolioItems("")
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []models.PortfolioItem
+	for _, p := range items {
+		settleTradeHold(&p)
+		if status == "" || p.Status == status {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered, nil
+}
+
+// load fetches an item, settling an ended trade hold
+func load(db Store, id string) (*models.PortfolioItem, error) {
+	p, err := db.GetPortfolioItem(id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrNotFound
+	}
+	settleTradeHold(p)
+	return p, nil
+}
+
+// update saves an item, reporting one deleted since it was loaded as
+// ErrNotFound
+func update(db Store, p *models.PortfolioItem) error {
+	err := db.UpdatePortfolioItem(p)
+	if errors.Is(err, database.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// settleTradeHold moves an item whose trade hold ended to held
+func settleTradeHold(p *models.PortfolioItem) {
+	if p.Status == models.PortfolioTradeHold && (p.TradeHoldUntil == nil || !p.TradeHoldUntil.After(time.Now())) {
+		p.Status = models.PortfolioHeld
+	}
+}
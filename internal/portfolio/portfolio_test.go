@@ -0,0 +1,249 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// fakeStore keeps the portfolio in memory
+type fakeStore struct {
+	items    map[string]*models.PortfolioItem
+	listings map[string]*database.ListingForPurchase
+	skinIDs  map[string]string
+	prices   map[string]float64
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		items:    make(map[string]*models.PortfolioItem),
+		listings: make(map[string]*database.ListingForPurchase),
+		skinIDs:  make(map[string]string),
+		prices:   make(map[string]float64),
+	}
+}
+
+func (s *fakeStore) InsertPortfolioItem(p *models.PortfolioItem) (string, error) {
+	id := fmt.Sprintf("p%d", len(s.items)+1)
+	stored := *p
+	stored.ID = id
+	s.items[id] = &stored
+	return id, nil
+}
+
+func (s *fakeStore) UpdatePortfolioItem(p *models.PortfolioItem) error {
+	if _, ok := s.items[p.ID]; !ok {
+		return fmt.Errorf("portfolio item %s %w", p.ID, database.ErrNotFound)
+	}
+	stored := *p
+	s.items[p.ID] = &stored
+	return nil
+}
+
+func (s *fakeStore) GetPortfolioItem(id string) (*models.PortfolioItem, error) {
+	p, ok := s.items[id]
+	if !ok {
+		return nil, nil
+	}
+	loaded := *p
+	return &loaded, nil
+}
+
+func (s *fakeStore) GetPortfolioItems(status string) ([]models.PortfolioItem, error) {
+	var items []models.PortfolioItem
+	for _, p := range s.items {
+		if status == "" || p.Status == status {
+			items = append(items, *p)
+		}
+	}
+	return items, nil
+}
+
+func (s *fakeStore) GetListingForPurchase(itemID string) (*database.ListingForPurchase, error) {
+	return s.listings[itemID], nil
+}
+
+func (s *fakeStore) GetSkinIDs(marketHashNames []string) (map[string]string, error) {
+	ids := make(map[string]string)
+	for _, name := range marketHashNames {
+		if id, ok := s.skinIDs[name]; ok {
+			ids[name] = id
+		}
+	}
+	return ids, nil
+}
+
+func (s *fakeStore) GetReferencePrices(marketHashNames []string) (map[string]float64, error) {
+	return s.prices, nil
+}
+
+// fixedRate pins the exchange rate for the duration of a test
+func fixedRate(t *testing.T, rate float64) {
+	t.Helper()
+	saved := usdToIRRRate
+	usdToIRRRate = func() float64 { return rate }
+	t.Cleanup(func() { usdToIRRRate = saved })
+}
+
+func TestRecordPurchase(t *testing.T) {
+	fixedRate(t, 1000)
+
+	listingFloat := 0.15
+	future := time.Now().Add(48 * time.Hour)
+	db := newFakeStore()
+	db.listings["listing-1"] = &database.ListingForPurchase{
+		ItemID: "listing-1", SkinID: "skin-ak", MarketHashName: "AK-47 | Redline (Field-Tested)",
+		Float: &listingFloat, PriceUSD: 10, PriceIRR: 10500, TradeHoldUntil: &future,
+	}
+	db.skinIDs["AWP | Asiimov (Field-Tested)"] = "skin-awp"
+
+	t.Run("from a listing", func(t *testing.T) {
+		p, err := RecordPurchase(db, Purchase{ItemID: "listing-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.SkinID == nil || *p.SkinID != "skin-ak" || p.MarketHashName != "AK-47 | Redline (Field-Tested)" {
+			t.Errorf("skin, name = %v, %q", p.SkinID, p.MarketHashName)
+		}
+		if p.BuyPriceUSD != 10 || p.BuyPriceIRR != 10500 || p.Float == nil || *p.Float != listingFloat {
+			t.Errorf("prices, float = %v, %v, %v", p.BuyPriceUSD, p.BuyPriceIRR, p.Float)
+		}
+		if p.Status != models.PortfolioTradeHold {
+			t.Errorf("status = %q, want %q", p.Status, models.PortfolioTradeHold)
+		}
+		if db.items[p.ID] == nil {
+			t.Error("purchase wasn't stored")
+		}
+	})
+
+	t.Run("manual entry resolves its skin", func(t *testing.T) {
+		p, err := RecordPurchase(db, Purchase{MarketHashName: "AWP | Asiimov (Field-Tested)", BuyPriceIRR: 50000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.SkinID == nil || *p.SkinID != "skin-awp" {
+			t.Errorf("skin = %v, want skin-awp", p.SkinID)
+		}
+		if p.BuyPriceUSD != 50 || p.Status != models.PortfolioHeld {
+			t.Errorf("price, status = %v, %q, want 50, %q", p.BuyPriceUSD, p.Status, models.PortfolioHeld)
+		}
+	})
+
+	t.Run("manual entry of an unknown skin", func(t *testing.T) {
+		p, err := RecordPurchase(db, Purchase{MarketHashName: "Mystery Item", BuyPriceUSD: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.SkinID != nil || p.BuyPriceIRR != 2000 {
+			t.Errorf("skin, IRR price = %v, %v, want nil, 2000", p.SkinID, p.BuyPriceIRR)
+		}
+	})
+
+	invalid := []Purchase{
+		{ItemID: "missing"},
+		{BuyPriceUSD: 5},
+		{MarketHashName: "AWP | Asiimov (Field-Tested)"},
+	}
+	for _, purchase := range invalid {
+		if _, err := RecordPurchase(db, purchase); !errors.Is(err, ErrInvalid) {
+			t.Errorf("RecordPurchase(%+v) error = %v, want ErrInvalid", purchase, err)
+		}
+	}
+}
+
+func TestMarkSold(t *testing.T) {
+	fixedRate(t, 1000)
+	t.Setenv("STEAM_SALE_FEE_PERCENT", "15")
+
+	db := newFakeStore()
+	held, _ := RecordPurchase(db, Purchase{MarketHashName: "Item", BuyPriceUSD: 10})
+
+	p, err := MarkSold(db, held.ID, 20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != models.PortfolioSold || p.SaleFeeUSD == nil || *p.SaleFeeUSD != 3 || p.SaleRateIRR == nil || *p.SaleRateIRR != 1000 {
+		t.Errorf("sold item = %+v", p)
+	}
+	if db.items[held.ID].Status != models.PortfolioSold {
+		t.Error("sale wasn't stored")
+	}
+
+	if _, err := MarkSold(db, held.ID, 20, nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("selling twice: error = %v, want ErrInvalid", err)
+	}
+	if _, err := MarkSold(db, "missing", 20, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("selling a missing item: error = %v, want ErrNotFound", err)
+	}
+	negative := -1.0
+	if _, err := MarkSold(db, held.ID, 20, &negative); !errors.Is(err, ErrInvalid) {
+		t.Errorf("negative fee: error = %v, want ErrInvalid", err)
+	}
+
+	other, _ := RecordPurchase(db, Purchase{MarketHashName: "Item", BuyPriceUSD: 10})
+	fee := 1.0
+	if p, err := MarkSold(db, other.ID, 20, &fee); err != nil || *p.SaleFeeUSD != 1 {
+		t.Errorf("sale with a fee = %+v, %v", p, err)
+	}
+}
+
+func TestUpdateOfDeletedItem(t *testing.T) {
+	db := newFakeStore()
+	if err := update(db, &models.PortfolioItem{ID: "gone"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	fixedRate(t, 1000)
+	t.Setenv("STEAM_SALE_FEE_PERCENT", "10")
+
+	db := newFakeStore()
+	db.prices["Held"] = 20
+
+	salePrice, saleFee, saleRate := 30.0, 3.0, 900.0
+	listPrice := 40.0
+	items := []models.PortfolioItem{
+		{MarketHashName: "Sold", Status: models.PortfolioSold, BuyPriceUSD: 20, BuyPriceIRR: 18000,
+			SalePriceUSD: &salePrice, SaleFeeUSD: &saleFee, SaleRateIRR: &saleRate},
+		{MarketHashName: "Held", Status: models.PortfolioHeld, BuyPriceUSD: 10, BuyPriceIRR: 10000},
+		{MarketHashName: "Held", Status: models.PortfolioListed, BuyPriceUSD: 10, BuyPriceIRR: 10000, ListPriceUSD: &listPrice},
+		{MarketHashName: "Unpriced", Status: models.PortfolioHeld, BuyPriceUSD: 5, BuyPriceIRR: 5000},
+	}
+
+	summary, err := Summarize(db, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"realized profit", summary.RealizedProfitUSD, 7}, // 30 - 3 - 20
+		{"realized profit IRR", summary.RealizedProfitIRR, 27*900 - 18000},
+		{"invested", summary.InvestedUSD, 25},
+		{"invested IRR", summary.InvestedIRR, 25000},
+		{"unrealized value", summary.UnrealizedValueUSD, 18 + 36},  // At the reference and asking price, less 10%
+		{"unrealized profit", summary.UnrealizedProfitUSD, 8 + 26}, // Unpriced items add nothing
+		{"held profit", summary.Items[1].ProfitUSD, 8},
+		{"listed profit", summary.Items[2].ProfitUSD, 26},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	if summary.Count != 4 || summary.UnpricedCount != 1 || summary.CountByStatus[models.PortfolioHeld] != 2 {
+		t.Errorf("count %d, unpriced %d, by status %v", summary.Count, summary.UnpricedCount, summary.CountByStatus)
+	}
+	if !summary.Items[0].Realized || summary.Items[1].Realized {
+		t.Error("only the sold item is realized")
+	}
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// clock is a settable time source
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter() (*Limiter, *clock) {
+	c := &clock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewLimiter()
+	l.now = c.now
+	return l, c
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		spec  string
+		want  Limit
+		valid bool
+	}{
+		{"30/1m", Limit{30, time.Minute}, true},
+		{" 2 / 10m ", Limit{2, 10 * time.Minute}, true},
+		{"1/1h30m", Limit{1, 90 * time.Minute}, true},
+		{"30", Limit{}, false},
+		{"0/1m", Limit{}, false},
+		{"-1/1m", Limit{}, false},
+		{"x/1m", Limit{}, false},
+		{"30/soon", Limit{}, false},
+		{"30/0s", Limit{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.spec)
+		if (err == nil) != tt.valid || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %v, %v, want %v (valid %v)", tt.spec, got, err, tt.want, tt.valid)
+		}
+	}
+
+	if s := (Limit{30, time.Minute}).String(); s != "30/1m0s" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+func TestAllowBurstAndRefill(t *testing.T) {
+	l, c := newTestLimiter()
+	limit := Limit{Requests: 3, Period: time.Minute} // A token every 20s
+
+	for i := 2; i >= 0; i-- {
+		r := l.Allow("a", limit)
+		if !r.Allowed || r.Remaining != i || r.Limit != 3 {
+			t.Fatalf("burst request = %+v, want allowed with %d remaining", r, i)
+		}
+	}
+
+	r := l.Allow("a", limit)
+	if r.Allowed || r.RetryAfter != 20*time.Second {
+		t.Fatalf("over the burst = %+v, want retry after 20s", r)
+	}
+
+	// Part way to the next token
+	c.advance(5 * time.Second)
+	if r := l.Allow("a", limit); r.Allowed || r.RetryAfter != 15*time.Second {
+		t.Errorf("after 5s = %+v, want retry after 15s", r)
+	}
+
+	c.advance(15 * time.Second)
+	if r := l.Allow("a", limit); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("after 20s = %+v, want one allowed", r)
+	}
+
+	// Other keys have their own bucket
+	if r := l.Allow("b", limit); !r.Allowed || r.Remaining != 2 {
+		t.Errorf("other key = %+v, want a full bucket", r)
+	}
+
+	// Refilling stops at the burst size
+	c.advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		l.Allow("a", limit)
+	}
+	if r := l.Allow("a", limit); r.Allowed {
+		t.Errorf("bucket held more than its burst: %+v", r)
+	}
+}
+
+func TestForgetIdle(t *testing.T) {
+	l, c := newTestLimiter()
+	limit := Limit{Requests: 1, Period: time.Hour}
+
+	l.Allow("old", limit)
+	c.advance(30 * time.Minute)
+	l.Allow("recent", limit)
+	c.advance(31 * time.Minute)
+
+	l.forgetIdle(time.Hour)
+	if _, ok := l.buckets["old"]; ok {
+		t.Error("idle bucket was kept")
+	}
+	if _, ok := l.buckets["recent"]; !ok {
+		t.Error("recent bucket was forgotten")
+	}
+}
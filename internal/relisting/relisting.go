@@ -0,0 +1,59 @@
+package relisting
+
+import (
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+)
+
+// Summary describes how long a physical item has been on the market and how
+// its seller has repriced it across listings
+type Summary struct {
+	DaysOnMarket       float64 `json:"days_on_market"`
+	Listings           int     `json:"listings"`
+	Relistings         int     `json:"relistings"`
+	PriceChanges       int     `json:"price_changes"`
+	PriceCuts          int     `json:"price_cuts"`
+	FirstPrice         float64 `json:"first_price"` // Marketplace currency
+	LatestPrice        float64 `json:"latest_price"`
+	PriceChangePercent float64 `json:"price_change_percent"`
+	RelistedWithDrop   bool    `json:"relisted_with_drop"` // A relisting came in cheaper than the listing before it
+	LastListingActive  bool    `json:"last_listing_active"`
+}
+
+// Summarize works out a physical item's time on market and repricing from
+// its listings and price history. A listing counts as active when it was
+// scraped within the last day.
+func Summarize(rec *database.PhysicalItemRecord, now time.Time) Summary {
+	s := Summary{
+		Listings:     len(rec.Listings),
+		DaysOnMarket: rec.LastSeenAt.Sub(rec.FirstSeenAt).Hours() / 24,
+	}
+	if s.Listings > 1 {
+		s.Relistings = s.Listings - 1
+	}
+	if s.Listings > 0 {
+		last := rec.Listings[len(rec.Listings)-1]
+		s.LastListingActive = now.Sub(last.LastSeenAt) < 24*time.Hour
+	}
+
+	for i, p := range rec.History {
+		if i == 0 {
+			s.FirstPrice = p.Price
+		} else if prev := rec.History[i-1]; p.Price != prev.Price {
+			s.PriceChanges++
+			if p.Price < prev.Price {
+				s.PriceCuts++
+				if p.MarketItemID != prev.MarketItemID {
+					s.RelistedWithDrop = true
+				}
+			}
+		}
+		s.LatestPrice = p.Price
+	}
+	if s.FirstPrice > 0 {
+		s.PriceChangePercent = (s.LatestPrice - s.FirstPrice) / s.FirstPrice * 100
+	}
+
+	return s
+}
@@ -0,0 +1,90 @@
+package relisting
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+)
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name string
+		rec  database.PhysicalItemRecord
+		want Summary
+	}{
+		{
+			name: "single listing",
+			rec: database.PhysicalItemRecord{
+				FirstSeenAt: now.Add(-2 * day),
+				LastSeenAt:  now,
+				Listings:    []database.PhysicalListing{{MarketItemID: "1", LastSeenAt: now.Add(-time.Hour)}},
+				History:     []database.PricePoint{{MarketItemID: "1", Price: 100}, {MarketItemID: "1", Price: 100}},
+			},
+			want: Summary{DaysOnMarket: 2, Listings: 1, FirstPrice: 100, LatestPrice: 100, LastListingActive: true},
+		},
+		{
+			name: "relisted cheaper",
+			rec: database.PhysicalItemRecord{
+				FirstSeenAt: now.Add(-10 * day),
+				LastSeenAt:  now.Add(-3 * day),
+				Listings: []database.PhysicalListing{
+					{MarketItemID: "1", LastSeenAt: now.Add(-6 * day)},
+					{MarketItemID: "2", LastSeenAt: now.Add(-3 * day)},
+				},
+				History: []database.PricePoint{
+					{MarketItemID: "1", Price: 100},
+					{MarketItemID: "1", Price: 120}, // Raised
+					{MarketItemID: "2", Price: 90},  // Relisted cheaper
+					{MarketItemID: "2", Price: 80},
+				},
+			},
+			want: Summary{
+				DaysOnMarket: 7, Listings: 2, Relistings: 1, PriceChanges: 3, PriceCuts: 2,
+				FirstPrice: 100, LatestPrice: 80, PriceChangePercent: -20, RelistedWithDrop: true,
+			},
+		},
+		{
+			name: "cut within one listing",
+			rec: database.PhysicalItemRecord{
+				FirstSeenAt: now.Add(-day),
+				LastSeenAt:  now,
+				Listings: []database.PhysicalListing{
+					{MarketItemID: "1", LastSeenAt: now.Add(-day)},
+					{MarketItemID: "2", LastSeenAt: now},
+				},
+				History: []database.PricePoint{
+					{MarketItemID: "1", Price: 100},
+					{MarketItemID: "2", Price: 100},
+					{MarketItemID: "2", Price: 50},
+				},
+			},
+			want: Summary{
+				DaysOnMarket: 1, Listings: 2, Relistings: 1, PriceChanges: 1, PriceCuts: 1,
+				FirstPrice: 100, LatestPrice: 50, PriceChangePercent: -50, LastListingActive: true,
+			},
+		},
+		{
+			name: "no history",
+			rec:  database.PhysicalItemRecord{FirstSeenAt: now, LastSeenAt: now},
+			want: Summary{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Summarize(&tt.rec, now)
+			if math.Abs(got.DaysOnMarket-tt.want.DaysOnMarket) > 1e-9 || math.Abs(got.PriceChangePercent-tt.want.PriceChangePercent) > 1e-9 {
+				t.Errorf("days on market %v, change %v%%, want %v, %v%%", got.DaysOnMarket, got.PriceChangePercent, tt.want.DaysOnMarket, tt.want.PriceChangePercent)
+			}
+			got.DaysOnMarket, got.PriceChangePercent = tt.want.DaysOnMarket, tt.want.PriceChangePercent
+			if got != tt.want {
+				t.Errorf("Summarize = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package router
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// patternKey is the user value holding the pattern of the matched route
+const patternKey = "router.pattern"
+
+// paramKeyPrefix starts the user value keys of path parameters, so a
+// parameter can't overwrite the pattern or values set by middleware
+const paramKeyPrefix = "router.param."
+
+// Middleware wraps a handler with behaviour run around it
+type Middleware func(fasthttp.RequestHandler) fasthttp.RequestHandler
+
+// Kinds of pattern segment, from most to least specific
+const (
+	segmentLiteral = iota
+	segmentParam
+	segmentCatchAll
+)
+
+// segment is one "/"-separated part of a route pattern
+type segment struct {
+	kind  int
+	value string // Literal text, or the parameter name
+	// valid restricts the values a {name:type} parameter matches; nil
+	// matches any
+	valid func(string) bool
+}
+
+// paramTypes are the types a {name:type} parameter can be restricted to
+var paramTypes = map[string]func(string) bool{
+	"uuid": isUUID,
+}
+
+// isUUID reports whether s is a UUID in its canonical hyphenated form
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// route is a registered method and pattern with its wrapped handler
+type route struct {
+	method   string
+	pattern  string
+	segments []segment
+	handler  fasthttp.RequestHandler
+}
+
+// Router dispatches requests by method and path pattern. Patterns are made of
+// literal segments, {name} segments matching any single segment and a final
+// {name...} segment matching the rest of the path. A parameter can be
+// restricted to a type, as in {id:uuid}, so other values don't match the
+// route at all. Where several patterns match, the most specific wins, literal
+// segments beating parameters.
+type Router struct {
+	root       *Group
+	routes     []*route
+	middleware []Middleware
+
+	// NotFound handles requests no pattern matches
+	NotFound fasthttp.RequestHandler
+	// MethodNotAllowed handles requests whose path matches but whose method
+	// doesn't; the Allow header is already set when it runs
+	MethodNotAllowed fasthttp.RequestHandler
+}
+
+// New creates a router answering unmatched requests with the given handlers,
+// so they get the same error responses as the routes
+func New(notFound, methodNotAllowed fasthttp.RequestHandler) *Router {
+	r := &Router{NotFound: notFound, MethodNotAllowed: methodNotAllowed}
+	r.root = &Group{router: r}
+	return r
+}
+
+// Use adds middleware run on every request, including those answered by
+// NotFound and MethodNotAllowed
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Group returns a group of routes under a path prefix sharing middleware
+func (r *Router) Group(prefix string, middleware ...Middleware) *Group {
+	return r.root.Group(prefix, middleware...)
+}
+
+// Handle registers a handler for a method and pattern
+func (r *Router) Handle(method, pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	r.root.Handle(method, pattern, handler, middleware...)
+}
+
+// GET registers a handler for GET requests, which also serves HEAD
+func (r *Router) GET(pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	r.root.GET(pattern, handler, middleware...)
+}
+
+// POST registers a handler for POST requests
+func (r *Router) POST(pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	r.root.POST(pattern, handler, middleware...)
+}
+
+// PUT registers a handler for PUT requests
+func (r *Router) PUT(pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	r.root.PUT(pattern, handler, middleware...)
+}
+
+// DELETE registers a handler for DELETE requests
+func (r *Router) DELETE(pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	r.root.DELETE(pattern, handler, middleware...)
+}
+
+// Handler returns the request handler dispatching to the registered routes,
+// wrapped in the router's middleware
+func (r *Router) Handler() fasthttp.RequestHandler {
+	return chain(r.serve, r.middleware)
+}
+
+// serve dispatches a request to the most specific matching route
+func (r *Router) serve(ctx *fasthttp.RequestCtx) {
+	method := string(ctx.Method())
+	parts := splitPath(string(ctx.Path()))
+
+	var best, bestGet *route
+	var bestParams, bestGetParams map[string]string
+	allowed := map[string]bool{}
+	for _, rt := range r.routes {
+		params, ok := rt.match(parts)
+		if !ok {
+			continue
+		}
+		allowed[rt.method] = true
+		switch rt.method {
+		case method:
+			if best == nil || rt.moreSpecific(best) {
+				best, bestParams = rt, params
+			}
+		case fasthttp.MethodGet:
+			if bestGet == nil || rt.moreSpecific(bestGet) {
+				bestGet, bestGetParams = rt, params
+			}
+		}
+	}
+
+	// GET routes answer HEAD requests unless a HEAD route exists
+	if best == nil && method == fasthttp.MethodHead && bestGet != nil {
+		best, bestParams = bestGet, bestGetParams
+	}
+
+	if best != nil {
+		ctx.SetUserValue(patternKey, best.pattern)
+		for name, value := range bestParams {
+			ctx.SetUserValue(paramKeyPrefix+name, value)
+		}
+		best.handler(ctx)
+		return
+	}
+
+	if len(allowed) == 0 {
+		r.NotFound(ctx)
+		return
+	}
+
+	if allowed[fasthttp.MethodGet] {
+		allowed[fasthttp.MethodHead] = true
+	}
+	methods := make([]string, 0, len(allowed)+1)
+	for m := range allowed {
+		methods = append(methods, m)
+	}
+	methods = append(methods, fasthttp.MethodOptions)
+	sort.Strings(methods)
+	ctx.Response.Header.Set("Allow", strings.Join(methods, ", "))
+
+	if method == fasthttp.MethodOptions {
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+		return
+	}
+	r.MethodNotAllowed(ctx)
+}
+
+// Param returns the value of a path parameter of the matched route, or an
+// empty string when the route has no such parameter
+func Param(ctx *fasthttp.RequestCtx, name string) string {
+	value, _ := ctx.UserValue(paramKeyPrefix + name).(string)
+	return value
+}
+
+// Pattern returns the pattern of the route a request matched, such as
+// "/api/skins/{id}", or an empty string when none matched
+func Pattern(ctx *fasthttp.RequestCtx) string {
+	pattern, _ := ctx.UserValue(patternKey).(string)
+	return pattern
+}
+
+// Group registers routes under a shared path prefix and middleware
+type Group struct {
+	router     *Router
+	prefix     string
+	middleware []Middleware
+}
+
+// Use adds middleware to the routes registered on the group afterwards
+func (g *Group) Use(middleware ...Middleware) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// Group returns a nested group whose routes run this group's middleware
+// before their own
+func (g *Group) Group(prefix string, middleware ...Middleware) *Group {
+	combined := make([]Middleware, 0, len(g.middleware)+len(middleware))
+	combined = append(combined, g.middleware...)
+	combined = append(combined, middleware...)
+	return &Group{router: g.router, prefix: g.prefix + prefix, middleware: combined}
+}
+
+// Handle registers a handler for a method and a pattern relative to the
+// group prefix. The group's middleware runs first, outermost, then the
+// route's own in the order given.
+func (g *Group) Handle(method, pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	full := g.prefix + pattern
+	if full == "" {
+		full = "/"
+	}
+
+	combined := make([]Middleware, 0, len(g.middleware)+len(middleware))
+	combined = append(combined, g.middleware...)
+	combined = append(combined, middleware...)
+
+	g.router.routes = append(g.router.routes, &route{
+		method:   method,
+		pattern:  full,
+		segments: parsePattern(full),
+		handler:  chain(handler, combined),
+	})
+}
+
+// GET registers a handler for GET requests, which also serves HEAD
+func (g *Group) GET(pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	g.Handle(fasthttp.MethodGet, pattern, handler, middleware...)
+}
+
+// POST registers a handler for POST requests
+func (g *Group) POST(pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	g.Handle(fasthttp.MethodPost, pattern, handler, middleware...)
+}
+
+// PUT registers a handler for PUT requests
+func (g *Group) PUT(pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	g.Handle(fasthttp.MethodPut, pattern, handler, middleware...)
+}
+
+// DELETE registers a handler for DELETE requests
+func (g *Group) DELETE(pattern string, handler fasthttp.RequestHandler, middleware ...Middleware) {
+	g.Handle(fasthttp.MethodDelete, pattern, handler, middleware...)
+}
+
+// chain wraps a handler in middleware so the first one given runs first
+func chain(handler fasthttp.RequestHandler, middleware []Middleware) fasthttp.RequestHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// splitPath splits a request path into its segments, ignoring a trailing
+// slash
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// parsePattern splits a route pattern into typed segments
+func parsePattern(pattern string) []segment {
+	parts := splitPath(pattern)
+	segments := make([]segment, len(parts))
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "...}"):
+			if i != len(parts)-1 {
+				panic("router: catch-all parameter must end the pattern " + pattern)
+			}
+			segments[i] = segment{kind: segmentCatchAll, value: part[1 : len(part)-4]}
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			name, typ, typed := strings.Cut(part[1:len(part)-1], ":")
+			seg := segment{kind: segmentParam, value: name}
+			if typed {
+				if seg.valid = paramTypes[typ]; seg.valid == nil {
+					panic("router: unknown parameter type " + typ + " in pattern " + pattern)
+				}
+			}
+			segments[i] = seg
+		default:
+			segments[i] = segment{kind: segmentLiteral, value: part}
+		}
+	}
+	return segments
+}
+
+// match reports whether the route's pattern matches the path segments,
+// returning the path parameters
+func (rt *route) match(parts []string) (map[string]string, bool) {
+	var params map[string]string
+	for i, seg := range rt.segments {
+		if seg.kind == segmentCatchAll {
+			if i >= len(parts) {
+				return nil, false
+			}
+			if params == nil {
+				params = map[string]string{}
+			}
+			params[seg.value] = strings.Join(parts[i:], "/")
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch seg.kind {
+		case segmentLiteral:
+			if parts[i] != seg.value {
+				return nil, false
+			}
+		case segmentParam:
+			if seg.valid != nil && !seg.valid(parts[i]) {
+				return nil, false
+			}
+			if params == nil {
+				params = map[string]string{}
+			}
+			params[seg.value] = parts[i]
+		}
+	}
+	return params, len(parts) == len(rt.segments)
+}
+
+// moreSpecific reports whether the route's pattern is more specific than
+// another matching the same path, comparing segment kinds left to right
+func (rt *route) moreSpecific(other *route) bool {
+	for i := 0; i < len(rt.segments) && i < len(other.segments); i++ {
+		if rt.segments[i].kind != other.segments[i].kind {
+			return rt.segments[i].kind < other.segments[i].kind
+		}
+	}
+	return len(rt.segments) > len(other.segments)
+}
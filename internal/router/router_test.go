@@ -0,0 +1,192 @@
+package router
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// named returns a handler writing its name and the given path parameters
+func named(name string, params ...string) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		body := name
+		for _, p := range params {
+			body += " " + p + "=" + Param(ctx, p)
+		}
+		ctx.SetBodyString(body)
+	}
+}
+
+// newRouter creates a router whose 404 and 405 handlers write plain text
+func newRouter() *Router {
+	return New(
+		func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(fasthttp.StatusNotFound)
+			ctx.SetBodyString("nothing here")
+		},
+		func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+			ctx.SetBodyString("use " + string(ctx.Response.Header.Peek("Allow")))
+		},
+	)
+}
+
+// serve sends a request through a handler
+func serve(handler fasthttp.RequestHandler, method, path string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(path)
+	handler(ctx)
+	return ctx
+}
+
+func TestRouting(t *testing.T) {
+	r := newRouter()
+	r.GET("/", named("index"))
+	r.GET("/skins", named("list"))
+	r.POST("/skins", named("create"))
+	r.GET("/skins/{id}", named("skin", "id"))
+	r.GET("/skins/new", named("new"))
+	r.GET("/skins/{id}/prices", named("prices", "id"))
+	r.DELETE("/skins/{id}", named("delete", "id"))
+	r.GET("/static/{path...}", named("static", "path"))
+	r.GET("/static/app.js", named("app"))
+	r.GET("/{section}/about", named("about", "section"))
+	handler := r.Handler()
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{"GET", "/", "index"},
+		{"GET", "/skins", "list"},
+		{"GET", "/skins/", "list"}, // Trailing slash
+		{"POST", "/skins", "create"},
+		{"GET", "/skins/42", "skin id=42"},
+		{"DELETE", "/skins/42", "delete id=42"},
+		{"GET", "/skins/42/prices", "prices id=42"},
+		{"HEAD", "/skins/42", "skin id=42"},
+
+		// Literal segments beat parameters, and parameters catch-alls
+		{"GET", "/skins/new", "new"},
+		{"GET", "/static/app.js", "app"},
+		{"GET", "/static/css/site.css", "static path=css/site.css"},
+		{"GET", "/faq/about", "about section=faq"},
+		{"GET", "/skins/about", "skin id=about"}, // Compared left to right
+	}
+
+	for _, tt := range tests {
+		ctx := serve(handler, tt.method, tt.path)
+		if got := string(ctx.Response.Body()); ctx.Response.StatusCode() != 200 || got != tt.want {
+			t.Errorf("%s %s = %d %q, want %q", tt.method, tt.path, ctx.Response.StatusCode(), got, tt.want)
+		}
+	}
+
+	// A catch-all needs at least one segment
+	if ctx := serve(handler, "GET", "/static"); ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Errorf("GET /static = %d, want 404", ctx.Response.StatusCode())
+	}
+}
+
+func TestNotFoundAndMethodNotAllowed(t *testing.T) {
+	r := newRouter()
+	r.GET("/skins/{id}", named("skin"))
+	r.DELETE("/skins/{id}", named("delete"))
+	handler := r.Handler()
+
+	for _, path := range []string{"/missing", "/skins", "/skins/1/extra"} {
+		ctx := serve(handler, "GET", path)
+		if ctx.Response.StatusCode() != fasthttp.StatusNotFound || string(ctx.Response.Body()) != "nothing here" {
+			t.Errorf("GET %s = %d %s, want the 404 handler", path, ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+	}
+
+	// The 405 handler runs with the Allow header already set
+	ctx := serve(handler, "POST", "/skins/1")
+	if ctx.Response.StatusCode() != fasthttp.StatusMethodNotAllowed || string(ctx.Response.Body()) != "use DELETE, GET, HEAD, OPTIONS" {
+		t.Errorf("POST = %d %s, want the 405 handler", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	if allow := string(ctx.Response.Header.Peek("Allow")); allow != "DELETE, GET, HEAD, OPTIONS" {
+		t.Errorf("Allow = %q", allow)
+	}
+
+	ctx = serve(handler, "OPTIONS", "/skins/1")
+	if ctx.Response.StatusCode() != fasthttp.StatusNoContent || ctx.Response.Header.Peek("Allow") == nil {
+		t.Errorf("OPTIONS = %d, Allow %q, want 204 with Allow", ctx.Response.StatusCode(), ctx.Response.Header.Peek("Allow"))
+	}
+}
+
+func TestTypedParams(t *testing.T) {
+	r := newRouter()
+	r.GET("/keys/{id:uuid}", named("key", "id"))
+	r.DELETE("/keys/{id:uuid}", named("revoke", "id"))
+	handler := r.Handler()
+
+	const id = "0b5e3c1a-9f2d-4e6b-8a7c-1d2e3f4a5b6C"
+	if ctx := serve(handler, "GET", "/keys/"+id); string(ctx.Response.Body()) != "key id="+id {
+		t.Errorf("GET a UUID = %d %q", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	for _, bad := range []string{"abc", "1", strings.ReplaceAll(id, "-", ""), "0b5e3c1a-9f2d-4e6b-8a7c-1d2e3f4a5b6g"} {
+		if ctx := serve(handler, "DELETE", "/keys/"+bad); ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+			t.Errorf("DELETE /keys/%s = %d, want 404", bad, ctx.Response.StatusCode())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("an unknown parameter type didn't panic")
+		}
+	}()
+	r.GET("/keys/{id:int}", named("int"))
+}
+
+func TestParamsDontOverwriteUserValues(t *testing.T) {
+	r := newRouter()
+	r.Use(func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ctx.SetUserValue("request_id", "req-1")
+			next(ctx)
+		}
+	})
+	r.GET("/{request_id}/{router.pattern}", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyString(ctx.UserValue("request_id").(string) + " " + Pattern(ctx) + " " +
+			Param(ctx, "request_id") + " " + Param(ctx, "router.pattern"))
+	})
+
+	ctx := serve(r.Handler(), "GET", "/a/b")
+	if got := string(ctx.Response.Body()); got != "req-1 /{request_id}/{router.pattern} a b" {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+			return func(ctx *fasthttp.RequestCtx) {
+				order = append(order, name)
+				next(ctx)
+			}
+		}
+	}
+
+	r := newRouter()
+	r.Use(mark("router"))
+	api := r.Group("/api", mark("group"))
+	nested := api.Group("/v1", mark("nested"))
+	nested.GET("/skins", func(ctx *fasthttp.RequestCtx) { order = append(order, "handler") }, mark("route"))
+	handler := r.Handler()
+
+	serve(handler, "GET", "/api/v1/skins")
+	if got := strings.Join(order, ","); got != "router,group,nested,route,handler" {
+		t.Errorf("order = %s", got)
+	}
+
+	// Router middleware runs for unmatched requests too
+	order = nil
+	serve(handler, "GET", "/api/v1/missing")
+	if got := strings.Join(order, ","); got != "router" {
+		t.Errorf("unmatched order = %s", got)
+	}
+}
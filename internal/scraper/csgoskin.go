@@ -2,15 +2,23 @@ package scraper
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
 	"github.com/mswatii/cs2-arbitrage/internal/database"
 	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/internal/validation"
+	"github.com/mswatii/cs2-arbitrage/internal/valuation"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
 	"github.com/valyala/fasthttp"
 )
 
@@ -26,10 +34,25 @@ const (
 type CSGOSkinScraper struct {
 	db            *database.Database
 	marketplaceID string
+	observers     []Observer
+	validator     *validation.Validator
 }
 
-// NewCSGOSkinScraper creates a new scraper for csgoskin.ir
-func NewCSGOSkinScraper(db *database.Database) (*CSGOSkinScraper, error) {
+// ErrQuarantined is returned for listings that failed validation and were
+// quarantined instead of stored, along with the item carrying the ID it was
+// stored under by an earlier scrape, if any
+var ErrQuarantined = errors.New("item quarantined")
+
+// ErrScrapeRunning is returned by FetchItems while another scrape of
+// csgoskin.ir runs, since overlapping scrapes multiply the load on the site
+var ErrScrapeRunning = errors.New("a scrape is already running")
+
+// scrapeMu is held by the running scrape, shared by every scraper instance
+var scrapeMu sync.Mutex
+
+// NewCSGOSkinScraper creates a new scraper for csgoskin.ir. Observers are
+// notified as items are stored and when the scrape completes.
+func NewCSGOSkinScraper(db *database.Database, observers ...Observer) (*CSGOSkinScraper, error) {
 	// Insert or get marketplace ID
 	marketplace := &models.Marketplace{
 		Name:     CSGOSkinMarketplaceName,
@@ -42,17 +65,63 @@ func NewCSGOSkinScraper(db *database.Database) (*CSGOSkinScraper, error) {
 		return nil, fmt.Errorf("failed to insert marketplace: %v", err)
 	}
 
+	// Record the fees charged when selling on csgoskin.ir, used to price the
+	// reverse direction
+	fee := &models.MarketplaceFee{
+		MarketplaceID:      marketplaceID,
+		ListingFeePercent:  config.Float("CSGOSKIN_LISTING_FEE_PERCENT", 0),
+		SaleFeePercent:     config.Float("CSGOSKIN_SALE_FEE_PERCENT", 5),
+		FastSellFeePercent: config.Float("CSGOSKIN_FAST_SELL_FEE_PERCENT", 0),
+	}
+	if err := db.UpsertMarketplaceFee(fee); err != nil {
+		log.Printf("Warning: Failed to store csgoskin.ir fees: %v", err)
+	}
+
 	return &CSGOSkinScraper{
 		db:            db,
 		marketplaceID: marketplaceID,
+		observers:     observers,
+		validator:     validation.NewValidator(db),
 	}, nil
 }
 
-// FetchItems fetches all items from csgoskin.ir using pagination
+// FetchItems fetches all items from csgoskin.ir using pagination. It returns
+// ErrScrapeRunning without scraping when another scrape is running.
 func (s *CSGOSkinScraper) FetchItems() error {
+	if !scrapeMu.TryLock() {
+		return ErrScrapeRunning
+	}
+	defer scrapeMu.Unlock()
+
+	return s.fetchItems()
+}
+
+// StartFetchItems runs FetchItems in the background, calling done with its
+// result. It returns ErrScrapeRunning without starting when another scrape
+// is running.
+func (s *CSGOSkinScraper) StartFetchItems(done func(error)) error {
+	if !scrapeMu.TryLock() {
+		return ErrScrapeRunning
+	}
+
+	go func() {
+		defer scrapeMu.Unlock()
+		done(s.fetchItems())
+	}()
+	return nil
+}
+
+// fetchItems scrapes every page, with scrapeMu held
+func (s *CSGOSkinScraper) fetchItems() error {
 	var lastItemID string = "0" // Start with 0 for the first page
 	var totalItemsProcessed int = 0
 	var totalPages int = 0
+	var totalQuarantined int = 0
+	summary := &models.ScrapeSummary{MarketplaceID: s.marketplaceID, Complete: true}
+
+	for _, o := range s.observers {
+		o.ScrapeStarted(s.marketplaceID)
+	}
 
 	for {
 		totalPages++
@@ -69,11 +138,23 @@ func (s *CSGOSkinScraper) FetchItems() error {
 
 		// Process items from this page
 		for _, csgoItem := range csgoItems {
-			if err := s.processItem(csgoItem); err != nil {
-				log.Printf("Error processing item %s: %v", csgoItem.MarketHashName, err)
+			item, err := s.processItem(csgoItem)
+			if err != nil {
+				if errors.Is(err, ErrQuarantined) {
+					totalQuarantined++
+				} else {
+					log.Printf("Error processing item %s: %v", csgoItem.MarketHashName, err)
+				}
+				// A listing stored by an earlier scrape is still listed, but
+				// can't be trusted as an opportunity any more
+				if itemID := s.storedItemID(item, csgoItem); itemID != "" {
+					summary.InvalidItemIDs = append(summary.InvalidItemIDs, itemID)
+				}
 				continue
 			}
 			totalItemsProcessed++
+			summary.SeenItemIDs = append(summary.SeenItemIDs, item.ID)
+			s.notifyItemProcessed(item)
 		}
 
 		// Check if we've reached the end (no more items or same last item ID)
@@ -85,6 +166,7 @@ func (s *CSGOSkinScraper) FetchItems() error {
 		// Check if we've hit the safety limit
 		if totalItemsProcessed >= MaxItemsToFetch {
 			log.Printf("Reached maximum items limit (%d). Stopping pagination.", MaxItemsToFetch)
+			summary.Complete = false
 			break
 		}
 
@@ -95,10 +177,46 @@ func (s *CSGOSkinScraper) FetchItems() error {
 		time.Sleep(RequestDelayMs * time.Millisecond)
 	}
 
-	log.Printf("Completed fetching all items. Processed %d items across %d pages.", totalItemsProcessed, totalPages)
+	log.Printf("Completed fetching all items. Processed %d items across %d pages, quarantined %d.", totalItemsProcessed, totalPages, totalQuarantined)
+
+	for _, o := range s.observers {
+		o.ScrapeCompleted(summary)
+	}
+
 	return nil
 }
 
+// notifyItemProcessed values a stored item once and hands the valuation to
+// every observer, so they don't each query it again
+func (s *CSGOSkinScraper) notifyItemProcessed(item *models.Item) {
+	if len(s.observers) == 0 {
+		return
+	}
+
+	opp, err := arbitrage.FindByItemID(s.db, item.ID)
+	if err != nil {
+		log.Printf("Error valuing item %s: %v", item.ID, err)
+		return
+	}
+
+	for _, o := range s.observers {
+		o.ItemProcessed(item, opp)
+	}
+}
+
+// storedItemID returns the ID of the stored item of a listing that was not
+// stored in this scrape: the quarantined item, or else the one looked up
+func (s *CSGOSkinScraper) storedItemID(item *models.Item, csgoItem models.CSGOSkinItem) string {
+	if item != nil {
+		return item.ID
+	}
+	id, err := s.db.GetItemID(s.marketplaceID, csgoItem.ItemID)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return id
+}
+
 // fetchItemsPage fetches a single page of items based on the last item ID
 func (s *CSGOSkinScraper) fetchItemsPage(lastItemID string) ([]models.CSGOSkinItem, string, error) {
 	// Create HTTP request
@@ -166,85 +284,135 @@ func (s *CSGOSkinScraper) fetchItemsPage(lastItemID string) ([]models.CSGOSkinIt
 }
 
 // processItem processes a single item by inserting it into the database
-func (s *CSGOSkinScraper) processItem(csgoItem models.CSGOSkinItem) error {
-	// 1. First create or update the skin
-	skin, err := s.convertToSkin(csgoItem)
+func (s *CSGOSkinScraper) processItem(csgoItem models.CSGOSkinItem) (*models.Item, error) {
+	// 1. First create or update the skin and the base skin it belongs to
+	skin, baseSkin, err := s.convertToSkin(csgoItem)
 	if err != nil {
-		return fmt.Errorf("error converting skin: %v", err)
+		return nil, fmt.Errorf("error converting skin: %v", err)
 	}
 
-	skinID, err := s.db.InsertSkin(skin)
+	skin.BaseSkinID, err = s.db.UpsertBaseSkin(baseSkin)
 	if err != nil {
-		return fmt.Errorf("error inserting skin: %v", err)
+		return nil, fmt.Errorf("error inserting base skin: %v", err)
 	}
 
-	// 2. Then create or update the specific item
-	item, err := s.convertToItem(csgoItem, skinID)
+	skinID, err := s.db.InsertSkin(skin)
 	if err != nil {
-		return fmt.Errorf("error converting item: %v", err)
+		return nil, fmt.Errorf("error inserting skin: %v", err)
+	}
+
+	// 2. Then validate the specific item, quarantining it rather than
+	// storing it as tradable when anything looks wrong
+	item, issues := s.convertToItem(csgoItem, skinID)
+	issues = append(issues, s.validator.Validate(item, skin)...)
+	if len(issues) > 0 {
+		item.ID = s.quarantine(csgoItem, item, issues)
+		return item, ErrQuarantined
+	}
+
+	// Link relistings of the same physical item under their new listing ID
+	if fingerprint := item.Fingerprint(); fingerprint != "" {
+		item.PhysicalItemID, err = s.db.LinkPhysicalItem(fingerprint, item.SkinID)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
 	}
 
-	_, err = s.db.InsertItem(item)
+	item.ID, err = s.db.InsertItem(item)
 	if err != nil {
-		return fmt.Errorf("error inserting item: %v", err)
+		return nil, fmt.Errorf("error inserting item: %v", err)
 	}
 
-	return nil
+	if err := s.db.ReleaseQuarantinedItem(s.marketplaceID, item.MarketItemID); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	if err := s.db.RecordPriceObservation(item); err != nil {
+		log.Printf("Warning: Failed to record price history for %s: %v", item.MarketItemID, err)
+	}
+
+	return item, nil
 }
 
-// convertToSkin converts CSGOSkinItem to Skin model
-func (s *CSGOSkinScraper) convertToSkin(csgoItem models.CSGOSkinItem) (*models.Skin, error) {
-	// Extract category and subcategory
-	category, subCategory := parseCategory(csgoItem.Name.Category)
+// convertToSkin converts CSGOSkinItem to Skin model and the base skin shared
+// by all of its variants
+func (s *CSGOSkinScraper) convertToSkin(csgoItem models.CSGOSkinItem) (*models.Skin, *models.BaseSkin, error) {
+	// Resolve type and weapon from the catalogue, using csgoskin's own
+	// category label as a hint when the name is not recognised
+	resolved := catalog.Default().Resolve(csgoItem.MarketHashName, csgoItem.Name.Category)
+	if !resolved.Known {
+		log.Printf("Warning: %s is not in the item catalogue (category %q)", csgoItem.MarketHashName, csgoItem.Name.Category)
+	}
 
-	// Determine min and max float values based on quality
-	// These are just approximations and should be refined with actual data
-	minFloat, maxFloat := 0.0, 1.0
 	quality := strings.TrimSpace(csgoItem.Quality)
-	if quality == "Factory New" || quality == "Factory-New" {
-		minFloat, maxFloat = 0.00, 0.07
-	} else if quality == "Minimal Wear" || quality == "Minimal-Wear" {
-		minFloat, maxFloat = 0.07, 0.15
-	} else if quality == "Field-Tested" || quality == "Field-Tested" {
-		minFloat, maxFloat = 0.15, 0.38
-	} else if quality == "Well-Worn" || quality == "Well-Worn" {
-		minFloat, maxFloat = 0.38, 0.45
-	} else if quality == "Battle-Scarred" || quality == "Battle-Scarred" {
-		minFloat, maxFloat = 0.45, 1.00
+	if wear := catalog.NormalizeWear(quality); wear != "" {
+		quality = wear
+	} else if quality == "" {
+		quality = resolved.Wear
+	}
+
+	// Use the skin's real paint range from the catalogue, falling back to
+	// the wear bucket when the skin is not catalogued. Items without wear
+	// (cases, stickers, agents...) have no float range at all.
+	var minFloat, maxFloat *float64
+	if catalog.HasWear(resolved.Type) {
+		low, high, found := catalog.Default().FloatRange(resolved.Weapon, resolved.Finish)
+		if !found {
+			low, high = 0.0, 1.0
+			if wearLow, wearHigh, ok := valuation.WearRange(quality); ok {
+				low, high = wearLow, wearHigh
+			}
+		}
+		minFloat, maxFloat = &low, &high
+	} else {
+		quality = ""
 	}
 
 	// Create the skin model
 	skin := &models.Skin{
 		MarketHashName: csgoItem.MarketHashName,
-		Category:       category,
-		SubCategory:    subCategory,
+		Category:       resolved.Type,
+		SubCategory:    resolved.Weapon,
 		SkinName:       strings.TrimSpace(csgoItem.Name.SkinName),
-		IsStatTrak:     csgoItem.IsStatTrack == 1,
+		IsStatTrak:     resolved.StatTrak,
+		IsSouvenir:     resolved.Souvenir,
+		IsStar:         resolved.Star,
 		Quality:        quality,
 		MinFloat:       minFloat,
 		MaxFloat:       maxFloat,
 		IconURL:        "https://csgoskin.ir" + csgoItem.IconMedium,
 	}
 
-	return skin, nil
+	baseSkin := &models.BaseSkin{
+		Weapon:   resolved.Weapon,
+		Finish:   resolved.Finish,
+		Category: resolved.Type,
+		Rarity:   resolved.Rarity,
+	}
+
+	return skin, baseSkin, nil
 }
 
-// convertToItem converts CSGOSkinItem to Item model
-func (s *CSGOSkinScraper) convertToItem(csgoItem models.CSGOSkinItem, skinID string) (*models.Item, error) {
-	// Parse float value
-	var floatVal float64
+// convertToItem converts CSGOSkinItem to Item model, along with any fields
+// that failed to parse
+func (s *CSGOSkinScraper) convertToItem(csgoItem models.CSGOSkinItem, skinID string) (*models.Item, []validation.Issue) {
+	var issues []validation.Issue
+
+	// Parse float value, left nil for items without wear
+	var floatVal *float64
 	if csgoItem.Float != "" {
-		var err error
-		floatVal, err = strconv.ParseFloat(csgoItem.Float, 64)
+		parsed, err := strconv.ParseFloat(csgoItem.Float, 64)
 		if err != nil {
-			log.Printf("Warning: Could not parse float value %s: %v", csgoItem.Float, err)
+			issues = append(issues, validation.Issuef(validation.RuleUnparsable, "float %q: %v", csgoItem.Float, err))
+		} else {
+			floatVal = &parsed
 		}
 	}
 
 	// Parse prices - NOTE: These are in Toman (IRT), not Rial (IRR)
 	priceInToman, err := strconv.ParseFloat(csgoItem.Price, 64)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse price %s: %v", csgoItem.Price, err)
+		issues = append(issues, validation.Issuef(validation.RuleUnparsable, "price %q: %v", csgoItem.Price, err))
 	}
 
 	// Convert Toman to Rial (1 Toman = 10 Rial)
@@ -274,84 +442,89 @@ func (s *CSGOSkinScraper) convertToItem(csgoItem models.CSGOSkinItem, skinID str
 		steamPriceStr := strings.TrimPrefix(csgoItem.PriceSteam, "$")
 		steamPriceUSD, err = strconv.ParseFloat(steamPriceStr, 64)
 		if err != nil {
-			log.Printf("Warning: Could not parse steam price %s: %v", csgoItem.PriceSteam, err)
+			issues = append(issues, validation.Issuef(validation.RuleUnparsable, "Steam price %q: %v", csgoItem.PriceSteam, err))
 		}
 	}
 
-	// Create Item model
-	item := &models.Item{
-		SkinID:        skinID,
-		MarketplaceID: s.marketplaceID,
-		Float:         floatVal,
-		Stickers:      csgoItem.Stickers,
-		Price:         priceInRial,       // Store the price in Rial
-		PriceFailed:   priceFailedInRial, // Store the failed price in Rial
-		PriceUSD:      priceUSD,
-		SteamPriceUSD: steamPriceUSD,
-		Tradeable:     csgoItem.Tradeable,
-		IsFastSell:    true, // Assuming all items from this query are fast sell since fasttrade=1
-		MarketItemID:  csgoItem.ItemID,
+	// Capture pattern details: the paint index and seed when the source
+	// provides them, and the Doppler phase or fade percentage from hints in
+	// the skin name otherwise. Icon paths are shared between phases, so they
+	// can't tell them apart.
+	paintIndex := int(csgoItem.PaintIndex)
+	paintSeed := int(csgoItem.PaintSeed)
+	name := catalog.ParseMarketHashName(csgoItem.MarketHashName)
+	phase := catalog.DetectPhase(name.Finish, paintIndex, csgoItem.Name.SkinName)
+	fadePercent := catalog.DetectFadePercent(name.Finish, csgoItem.Name.SkinName)
+
+	// The name is authoritative for StatTrak; a disagreeing flag means the
+	// listing is mislabelled
+	labelMismatch := (csgoItem.IsStatTrack == 1) != name.StatTrak
+	if labelMismatch {
+		log.Printf("Warning: Item %s (%s) has is_stattrack=%d but its name says otherwise",
+			csgoItem.ItemID, csgoItem.MarketHashName, csgoItem.IsStatTrack)
 	}
 
-	return item, nil
-}
-
-// parseCategory extracts category and subcategory from a string
-func parseCategory(fullCategory string) (string, string) {
-	// Existing code...
-	fullCategory = strings.TrimSpace(fullCategory)
-
-	// Handle common categories
-	if strings.Contains(fullCategory, "KNIFE") {
-		return "Knife", fullCategory
+	// Work out when the trade hold ends, keeping the raw value either way
+	tradeHoldUntil, ok := ParseTradeHold(csgoItem.Tradeable, time.Now())
+	if !ok {
+		log.Printf("Warning: Could not parse tradeable value %q of item %s", csgoItem.Tradeable, csgoItem.ItemID)
 	}
 
-	if strings.Contains(fullCategory, "GLOVES") {
-		return "Gloves", fullCategory
+	// Create Item model
+	item := &models.Item{
+		SkinID:         skinID,
+		MarketplaceID:  s.marketplaceID,
+		Float:          floatVal,
+		Stickers:       csgoItem.Stickers,
+		Price:          priceInRial,       // Store the price in Rial
+		PriceFailed:    priceFailedInRial, // Store the failed price in Rial
+		PriceUSD:       priceUSD,
+		SteamPriceUSD:  steamPriceUSD,
+		Tradeable:      csgoItem.Tradeable,
+		TradeHoldUntil: tradeHoldUntil,
+		IsFastSell:     true, // Assuming all items from this query are fast sell since fasttrade=1
+		MarketItemID:   csgoItem.ItemID,
+		PaintIndex:     paintIndex,
+		PaintSeed:      paintSeed,
+		Phase:          phase,
+		FadePercent:    fadePercent,
+		LabelMismatch:  labelMismatch,
+		AssetID:        string(csgoItem.AssetID),
 	}
 
-	// For guns and other items
-	parts := strings.Fields(fullCategory)
-	if len(parts) > 0 {
-		subCategory := parts[0]
-
-		// Determine main category
-		if contains([]string{"AK-47", "M4A4", "M4A1-S", "FAMAS", "GALIL", "AUG", "SG"}, subCategory) {
-			return "Rifle", subCategory
-		}
-
-		if contains([]string{"AWP", "SCAR-20", "G3SG1", "SSG", "SSG 08"}, subCategory) {
-			return "Sniper Rifle", subCategory
-		}
-
-		if contains([]string{"P90", "MP5", "MP7", "MP9", "MAC-10", "UMP-45", "PP-BIZON"}, subCategory) {
-			return "SMG", subCategory
-		}
-
-		if contains([]string{"GLOCK", "USP-S", "P2000", "P250", "FIVE-SEVEN", "TEC-9", "CZ75", "DESERT EAGLE", "DUAL BERETTAS", "R8"}, subCategory) {
-			return "Pistol", subCategory
-		}
-
-		if contains([]string{"NOVA", "XM1014", "MAG-7", "SAWED-OFF"}, subCategory) {
-			return "Shotgun", subCategory
-		}
+	return item, issues
+}
 
-		if contains([]string{"M249", "NEGEV"}, subCategory) {
-			return "Machine Gun", subCategory
+// quarantine records a listing that failed validation along with the raw
+// listing, so it can be inspected later. It returns the ID of the item
+// stored for the listing by an earlier scrape, now flagged, if any.
+func (s *CSGOSkinScraper) quarantine(csgoItem models.CSGOSkinItem, item *models.Item, issues []validation.Issue) string {
+	q := &models.QuarantinedItem{
+		MarketplaceID:  s.marketplaceID,
+		MarketItemID:   csgoItem.ItemID,
+		MarketHashName: csgoItem.MarketHashName,
+		SkinID:         &item.SkinID,
+		Price:          item.Price,
+		PriceUSD:       item.PriceUSD,
+		SteamPriceUSD:  item.SteamPriceUSD,
+		Float:          item.Float,
+	}
+	for _, issue := range issues {
+		if !slices.Contains(q.Rules, issue.Rule) {
+			q.Rules = append(q.Rules, issue.Rule)
 		}
-
-		return "Other", subCategory
+		q.Reasons = append(q.Reasons, issue.Reason)
 	}
 
-	return "Unknown", fullCategory
-}
+	raw, err := json.Marshal(csgoItem)
+	if err == nil {
+		q.Raw = raw
+	}
 
-// contains checks if a string is in a slice
-func contains(slice []string, s string) bool {
-	for _, item := range slice {
-		if item == s {
-			return true
-		}
+	log.Printf("Quarantined item %s (%s): %s", csgoItem.ItemID, csgoItem.MarketHashName, strings.Join(q.Reasons, "; "))
+	itemID, err := s.db.QuarantineItem(q)
+	if err != nil {
+		log.Printf("Warning: %v", err)
 	}
-	return false
+	return itemID
 }
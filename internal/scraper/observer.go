@@ -0,0 +1,22 @@
+package scraper
+
+import (
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// Observer is notified about scrape progress, e.g. to raise alerts or track
+// opportunities as soon as listings are stored
+type Observer interface {
+	// ScrapeStarted is called before a scrape of a marketplace fetches its
+	// first page. A scrape that fails is never completed, so observers drop
+	// what they gathered from an earlier one here.
+	ScrapeStarted(marketplaceID string)
+	// ItemProcessed is called after an item has been stored, with its ID set,
+	// along with its valuation, nil when it has no usable prices. The
+	// valuation is shared by every observer, which must not modify it.
+	ItemProcessed(item *models.Item, opp *arbitrage.Opportunity)
+	// ScrapeCompleted is called after a scrape of a marketplace has fetched
+	// its last page or stopped at MaxItemsToFetch
+	ScrapeCompleted(summary *models.ScrapeSummary)
+}
@@ -0,0 +1,188 @@
+package scraper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tehran is the zone csgoskin.ir shows dates in
+var tehran = loadTehran()
+
+func loadTehran() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Tehran"); err == nil {
+		return loc
+	}
+	return time.FixedZone("IRST", 3*3600+30*60)
+}
+
+// persianDigits maps Persian and Arabic-Indic digits to ASCII
+var persianDigits = strings.NewReplacer(
+	"۰", "0", "۱", "1", "۲", "2", "۳", "3", "۴", "4",
+	"۵", "5", "۶", "6", "۷", "7", "۸", "8", "۹", "9",
+	"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4",
+	"٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+)
+
+// Words meaning the item can be traded right away. Bare numbers are not
+// flags: "1" is a one day hold, parsed with the other day counts.
+var tradeableNowWords = []string{"", "true", "yes", "tradable", "tradeable", "trade", "قابل ترید", "آزاد"}
+
+// Durations such as "7 days", "3d", "12 hours" or "7 روز"
+var (
+	durationPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(days?|d|hours?|hrs?|h|روز|ساعت)`)
+	datePattern     = regexp.MustCompile(`(\d{4})[-/](\d{1,2})[-/](\d{1,2})(?:[ T,]+(\d{1,2}):(\d{2})(?::(\d{2}))?)?`)
+)
+
+// Layouts tried for English dates such as Steam's "Tradable After Jan 2, 2026 (7:00:00) GMT"
+var englishDateLayouts = []string{
+	time.RFC3339,
+	"Jan 2, 2006 (15:04:05) MST",
+	"Jan 2, 2006 15:04:05",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+}
+
+// ParseTradeHold parses csgoskin's raw tradeable field into the time the
+// trade hold ends. It returns nil when the item is tradeable now, and false
+// when the value isn't understood. Accepted forms are tradeable markers,
+// remaining durations in days or hours, Unix timestamps, and Gregorian or
+// Jalali (Persian calendar) dates, with Persian digits.
+func ParseTradeHold(raw string, now time.Time) (*time.Time, bool) {
+	s := strings.ToLower(strings.TrimSpace(persianDigits.Replace(raw)))
+	s = strings.TrimSpace(strings.TrimPrefix(s, "tradable after"))
+
+	for _, word := range tradeableNowWords {
+		if s == word {
+			return nil, true
+		}
+	}
+
+	// A bare number is a Unix timestamp or a count of days
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		var until time.Time
+		if n > 1e9 {
+			until = time.Unix(n, 0)
+		} else {
+			until = now.Add(time.Duration(n) * 24 * time.Hour)
+		}
+		return holdUntil(until, now), true
+	}
+
+	if m := datePattern.FindStringSubmatch(s); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		hour, min, sec := 0, 0, 0
+		if m[4] != "" {
+			hour, _ = strconv.Atoi(m[4])
+			min, _ = strconv.Atoi(m[5])
+			sec, _ = strconv.Atoi(m[6])
+		}
+		// Check before converting, which would roll a bad date or time over
+		jalali := year < 1700
+		if !validDate(year, month, day, jalali) || hour > 23 || min > 59 || sec > 59 {
+			return nil, false
+		}
+		if jalali {
+			year, month, day = jalaliToGregorian(year, month, day)
+		}
+		until := time.Date(year, time.Month(month), day, hour, min, sec, 0, tehran)
+		return holdUntil(until, now), true
+	}
+
+	if m := durationPattern.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		unit := 24 * time.Hour
+		if strings.HasPrefix(m[2], "h") || m[2] == "ساعت" {
+			unit = time.Hour
+		}
+		return holdUntil(now.Add(time.Duration(n*float64(unit))), now), true
+	}
+
+	for _, layout := range englishDateLayouts {
+		if until, err := time.Parse(layout, strings.TrimSpace(raw)); err == nil {
+			return holdUntil(until, now), true
+		}
+		if until, err := time.Parse(layout, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), "Tradable After"))); err == nil {
+			return holdUntil(until, now), true
+		}
+	}
+
+	return nil, false
+}
+
+// holdUntil returns the end of a hold, or nil when it already ended
+func holdUntil(until, now time.Time) *time.Time {
+	if !until.After(now) {
+		return nil
+	}
+	until = until.UTC()
+	return &until
+}
+
+// validDate reports whether day exists in the month of the Jalali or
+// Gregorian year
+func validDate(year, month, day int, jalali bool) bool {
+	if month < 1 || month > 12 || day < 1 {
+		return false
+	}
+	if !jalali {
+		return day <= time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	}
+	switch {
+	case month <= 6:
+		return day <= 31
+	case month <= 11:
+		return day <= 30
+	case day <= 29:
+		return true
+	default:
+		// Esfand has 30 days in leap years, when its 30th isn't already
+		// the first day of the next year
+		y, m, d := jalaliToGregorian(year, 12, 30)
+		ny, nm, nd := jalaliToGregorian(year+1, 1, 1)
+		return day == 30 && (y != ny || m != nm || d != nd)
+	}
+}
+
+// jalaliToGregorian converts a Jalali (Solar Hijri) date to Gregorian
+func jalaliToGregorian(jy, jm, jd int) (int, int, int) {
+	jy += 1595
+	days := -355668 + 365*jy + (jy/33)*8 + ((jy%33)+3)/4 + jd
+	if jm < 7 {
+		days += (jm - 1) * 31
+	} else {
+		days += (jm-7)*30 + 186
+	}
+
+	gy := 400 * (days / 146097)
+	days %= 146097
+	if days > 36524 {
+		days--
+		gy += 100 * (days / 36524)
+		days %= 36524
+		if days >= 365 {
+			days++
+		}
+	}
+	gy += 4 * (days / 1461)
+	days %= 1461
+	if days > 365 {
+		gy += (days - 1) / 365
+		days = (days - 1) % 365
+	}
+
+	gd := days + 1
+	monthDays := []int{0, 31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+	if (gy%4 == 0 && gy%100 != 0) || gy%400 == 0 {
+		monthDays[2] = 29
+	}
+	gm := 1
+	for gm <= 12 && gd > monthDays[gm] {
+		gd -= monthDays[gm]
+		gm++
+	}
+	return gy, gm, gd
+}
@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTradeHold(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		raw      string
+		wantHold time.Duration // 0 for tradeable now
+	}{
+		{"", 0},
+		{"tradable", 0},
+		{"0", 0},
+		{"1", 24 * time.Hour},
+		{"7", 7 * 24 * time.Hour},
+		{"۳", 3 * 24 * time.Hour},
+		{"12 hours", 12 * time.Hour},
+		{"2 روز", 2 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		until, ok := ParseTradeHold(tt.raw, now)
+		if !ok {
+			t.Errorf("ParseTradeHold(%q) not understood", tt.raw)
+			continue
+		}
+		if tt.wantHold == 0 {
+			if until != nil {
+				t.Errorf("ParseTradeHold(%q) = %v, want tradeable now", tt.raw, until)
+			}
+			continue
+		}
+		if until == nil || !until.Equal(now.Add(tt.wantHold)) {
+			t.Errorf("ParseTradeHold(%q) = %v, want %v", tt.raw, until, now.Add(tt.wantHold))
+		}
+	}
+
+	if _, ok := ParseTradeHold("soon", now); ok {
+		t.Errorf("ParseTradeHold(%q) understood, want not", "soon")
+	}
+}
+
+func TestParseTradeHoldDates(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		raw  string
+		want time.Time // Zero for tradeable now
+	}{
+		// Unix timestamps
+		{"1768219200", time.Date(2026, 1, 12, 12, 0, 0, 0, time.UTC)},
+		{"۱۷۶۸۲۱۹۲۰۰", time.Date(2026, 1, 12, 12, 0, 0, 0, time.UTC)},
+		{"1767225600", time.Time{}}, // Already passed
+		// Jalali dates, midnight in Tehran unless a time is given
+		{"1404/10/25", time.Date(2026, 1, 14, 20, 30, 0, 0, time.UTC)},
+		{"۱۴۰۴/۱۰/۲۵ ۱۴:۳۰", time.Date(2026, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"1405/01/01", time.Date(2026, 3, 20, 20, 30, 0, 0, time.UTC)},
+		{"1404-07-01", time.Time{}}, // 2025-09-23
+		// Gregorian dates
+		{"2026-01-12 10:00", time.Date(2026, 1, 12, 6, 30, 0, 0, time.UTC)},
+		{"Tradable After Jan 12, 2026 (7:00:00) GMT", time.Date(2026, 1, 12, 7, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		until, ok := ParseTradeHold(tt.raw, now)
+		if !ok {
+			t.Errorf("ParseTradeHold(%q) not understood", tt.raw)
+			continue
+		}
+		if tt.want.IsZero() {
+			if until != nil {
+				t.Errorf("ParseTradeHold(%q) = %v, want tradeable now", tt.raw, until)
+			}
+			continue
+		}
+		if until == nil || !until.Equal(tt.want) {
+			t.Errorf("ParseTradeHold(%q) = %v, want %v", tt.raw, until, tt.want)
+		}
+	}
+
+	// Month ends, including Esfand 30 in the leap year 1403 and 29 February
+	// in a Gregorian leap year
+	for _, raw := range []string{"1404/06/31", "1404/07/30", "1403/12/30", "1404/12/29", "2028-02-29", "2026-04-30 23:59:59"} {
+		if _, ok := ParseTradeHold(raw, now); !ok {
+			t.Errorf("ParseTradeHold(%q) not understood", raw)
+		}
+	}
+
+	// Dates and times that don't exist, rather than rolled over
+	invalid := []string{
+		"1404/13/01",
+		"1404/07/31",
+		"1404/12/31",
+		"1404/12/30", // 1404 isn't a leap year
+		"2026-02-29",
+		"2026-02-31",
+		"2026-04-31",
+		"2026-01-12 24:00",
+		"2026-01-12 25:99",
+		"2026-01-12 10:60",
+		"1404/10/25 10:00:60",
+	}
+	for _, raw := range invalid {
+		if _, ok := ParseTradeHold(raw, now); ok {
+			t.Errorf("ParseTradeHold(%q) understood, want not", raw)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package steam
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
+	"github.com/valyala/fasthttp"
+)
+
+// DefaultCommunityURL is the Steam Community base for public inventories
+const DefaultCommunityURL = "https://steamcommunity.com"
+
+// inventoryPageSize is the largest page Steam serves without throttling
+const inventoryPageSize = 2000
+
+// maxInventoryPages bounds the pages fetched for one inventory, well above
+// what the largest CS2 inventory with storage units needs, so a misbehaving
+// server can't keep the fetch going forever
+const maxInventoryPages = 25
+
+// steamID64Pattern matches the 17-digit SteamID64 of an individual account
+var steamID64Pattern = regexp.MustCompile(`^7656119\d{10}$`)
+
+// ValidSteamID64 reports whether s is the SteamID64 of an individual account
+func ValidSteamID64(s string) bool {
+	return steamID64Pattern.MatchString(s)
+}
+
+// InventoryAsset is one stack of items in a Steam inventory
+type InventoryAsset struct {
+	AssetID        string `json:"asset_id"`
+	ClassID        string `json:"class_id"`
+	InstanceID     string `json:"instance_id"`
+	MarketHashName string `json:"market_hash_name"`
+	Type           string `json:"type"`
+	IconURL        string `json:"icon_url"`
+	Amount         int    `json:"amount"`
+	Tradable       bool   `json:"tradable"`
+	Marketable     bool   `json:"marketable"`
+}
+
+// inventoryResponse is the public inventory JSON served by
+// /inventory/<steamid>/730/2
+type inventoryResponse struct {
+	Success     int    `json:"success"`
+	Error       string `json:"error"`
+	MoreItems   int    `json:"more_items"`
+	LastAssetID string `json:"last_assetid"`
+	TotalCount  int    `json:"total_inventory_count"`
+	Assets      []struct {
+		AssetID    string `json:"assetid"`
+		ClassID    string `json:"classid"`
+		InstanceID string `json:"instanceid"`
+		Amount     string `json:"amount"`
+	} `json:"assets"`
+	Descriptions []struct {
+		ClassID        string `json:"classid"`
+		InstanceID     string `json:"instanceid"`
+		MarketHashName string `json:"market_hash_name"`
+		Type           string `json:"type"`
+		IconURL        string `json:"icon_url"`
+		Tradable       int    `json:"tradable"`
+		Marketable     int    `json:"marketable"`
+	} `json:"descriptions"`
+}
+
+// ParseInventory parses a page of the public inventory JSON format, as saved
+// from steamcommunity.com/inventory/<steamid>/730/2, joining each asset with
+// its description
+func ParseInventory(data []byte) ([]InventoryAsset, error) {
+	var resp inventoryResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory: %v", err)
+	}
+	return resp.join()
+}
+
+// join matches assets to descriptions by class and instance ID
+func (r *inventoryResponse) join() ([]InventoryAsset, error) {
+	if r.Success != 1 && len(r.Assets) == 0 {
+		if r.Error != "" {
+			return nil, fmt.Errorf("steam inventory error: %s", r.Error)
+		}
+		return nil, fmt.Errorf("steam inventory is empty, private or invalid")
+	}
+
+	descriptions := make(map[string]int, len(r.Descriptions))
+	for i, d := range r.Descriptions {
+		descriptions[d.ClassID+"_"+d.InstanceID] = i
+	}
+
+	assets := make([]InventoryAsset, 0, len(r.Assets))
+	for _, a := range r.Assets {
+		asset := InventoryAsset{
+			AssetID:    a.AssetID,
+			ClassID:    a.ClassID,
+			InstanceID: a.InstanceID,
+			Amount:     1,
+		}
+		if amount := parseCount(a.Amount); amount != nil && *amount > 0 {
+			asset.Amount = *amount
+		}
+		if i, ok := descriptions[a.ClassID+"_"+a.InstanceID]; ok {
+			d := r.Descriptions[i]
+			asset.MarketHashName = d.MarketHashName
+			asset.Type = d.Type
+			asset.Tradable = d.Tradable == 1
+			asset.Marketable = d.Marketable == 1
+			if d.IconURL != "" {
+				asset.IconURL = "https://community.cloudflare.steamstatic.com/economy/image/" + d.IconURL
+			}
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// FetchInventory downloads the full CS2 inventory of a public Steam profile
+func FetchInventory(client httputil.Doer, baseURL, steamID string) ([]InventoryAsset, error) {
+	if !ValidSteamID64(steamID) {
+		return nil, fmt.Errorf("invalid steam ID %q", steamID)
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	var all []InventoryAsset
+	startAssetID := ""
+	for page := 1; ; page++ {
+		if page > maxInventoryPages {
+			return nil, fmt.Errorf("inventory of %s has more than %d pages", steamID, maxInventoryPages)
+		}
+
+		url := fmt.Sprintf("%s/inventory/%s/%d/2?l=english&count=%d", baseURL, steamID, AppID, inventoryPageSize)
+		if startAssetID != "" {
+			url += "&start_assetid=" + startAssetID
+		}
+
+		var resp inventoryResponse
+		status, err := httputil.GetJSON(client, url, nil, &resp)
+		if status == fasthttp.StatusTooManyRequests {
+			return nil, ErrRateLimited
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch inventory of %s: %v", steamID, err)
+		}
+
+		assets, err := resp.join()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, assets...)
+
+		if resp.MoreItems != 1 || resp.LastAssetID == "" {
+			break
+		}
+		startAssetID = resp.LastAssetID
+	}
+
+	return all, nil
+}
@@ -0,0 +1,92 @@
+package steam
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// pagingDoer serves inventory pages that always claim more items follow
+type pagingDoer struct {
+	requests int
+}
+
+func (d *pagingDoer) DoTimeout(req *fasthttp.Request, resp *fasthttp.Response, timeout time.Duration) error {
+	d.requests++
+	resp.SetStatusCode(fasthttp.StatusOK)
+	resp.SetBodyString(fmt.Sprintf(`{
+		"success": 1, "more_items": 1, "last_assetid": "%d",
+		"assets": [{"assetid": "%d", "classid": "1", "instanceid": "0", "amount": "1"}],
+		"descriptions": [{"classid": "1", "instanceid": "0", "market_hash_name": "AK-47 | Redline (Field-Tested)", "tradable": 1}]
+	}`, d.requests, d.requests))
+	return nil
+}
+
+func TestValidSteamID64(t *testing.T) {
+	for id, want := range map[string]bool{
+		"76561197960287930":  true,
+		"76561198000000000":  true,
+		"7656119796028793":   false, // 16 digits
+		"765611979602879300": false, // 18 digits
+		"12345678901234567":  false, // Not an individual account
+		"76561197960287930/": false,
+		"../../etc/passwd":   false,
+		"":                   false,
+	} {
+		if got := ValidSteamID64(id); got != want {
+			t.Errorf("ValidSteamID64(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestParseInventory(t *testing.T) {
+	assets, err := ParseInventory([]byte(`{
+		"success": 1,
+		"assets": [
+			{"assetid": "1", "classid": "10", "instanceid": "0", "amount": "1"},
+			{"assetid": "2", "classid": "20", "instanceid": "0", "amount": "5"},
+			{"assetid": "3", "classid": "30", "instanceid": "0", "amount": ""}
+		],
+		"descriptions": [
+			{"classid": "10", "instanceid": "0", "market_hash_name": "AK-47 | Redline (Field-Tested)", "icon_url": "abc", "tradable": 1, "marketable": 1},
+			{"classid": "20", "instanceid": "0", "market_hash_name": "Operation Bravo Case", "tradable": 0, "marketable": 1}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 3 {
+		t.Fatalf("parsed %d assets, want 3", len(assets))
+	}
+	if a := assets[0]; a.MarketHashName != "AK-47 | Redline (Field-Tested)" || !a.Tradable || !strings.HasSuffix(a.IconURL, "/abc") {
+		t.Errorf("asset 1 = %+v", a)
+	}
+	if a := assets[1]; a.Amount != 5 || a.Tradable || !a.Marketable {
+		t.Errorf("asset 2 = %+v", a)
+	}
+	if a := assets[2]; a.Amount != 1 || a.MarketHashName != "" {
+		t.Errorf("asset without a description = %+v", a)
+	}
+
+	if _, err := ParseInventory([]byte(`{"success": 0, "error": "private"}`)); err == nil {
+		t.Error("private inventory parsed, want an error")
+	}
+}
+
+func TestFetchInventory(t *testing.T) {
+	doer := &pagingDoer{}
+	if _, err := FetchInventory(doer, "https://steam.example.com", "76561197960287930"); err == nil {
+		t.Error("endless inventory fetched, want an error")
+	}
+	if doer.requests != maxInventoryPages {
+		t.Errorf("made %d requests, want %d", doer.requests, maxInventoryPages)
+	}
+
+	doer = &pagingDoer{}
+	if _, err := FetchInventory(doer, "https://steam.example.com", "../profiles"); err == nil || doer.requests != 0 {
+		t.Errorf("invalid steam ID: error %v after %d requests, want an error before any", err, doer.requests)
+	}
+}
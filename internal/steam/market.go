@@ -0,0 +1,128 @@
+package steam
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/pkg/httputil"
+	"github.com/valyala/fasthttp"
+)
+
+// Steam Community Market endpoints for CS2 (app 730), priced in USD
+const (
+	DefaultMarketURL = "https://steamcommunity.com/market"
+	AppID            = 730
+	CurrencyUSD      = 1
+)
+
+// ErrRateLimited is returned when Steam answers 429 Too Many Requests
+var ErrRateLimited = errors.New("rate limited by steam")
+
+// MarketClient fetches price statistics from the Steam Community Market
+type MarketClient struct {
+	baseURL string
+	client  httputil.Doer
+}
+
+// NewMarketClient creates a market client. baseURL is normally
+// DefaultMarketURL.
+func NewMarketClient(baseURL string, client httputil.Doer) *MarketClient {
+	return &MarketClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// priceOverview is the response of /market/priceoverview
+type priceOverview struct {
+	Success     bool   `json:"success"`
+	LowestPrice string `json:"lowest_price"`
+	MedianPrice string `json:"median_price"`
+	Volume      string `json:"volume"`
+}
+
+// searchResults is the response of /market/search/render with norender=1
+type searchResults struct {
+	Success bool `json:"success"`
+	Results []struct {
+		HashName     string `json:"hash_name"`
+		SellListings int    `json:"sell_listings"`
+	} `json:"results"`
+}
+
+// Stats fetches the lowest and median price, daily volume and listing count
+// of an item. The volume is nil and the listing count -1 when Steam doesn't
+// report them.
+func (c *MarketClient) Stats(marketHashName string) (*models.SteamPriceStats, error) {
+	var overview priceOverview
+	overviewURL := fmt.Sprintf("%s/priceoverview/?appid=%d&currency=%d&market_hash_name=%s",
+		c.baseURL, AppID, CurrencyUSD, url.QueryEscape(marketHashName))
+	if err := c.get(overviewURL, &overview); err != nil {
+		return nil, err
+	}
+	if !overview.Success {
+		return nil, fmt.Errorf("steam has no price overview for %s", marketHashName)
+	}
+
+	stats := &models.SteamPriceStats{
+		MarketHashName: marketHashName,
+		LowestPriceUSD: ParsePrice(overview.LowestPrice),
+		MedianPriceUSD: ParsePrice(overview.MedianPrice),
+		DailyVolume:    parseCount(overview.Volume),
+		ListingCount:   -1,
+	}
+
+	var search searchResults
+	searchURL := fmt.Sprintf("%s/search/render/?appid=%d&norender=1&count=10&search_descriptions=0&query=%s",
+		c.baseURL, AppID, url.QueryEscape(marketHashName))
+	if err := c.get(searchURL, &search); err != nil {
+		if errors.Is(err, ErrRateLimited) {
+			return nil, err
+		}
+		return stats, nil // The overview alone is still useful
+	}
+	for _, result := range search.Results {
+		if result.HashName == marketHashName {
+			stats.ListingCount = result.SellListings
+			break
+		}
+	}
+
+	return stats, nil
+}
+
+// get fetches a JSON endpoint, mapping 429 to ErrRateLimited
+func (c *MarketClient) get(url string, target interface{}) error {
+	status, err := httputil.GetJSON(c.client, url, nil, target)
+	if status == fasthttp.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+	return err
+}
+
+// ParsePrice parses a Steam price string such as "$1,234.56" into dollars,
+// returning 0 when it can't be parsed
+func ParsePrice(s string) float64 {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "$"))
+	s = strings.TrimSuffix(s, " USD")
+	s = strings.ReplaceAll(s, ",", "")
+	price, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return price
+}
+
+// parseCount parses a count such as "1,234", returning nil when absent so
+// an unreported count isn't read as none
+func parseCount(s string) *int {
+	n, err := strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(s), ",", ""))
+	if err != nil {
+		return nil
+	}
+	return &n
+}
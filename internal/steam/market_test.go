@@ -0,0 +1,44 @@
+package steam
+
+import "testing"
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float64
+	}{
+		{"$1.23", 1.23},
+		{"$1,234.56", 1234.56},
+		{" $0.03 USD ", 0.03},
+		{"12", 12},
+		{"", 0},
+		{"1,23€", 0},
+	}
+
+	for _, tt := range tests {
+		if got := ParsePrice(tt.s); got != tt.want {
+			t.Errorf("ParsePrice(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+		ok   bool
+	}{
+		{"1,234", 1234, true},
+		{" 7 ", 7, true},
+		{"0", 0, true},
+		{"", 0, false},
+		{"many", 0, false},
+	}
+
+	for _, tt := range tests {
+		got := parseCount(tt.s)
+		if (got != nil) != tt.ok || (got != nil && *got != tt.want) {
+			t.Errorf("parseCount(%q) = %v, want %d (known %v)", tt.s, got, tt.want, tt.ok)
+		}
+	}
+}
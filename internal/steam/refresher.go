@@ -0,0 +1,94 @@
+package steam
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+)
+
+// Refresher keeps the Steam statistics of listed skins up to date, pacing
+// requests to stay under Steam's rate limit
+type Refresher struct {
+	db           *database.Database
+	client       *MarketClient
+	maxAge       time.Duration
+	batchSize    int
+	requestDelay time.Duration
+	idleDelay    time.Duration
+	backoff      time.Duration
+}
+
+// NewRefresher creates a refresher configured from STEAM_STATS_MAX_AGE,
+// STEAM_STATS_BATCH_SIZE, STEAM_REQUEST_DELAY, STEAM_STATS_IDLE_DELAY and
+// STEAM_RATE_LIMIT_BACKOFF
+func NewRefresher(db *database.Database, client *MarketClient) *Refresher {
+	return &Refresher{
+		db:           db,
+		client:       client,
+		maxAge:       config.Duration("STEAM_STATS_MAX_AGE", 6*time.Hour),
+		batchSize:    config.Int("STEAM_STATS_BATCH_SIZE", 50),
+		requestDelay: config.Duration("STEAM_REQUEST_DELAY", 3*time.Second),
+		idleDelay:    config.Duration("STEAM_STATS_IDLE_DELAY", 10*time.Minute),
+		backoff:      config.Duration("STEAM_RATE_LIMIT_BACKOFF", 2*time.Minute),
+	}
+}
+
+// Start refreshes statistics in the background until the process exits
+func (r *Refresher) Start() {
+	go func() {
+		for {
+			if refreshed := r.RefreshBatch(); refreshed == 0 {
+				time.Sleep(r.idleDelay)
+			}
+		}
+	}()
+}
+
+// RefreshBatch refreshes the stalest batch of skins and returns how many
+// were updated
+func (r *Refresher) RefreshBatch() int {
+	skins, err := r.db.GetSkinsNeedingSteamStats(r.maxAge, r.batchSize)
+	if err != nil {
+		log.Printf("Error loading skins for Steam stats: %v", err)
+		return 0
+	}
+
+	refreshed := 0
+	for _, skin := range skins {
+		stats, err := r.client.Stats(skin.MarketHashName)
+		for errors.Is(err, ErrRateLimited) {
+			// Retry the same skin once the limit lifts rather than leaving
+			// it for the next batch
+			log.Printf("Steam rate limit hit, pausing for %s", r.backoff)
+			time.Sleep(r.backoff)
+			stats, err = r.client.Stats(skin.MarketHashName)
+		}
+		if err != nil {
+			log.Printf("Error fetching Steam stats for %s: %v", skin.MarketHashName, err)
+			// Keep the figures fetched before, only holding off retries
+			// until the skin goes stale again
+			if err := r.db.MarkSteamStatsFailed(skin.SkinID); err != nil {
+				log.Printf("Error recording failed Steam stats for %s: %v", skin.MarketHashName, err)
+			}
+			time.Sleep(r.requestDelay)
+			continue
+		}
+
+		stats.SkinID = skin.SkinID
+		if err := r.db.UpsertSteamPriceStats(stats); err != nil {
+			log.Printf("Error storing Steam stats for %s: %v", skin.MarketHashName, err)
+		} else {
+			refreshed++
+		}
+
+		time.Sleep(r.requestDelay)
+	}
+
+	if refreshed > 0 {
+		log.Printf("Refreshed Steam stats for %d skins", refreshed)
+	}
+	return refreshed
+}
@@ -0,0 +1,189 @@
+package stream
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// Event types pushed to subscribers
+const (
+	EventOpportunityAdded   = "opportunity-added"
+	EventOpportunityUpdated = "opportunity-updated"
+	EventOpportunityRemoved = "opportunity-removed"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before it is disconnected
+const subscriberBuffer = 256
+
+// Event is a change to the set of open opportunities
+type Event struct {
+	ID          int64                  `json:"id"`
+	Type        string                 `json:"type"`
+	ItemID      string                 `json:"item_id"`
+	Opportunity *arbitrage.Opportunity `json:"opportunity,omitempty"` // nil for removals
+	Time        time.Time              `json:"time"`
+}
+
+// Data returns the JSON encoding of the event
+func (e Event) Data() []byte {
+	data, _ := json.Marshal(e)
+	return data
+}
+
+// Store is the storage the hub seeds its open opportunities from
+type Store interface {
+	GetOpenOpportunities(marketplaceID string) ([]models.OpportunityRecord, error)
+}
+
+// tracked is the last known state of an open opportunity
+type tracked struct {
+	marketplaceID string
+	buyPriceUSD   float64
+	sellPriceUSD  float64
+}
+
+// Hub tracks open opportunities as scrapes process items and fans out
+// added, updated and removed events to subscribers. It implements
+// scraper.Observer.
+type Hub struct {
+	minProfitPercent float64
+
+	mu          sync.Mutex
+	open        map[string]tracked
+	subscribers map[chan Event]struct{}
+	nextID      int64
+}
+
+// NewHub creates a hub treating listings that qualify at minProfitPercent
+// (plain or sticker-adjusted, as for the tracker) as open opportunities. The
+// opportunities the tracker holds open are loaded so the first scrape only
+// emits real changes, which only holds when minProfitPercent is no lower
+// than the tracker's threshold: listings between the two were never recorded.
+func NewHub(db Store, minProfitPercent float64) *Hub {
+	h := &Hub{
+		minProfitPercent: minProfitPercent,
+		open:             make(map[string]tracked),
+		subscribers:      make(map[chan Event]struct{}),
+	}
+
+	records, err := db.GetOpenOpportunities("")
+	if err != nil {
+		log.Printf("Warning: Failed to load open opportunities for streaming: %v", err)
+		return h
+	}
+	for _, rec := range records {
+		if rec.ProfitPercent < minProfitPercent && rec.ProfitWithStickersPercent < minProfitPercent {
+			continue
+		}
+		h.open[rec.ItemID] = tracked{marketplaceID: rec.MarketplaceID, buyPriceUSD: rec.BuyPriceUSD, sellPriceUSD: rec.SellPriceUSD}
+	}
+
+	return h
+}
+
+// Subscribe registers a subscriber and returns its event channel. The
+// channel is closed when the subscriber falls too far behind or unsubscribes.
+func (h *Hub) Subscribe() chan Event {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch
+}
+
+// Unsubscribe removes a subscriber
+func (h *Hub) Unsubscribe(ch chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[ch]; ok {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// ItemProcessed emits an event when a listing becomes, changes as or stops
+// being an opportunity
+func (h *Hub) ItemProcessed(item *models.Item, opp *arbitrage.Opportunity) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev, wasOpen := h.open[item.ID]
+	isOpen := arbitrage.Qualifies(opp, h.minProfitPercent)
+
+	switch {
+	case isOpen && !wasOpen:
+		h.open[item.ID] = tracked{marketplaceID: item.MarketplaceID, buyPriceUSD: opp.BuyPriceUSD, sellPriceUSD: opp.SellPriceUSD}
+		h.publish(EventOpportunityAdded, item.ID, opp)
+	case isOpen && wasOpen:
+		h.open[item.ID] = tracked{marketplaceID: item.MarketplaceID, buyPriceUSD: opp.BuyPriceUSD, sellPriceUSD: opp.SellPriceUSD}
+		if prev.buyPriceUSD != opp.BuyPriceUSD || prev.sellPriceUSD != opp.SellPriceUSD {
+			h.publish(EventOpportunityUpdated, item.ID, opp)
+		}
+	case !isOpen && wasOpen:
+		delete(h.open, item.ID)
+		h.publish(EventOpportunityRemoved, item.ID, nil)
+	}
+}
+
+// ScrapeStarted is a no-op; the hub's state carries over failed scrapes
+// until a later one completes
+func (h *Hub) ScrapeStarted(marketplaceID string) {}
+
+// ScrapeCompleted removes the open opportunities of the marketplace whose
+// listings were invalidated in the scrape and, when the scrape was complete,
+// those not seen in it, as they are no longer listed
+func (h *Hub) ScrapeCompleted(summary *models.ScrapeSummary) {
+	seen := make(map[string]bool, len(summary.SeenItemIDs))
+	for _, id := range summary.SeenItemIDs {
+		seen[id] = true
+	}
+	invalid := make(map[string]bool, len(summary.InvalidItemIDs))
+	for _, id := range summary.InvalidItemIDs {
+		invalid[id] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for itemID, t := range h.open {
+		if t.marketplaceID != summary.MarketplaceID || seen[itemID] {
+			continue
+		}
+		if !invalid[itemID] && !summary.Complete {
+			continue
+		}
+		delete(h.open, itemID)
+		h.publish(EventOpportunityRemoved, itemID, nil)
+	}
+}
+
+// publish sends an event to every subscriber. Callers must hold h.mu.
+func (h *Hub) publish(eventType, itemID string, opp *arbitrage.Opportunity) {
+	h.nextID++
+	event := Event{
+		ID:          h.nextID,
+		Type:        eventType,
+		ItemID:      itemID,
+		Opportunity: opp,
+		Time:        time.Now().UTC(),
+	}
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Drop subscribers that can't keep up; clients reconnect and reload
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// fakeStore serves a fixed set of open opportunities
+type fakeStore []models.OpportunityRecord
+
+func (s fakeStore) GetOpenOpportunities(marketplaceID string) ([]models.OpportunityRecord, error) {
+	return s, nil
+}
+
+func item(id string) *models.Item {
+	return &models.Item{ID: id, MarketplaceID: "mp"}
+}
+
+func opportunity(buy, sell float64) *arbitrage.Opportunity {
+	profit := (sell - buy) / buy * 100
+	return &arbitrage.Opportunity{BuyPriceUSD: buy, SellPriceUSD: sell, ProfitPercent: profit, ProfitWithStickersPercent: profit}
+}
+
+// drain returns the events published so far as "type:item" strings, stopping
+// at a closed channel
+func drain(ch chan Event) []string {
+	var events []string
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, e.Type+":"+e.ItemID)
+		default:
+			return events
+		}
+	}
+}
+
+func assertEvents(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("events = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestHubItemProcessed(t *testing.T) {
+	h := NewHub(fakeStore(nil), 10)
+	ch := h.Subscribe()
+
+	h.ItemProcessed(item("a"), opportunity(100, 120))
+	h.ItemProcessed(item("a"), opportunity(100, 120)) // Unchanged
+	h.ItemProcessed(item("a"), opportunity(100, 125))
+	h.ItemProcessed(item("a"), opportunity(100, 105)) // Below the threshold
+	h.ItemProcessed(item("b"), opportunity(100, 105))
+	h.ItemProcessed(item("b"), nil)
+
+	stickered := opportunity(100, 102)
+	stickered.ProfitWithStickersPercent = 30
+	h.ItemProcessed(item("c"), stickered)
+
+	assertEvents(t, drain(ch),
+		EventOpportunityAdded+":a",
+		EventOpportunityUpdated+":a",
+		EventOpportunityRemoved+":a",
+		EventOpportunityAdded+":c",
+	)
+}
+
+func TestHubSeededFromTracker(t *testing.T) {
+	h := NewHub(fakeStore{
+		{ItemID: "a", MarketplaceID: "mp", BuyPriceUSD: 100, SellPriceUSD: 120, ProfitPercent: 20},
+		{ItemID: "low", MarketplaceID: "mp", BuyPriceUSD: 100, SellPriceUSD: 105, ProfitPercent: 5},
+	}, 10)
+	ch := h.Subscribe()
+
+	// Known opportunities at their recorded prices aren't announced again
+	h.ItemProcessed(item("a"), opportunity(100, 120))
+	assertEvents(t, drain(ch))
+
+	// Those below the hub's threshold aren't open
+	h.ItemProcessed(item("low"), opportunity(100, 105))
+	assertEvents(t, drain(ch))
+}
+
+func TestHubSeededWithStickerOnlyOpportunity(t *testing.T) {
+	h := NewHub(fakeStore{
+		{ItemID: "c", MarketplaceID: "mp", BuyPriceUSD: 100, SellPriceUSD: 102, ProfitPercent: 2, ProfitWithStickersPercent: 30},
+	}, 10)
+	ch := h.Subscribe()
+
+	// The tracker recorded it through its stickers, so it's already open
+	stickered := opportunity(100, 102)
+	stickered.ProfitWithStickersPercent = 30
+	h.ItemProcessed(item("c"), stickered)
+	assertEvents(t, drain(ch))
+}
+
+func TestHubScrapeCompleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary models.ScrapeSummary
+		want    []string
+	}{
+		{
+			name:    "complete scrape removes unseen",
+			summary: models.ScrapeSummary{MarketplaceID: "mp", SeenItemIDs: []string{"a"}, Complete: true},
+			want:    []string{EventOpportunityRemoved + ":b"},
+		},
+		{
+			name:    "partial scrape keeps unseen",
+			summary: models.ScrapeSummary{MarketplaceID: "mp", SeenItemIDs: []string{"a"}},
+		},
+		{
+			name:    "partial scrape removes invalidated",
+			summary: models.ScrapeSummary{MarketplaceID: "mp", InvalidItemIDs: []string{"b"}},
+			want:    []string{EventOpportunityRemoved + ":b"},
+		},
+		{
+			name:    "other marketplace",
+			summary: models.ScrapeSummary{MarketplaceID: "other", Complete: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHub(fakeStore(nil), 10)
+			h.ItemProcessed(item("a"), opportunity(100, 120))
+			h.ItemProcessed(item("b"), opportunity(100, 130))
+			ch := h.Subscribe()
+
+			h.ScrapeCompleted(&tt.summary)
+			assertEvents(t, drain(ch), tt.want...)
+		})
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	h := NewHub(fakeStore(nil), 10)
+	ch := h.Subscribe()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		h.ItemProcessed(item("a"), opportunity(100, 120+float64(i)))
+	}
+
+	if got := len(drain(ch)); got != subscriberBuffer {
+		t.Errorf("buffered %d events, want %d", got, subscriberBuffer)
+	}
+	if _, ok := <-ch; ok {
+		t.Error("slow subscriber's channel is still open")
+	}
+}
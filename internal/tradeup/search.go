@@ -0,0 +1,171 @@
+package tradeup
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mswatii/cs2-arbitrage/internal/arbitrage"
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+)
+
+// floatCaps are the normalized float ceilings tried for each group of
+// inputs. Lower caps cost more but can push outcomes into a better wear.
+var floatCaps = []float64{0.05, 0.1, 0.2, 0.3, 0.5, 1.0}
+
+// Filter narrows a trade-up search
+type Filter struct {
+	MinProfitPercent float64
+	StatTrak         *bool // nil searches both
+	Limit            int
+}
+
+// Evaluate prices a contract made of specific listings, each used once
+func Evaluate(db *database.Database, itemIDs []string) (*Contract, error) {
+	seen := make(map[string]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("%w: item %s is listed more than once", ErrInvalid, id)
+		}
+		seen[id] = true
+	}
+
+	candidates, err := db.GetTradeUpCandidatesByID(itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]database.TradeUpCandidate, len(candidates))
+	for _, c := range candidates {
+		byID[c.ItemID] = c
+	}
+
+	collections := catalog.DefaultCollections()
+	inputs := make([]Input, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		c, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: item %s not found or has no float", ErrInvalid, id)
+		}
+		in, ok := NewInput(c, collections)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not in a known collection", ErrInvalid, c.MarketHashName)
+		}
+		inputs = append(inputs, in)
+	}
+
+	contract, err := Outline(inputs, collections)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := db.GetReferencePrices(contract.OutcomeNames())
+	if err != nil {
+		return nil, err
+	}
+	contract.Price(prices, arbitrage.SteamSaleFeePercent())
+
+	return contract, nil
+}
+
+// Search looks for profitable contracts among current listings. Inputs are
+// grouped by collection, rarity and StatTrak, and for each group the
+// cheapest listings under each float cap form a candidate contract.
+func Search(db *database.Database, filter Filter) ([]Contract, error) {
+	candidates, err := db.GetTradeUpCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	collections := catalog.DefaultCollections()
+	groups := make(map[string][]Input)
+	for _, c := range candidates {
+		in, ok := NewInput(c, collections)
+		if !ok {
+			continue
+		}
+		if filter.StatTrak != nil && in.StatTrak != *filter.StatTrak {
+			continue
+		}
+		if _, ok := collections.NextRarity(in.Rarity); !ok {
+			continue
+		}
+		key := fmt.Sprintf("%s|%s|%t", in.Collection, in.Rarity, in.StatTrak)
+		groups[key] = append(groups[key], in)
+	}
+
+	var contracts []*Contract
+	for _, inputs := range groups {
+		if len(inputs) < ContractSize {
+			continue
+		}
+		sort.Slice(inputs, func(i, j int) bool { return inputs[i].PriceUSD < inputs[j].PriceUSD })
+
+		seen := make(map[string]bool)
+		for _, maxFloat := range floatCaps {
+			picked := make([]Input, 0, ContractSize)
+			for _, in := range inputs {
+				if in.NormalizedFloat() <= maxFloat {
+					picked = append(picked, in)
+					if len(picked) == ContractSize {
+						break
+					}
+				}
+			}
+			if len(picked) < ContractSize {
+				continue
+			}
+
+			// Looser caps often pick the same listings
+			key := contractKey(picked)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			contract, err := Outline(picked, collections)
+			if err != nil {
+				continue
+			}
+			contracts = append(contracts, contract)
+		}
+	}
+
+	var names []string
+	for _, c := range contracts {
+		names = append(names, c.OutcomeNames()...)
+	}
+	prices, err := db.GetReferencePrices(names)
+	if err != nil {
+		return nil, err
+	}
+
+	saleFee := arbitrage.SteamSaleFeePercent()
+	results := make([]Contract, 0)
+	for _, c := range contracts {
+		c.Price(prices, saleFee)
+		if c.UnpricedProbability >= 1 || c.ExpectedProfitPercent < filter.MinProfitPercent {
+			continue
+		}
+		results = append(results, *c)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ExpectedProfitUSD > results[j].ExpectedProfitUSD
+	})
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+
+	return results, nil
+}
+
+// contractKey identifies a set of inputs
+func contractKey(inputs []Input) string {
+	ids := make([]string, len(inputs))
+	for i, in := range inputs {
+		ids[i] = in.ItemID
+	}
+	sort.Strings(ids)
+	return fmt.Sprint(ids)
+}
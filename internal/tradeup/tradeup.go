@@ -0,0 +1,229 @@
+package tradeup
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
+	"github.com/mswatii/cs2-arbitrage/internal/database"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// ContractSize is the number of items a trade-up contract consumes
+const ContractSize = 10
+
+// ErrInvalid is wrapped by errors for contracts that can't be made, which
+// callers can map to client errors
+var ErrInvalid = errors.New("invalid trade-up")
+
+// Input is a listing used as a trade-up input
+type Input struct {
+	ItemID         string  `json:"item_id"`
+	MarketHashName string  `json:"market_hash_name"`
+	Marketplace    string  `json:"marketplace"`
+	Collection     string  `json:"collection"`
+	Rarity         string  `json:"rarity"`
+	StatTrak       bool    `json:"stattrak"`
+	Float          float64 `json:"float"`
+	MinFloat       float64 `json:"min_float"`
+	MaxFloat       float64 `json:"max_float"`
+	PriceUSD       float64 `json:"price_usd"`
+}
+
+// NormalizedFloat returns where the input's float sits within its skin's
+// float range, which is what carries over into the output
+func (in Input) NormalizedFloat() float64 {
+	if in.MaxFloat <= in.MinFloat {
+		return in.Float
+	}
+	return math.Max(0, math.Min(1, (in.Float-in.MinFloat)/(in.MaxFloat-in.MinFloat)))
+}
+
+// Outcome is one skin a contract can produce
+type Outcome struct {
+	MarketHashName string   `json:"market_hash_name"`
+	Collection     string   `json:"collection"`
+	Probability    float64  `json:"probability"`
+	Float          float64  `json:"float"`
+	Wear           string   `json:"wear"`
+	PriceUSD       *float64 `json:"price_usd"`     // Reference price, nil when unknown
+	NetPriceUSD    *float64 `json:"net_price_usd"` // After the Steam sale fee
+}
+
+// Contract is an evaluated trade-up contract
+type Contract struct {
+	Rarity                string    `json:"rarity"`
+	OutputRarity          string    `json:"output_rarity"`
+	StatTrak              bool      `json:"stattrak"`
+	Inputs                []Input   `json:"inputs"`
+	Outcomes              []Outcome `json:"outcomes"`
+	AverageFloat          float64   `json:"average_normalized_float"`
+	CostUSD               float64   `json:"cost_usd"`
+	ExpectedValueUSD      float64   `json:"expected_value_usd"` // Net of the Steam sale fee
+	ExpectedProfitUSD     float64   `json:"expected_profit_usd"`
+	ExpectedProfitPercent float64   `json:"expected_profit_percent"`
+	ProfitChance          float64   `json:"profit_chance"`        // Probability the outcome is worth more than the cost
+	UnpricedProbability   float64   `json:"unpriced_probability"` // Probability of an outcome without a price
+}
+
+// NewInput resolves a listing's collection, rarity and float range. It
+// returns false when the skin isn't in the collections dataset.
+func NewInput(c database.TradeUpCandidate, collections *catalog.Collections) (Input, bool) {
+	name := catalog.ParseMarketHashName(c.MarketHashName)
+	if name.Souvenir || name.Star {
+		return Input{}, false
+	}
+
+	collection, skin, ok := collections.Lookup(name.Weapon, name.Finish)
+	if !ok {
+		return Input{}, false
+	}
+
+	in := Input{
+		ItemID:         c.ItemID,
+		MarketHashName: c.MarketHashName,
+		Marketplace:    c.Marketplace,
+		Collection:     collection,
+		Rarity:         skin.Rarity,
+		StatTrak:       c.IsStatTrak || name.StatTrak,
+		Float:          c.Float,
+		PriceUSD:       c.PriceUSD,
+	}
+	in.MinFloat, in.MaxFloat = floatRange(name.Weapon, name.Finish, skin)
+	if c.MinFloat != nil && c.MaxFloat != nil && *c.MaxFloat > *c.MinFloat {
+		in.MinFloat, in.MaxFloat = *c.MinFloat, *c.MaxFloat
+	}
+
+	return in, true
+}
+
+// floatRange returns a skin's float range from the collections dataset,
+// then the catalog, falling back to the full 0-1 range
+func floatRange(weapon, finish string, skin catalog.CollectionSkin) (float64, float64) {
+	if skin.MaxFloat > skin.MinFloat {
+		return skin.MinFloat, skin.MaxFloat
+	}
+	if minFloat, maxFloat, ok := catalog.Default().FloatRange(weapon, finish); ok && maxFloat > minFloat {
+		return minFloat, maxFloat
+	}
+	return 0, 1
+}
+
+// Outline works out a contract's possible outcomes without pricing them,
+// so the outcome names can be priced in one batch
+func Outline(inputs []Input, collections *catalog.Collections) (*Contract, error) {
+	if len(inputs) != ContractSize {
+		return nil, fmt.Errorf("%w: a trade-up needs exactly %d inputs, got %d", ErrInvalid, ContractSize, len(inputs))
+	}
+
+	rarity, statTrak := inputs[0].Rarity, inputs[0].StatTrak
+	var cost, floatSum float64
+	for _, in := range inputs {
+		if in.Rarity != rarity {
+			return nil, fmt.Errorf("%w: all inputs must share a rarity: %s is %s, expected %s", ErrInvalid, in.MarketHashName, in.Rarity, rarity)
+		}
+		if in.StatTrak != statTrak {
+			return nil, fmt.Errorf("%w: StatTrak and non-StatTrak inputs can't be mixed", ErrInvalid)
+		}
+		cost += in.PriceUSD
+		floatSum += in.NormalizedFloat()
+	}
+
+	outputRarity, ok := collections.NextRarity(rarity)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s items can't be traded up", ErrInvalid, rarity)
+	}
+
+	contract := &Contract{
+		Rarity:       rarity,
+		OutputRarity: outputRarity,
+		StatTrak:     statTrak,
+		Inputs:       inputs,
+		AverageFloat: floatSum / float64(len(inputs)),
+		CostUSD:      cost,
+	}
+
+	// Every input adds a ticket for each skin of its collection at the next
+	// rarity and one ticket is drawn, so a skin's chance is the number of
+	// inputs from its collection over the total tickets
+	fromCollection := make(map[string]int)
+	tickets := 0
+	for _, in := range inputs {
+		skins := collections.Outcomes(in.Collection, outputRarity)
+		if len(skins) == 0 {
+			return nil, fmt.Errorf("%w: %s has no %s skins to trade up into", ErrInvalid, in.Collection, outputRarity)
+		}
+		fromCollection[in.Collection]++
+		tickets += len(skins)
+	}
+
+	var order []string
+	byName := make(map[string]Outcome)
+	for _, in := range inputs {
+		for _, skin := range collections.Outcomes(in.Collection, outputRarity) {
+			minFloat, maxFloat := floatRange(skin.Weapon, skin.Finish, skin)
+			outFloat := minFloat + contract.AverageFloat*(maxFloat-minFloat)
+			wear := models.GetWearCategory(outFloat)
+
+			name := fmt.Sprintf("%s | %s (%s)", skin.Weapon, skin.Finish, wear)
+			if statTrak {
+				name = "StatTrak™ " + name
+			}
+			if _, seen := byName[name]; seen {
+				continue
+			}
+			order = append(order, name)
+			byName[name] = Outcome{
+				MarketHashName: name,
+				Collection:     in.Collection,
+				Probability:    float64(fromCollection[in.Collection]) / float64(tickets),
+				Float:          outFloat,
+				Wear:           wear,
+			}
+		}
+	}
+
+	for _, name := range order {
+		contract.Outcomes = append(contract.Outcomes, byName[name])
+	}
+
+	return contract, nil
+}
+
+// Price fills in outcome prices and the contract's expected value from
+// reference prices keyed by market hash name
+func (c *Contract) Price(prices map[string]float64, saleFeePercent float64) {
+	c.ExpectedValueUSD = 0
+	c.ProfitChance = 0
+	c.UnpricedProbability = 0
+	for i := range c.Outcomes {
+		o := &c.Outcomes[i]
+		price, ok := prices[o.MarketHashName]
+		if !ok || price <= 0 {
+			o.PriceUSD, o.NetPriceUSD = nil, nil
+			c.UnpricedProbability += o.Probability
+			continue
+		}
+		net := price * (1 - saleFeePercent/100)
+		o.PriceUSD, o.NetPriceUSD = &price, &net
+		c.ExpectedValueUSD += o.Probability * net
+		if net > c.CostUSD {
+			c.ProfitChance += o.Probability
+		}
+	}
+
+	c.ExpectedProfitUSD = c.ExpectedValueUSD - c.CostUSD
+	if c.CostUSD > 0 {
+		c.ExpectedProfitPercent = c.ExpectedProfitUSD / c.CostUSD * 100
+	}
+}
+
+// OutcomeNames returns the market hash names of a contract's outcomes
+func (c *Contract) OutcomeNames() []string {
+	names := make([]string, len(c.Outcomes))
+	for i, o := range c.Outcomes {
+		names[i] = o.MarketHashName
+	}
+	return names
+}
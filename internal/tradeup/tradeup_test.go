@@ -0,0 +1,166 @@
+package tradeup
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
+)
+
+const testCollections = `{
+	"rarities": ["Mil-Spec", "Restricted", "Classified"],
+	"collections": [
+		{"name": "Alpha", "skins": [
+			{"weapon": "AK-47", "finish": "One", "rarity": "Restricted", "min_float": 0, "max_float": 1},
+			{"weapon": "AK-47", "finish": "Two", "rarity": "Restricted", "min_float": 0, "max_float": 1},
+			{"weapon": "AK-47", "finish": "Input", "rarity": "Mil-Spec", "min_float": 0, "max_float": 1}
+		]},
+		{"name": "Beta", "skins": [
+			{"weapon": "AWP", "finish": "Only", "rarity": "Restricted", "min_float": 0.1, "max_float": 0.5},
+			{"weapon": "AWP", "finish": "Input", "rarity": "Mil-Spec", "min_float": 0, "max_float": 1}
+		]},
+		{"name": "Gamma", "skins": [
+			{"weapon": "P90", "finish": "Dead End", "rarity": "Mil-Spec", "min_float": 0, "max_float": 1}
+		]}
+	]
+}`
+
+func testInputs(t *testing.T, fromAlpha int) ([]Input, *catalog.Collections) {
+	t.Helper()
+	collections, err := catalog.LoadCollections([]byte(testCollections))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := make([]Input, ContractSize)
+	for i := range inputs {
+		inputs[i] = Input{MarketHashName: "AWP | Input (Minimal Wear)", Collection: "Beta", Rarity: "Mil-Spec", Float: 0.2, MaxFloat: 1, PriceUSD: 1}
+		if i < fromAlpha {
+			inputs[i].MarketHashName = "AK-47 | Input (Minimal Wear)"
+			inputs[i].Collection = "Alpha"
+		}
+	}
+	return inputs, collections
+}
+
+func TestNormalizedFloat(t *testing.T) {
+	tests := []struct {
+		in   Input
+		want float64
+	}{
+		{Input{Float: 0.25, MinFloat: 0, MaxFloat: 1}, 0.25},
+		{Input{Float: 0.3, MinFloat: 0.1, MaxFloat: 0.5}, 0.5},
+		{Input{Float: 0.05, MinFloat: 0.1, MaxFloat: 0.5}, 0},
+		{Input{Float: 0.9, MinFloat: 0.1, MaxFloat: 0.5}, 1},
+		{Input{Float: 0.3}, 0.3}, // Unknown range
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.NormalizedFloat(); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("NormalizedFloat(%v in %v-%v) = %v, want %v", tt.in.Float, tt.in.MinFloat, tt.in.MaxFloat, got, tt.want)
+		}
+	}
+}
+
+func TestOutline(t *testing.T) {
+	// 4 Alpha inputs with 2 outcomes each and 6 Beta inputs with 1 make 14
+	// tickets: each Alpha skin has 4 and the Beta skin 6
+	inputs, collections := testInputs(t, 4)
+	contract, err := Outline(inputs, collections)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{
+		"AK-47 | One (Field-Tested)": 4.0 / 14,
+		"AK-47 | Two (Field-Tested)": 4.0 / 14,
+		"AWP | Only (Field-Tested)":  6.0 / 14, // Float 0.1 + 0.2 * 0.4 = 0.18
+	}
+	if len(contract.Outcomes) != len(want) {
+		t.Fatalf("outcomes = %+v, want %d", contract.Outcomes, len(want))
+	}
+	total := 0.0
+	for _, o := range contract.Outcomes {
+		if math.Abs(o.Probability-want[o.MarketHashName]) > 1e-9 {
+			t.Errorf("P(%s) = %v, want %v", o.MarketHashName, o.Probability, want[o.MarketHashName])
+		}
+		total += o.Probability
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("probabilities sum to %v", total)
+	}
+	if contract.OutputRarity != "Restricted" || contract.CostUSD != 10 || math.Abs(contract.AverageFloat-0.2) > 1e-9 {
+		t.Errorf("output rarity %s, cost %v, average float %v", contract.OutputRarity, contract.CostUSD, contract.AverageFloat)
+	}
+
+	// StatTrak contracts produce StatTrak outcomes
+	for i := range inputs {
+		inputs[i].StatTrak = true
+	}
+	contract, err = Outline(inputs, collections)
+	if err != nil || contract.Outcomes[0].MarketHashName != "StatTrak™ AK-47 | One (Field-Tested)" {
+		t.Errorf("StatTrak outcome = %v, %v", contract.Outcomes, err)
+	}
+}
+
+func TestOutlineInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func([]Input) []Input
+	}{
+		{"too few inputs", func(in []Input) []Input { return in[:9] }},
+		{"mixed rarities", func(in []Input) []Input { in[3].Rarity = "Restricted"; return in }},
+		{"mixed StatTrak", func(in []Input) []Input { in[3].StatTrak = true; return in }},
+		{"top rarity", func(in []Input) []Input {
+			for i := range in {
+				in[i].Rarity = "Classified"
+			}
+			return in
+		}},
+		{"collection without outcomes", func(in []Input) []Input { in[0].Collection = "Gamma"; return in }},
+	}
+
+	for _, tt := range tests {
+		inputs, collections := testInputs(t, 4)
+		if _, err := Outline(tt.modify(inputs), collections); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: error = %v, want ErrInvalid", tt.name, err)
+		}
+	}
+}
+
+func TestPrice(t *testing.T) {
+	inputs, collections := testInputs(t, 4)
+	contract, err := Outline(inputs, collections)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract.Price(map[string]float64{
+		"AK-47 | One (Field-Tested)": 20,
+		"AWP | Only (Field-Tested)":  5,
+	}, 10)
+
+	// 18 net at 4/14, 4.5 net at 6/14 and nothing for the unpriced outcome
+	wantValue := 18*4.0/14 + 4.5*6.0/14
+	if math.Abs(contract.ExpectedValueUSD-wantValue) > 1e-9 {
+		t.Errorf("expected value = %v, want %v", contract.ExpectedValueUSD, wantValue)
+	}
+	if math.Abs(contract.ExpectedProfitUSD-(wantValue-10)) > 1e-9 || math.Abs(contract.ExpectedProfitPercent-(wantValue-10)*10) > 1e-9 {
+		t.Errorf("expected profit = %v (%v%%)", contract.ExpectedProfitUSD, contract.ExpectedProfitPercent)
+	}
+	if math.Abs(contract.ProfitChance-4.0/14) > 1e-9 || math.Abs(contract.UnpricedProbability-4.0/14) > 1e-9 {
+		t.Errorf("profit chance %v, unpriced %v, want %v each", contract.ProfitChance, contract.UnpricedProbability, 4.0/14)
+	}
+	for _, o := range contract.Outcomes {
+		if (o.PriceUSD == nil) != (o.MarketHashName == "AK-47 | Two (Field-Tested)") {
+			t.Errorf("%s priced at %v", o.MarketHashName, o.PriceUSD)
+		}
+	}
+
+	// Pricing again starts over
+	contract.Price(map[string]float64{}, 10)
+	if contract.ExpectedValueUSD != 0 || math.Abs(contract.UnpricedProbability-1) > 1e-9 {
+		t.Errorf("repriced without prices: value %v, unpriced %v", contract.ExpectedValueUSD, contract.UnpricedProbability)
+	}
+}
@@ -0,0 +1,108 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+)
+
+// Validation rules. An item failing any of them is quarantined.
+const (
+	RuleUnparsable             = "unparsable"               // A field could not be parsed
+	RulePriceNotPositive       = "price_not_positive"       // Price is zero or negative
+	RuleFloatOutOfRange        = "float_out_of_range"       // Float outside the skin's paint range
+	RuleWearMismatch           = "wear_mismatch"            // Float doesn't match the wear in the name
+	RuleSteamPriceInconsistent = "steam_price_inconsistent" // Steam price far from its history
+)
+
+// Issue is a failed validation rule
+type Issue struct {
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// Issuef builds an issue with a formatted reason
+func Issuef(rule, format string, args ...interface{}) Issue {
+	return Issue{Rule: rule, Reason: fmt.Sprintf(format, args...)}
+}
+
+// Store is the storage the validator needs. *database.Database satisfies
+// it, and tests supply their own.
+type Store interface {
+	GetSteamReferencePrice(skinID string) (float64, error)
+}
+
+// Validator checks scraped items before they are stored as tradable
+type Validator struct {
+	db Store
+	// FloatTolerance absorbs rounding in floats reported by marketplaces
+	FloatTolerance float64
+	// MaxSteamPriceRatio is how far, as a multiple either way, a reported
+	// Steam price may stray from the skin's Steam price history
+	MaxSteamPriceRatio float64
+}
+
+// NewValidator creates a validator configured from VALIDATION_FLOAT_TOLERANCE
+// and VALIDATION_MAX_STEAM_PRICE_RATIO
+func NewValidator(db Store) *Validator {
+	return &Validator{
+		db:                 db,
+		FloatTolerance:     config.Float("VALIDATION_FLOAT_TOLERANCE", 0.000001),
+		MaxSteamPriceRatio: config.Float("VALIDATION_MAX_STEAM_PRICE_RATIO", 3),
+	}
+}
+
+// Validate checks an item against its skin and returns every rule it fails.
+// A float of 0 is a missing one, as no skin reaches exactly 0, so it isn't
+// checked.
+func (v *Validator) Validate(item *models.Item, skin *models.Skin) []Issue {
+	var issues []Issue
+
+	if item.Price <= 0 || item.PriceUSD <= 0 {
+		issues = append(issues, Issuef(RulePriceNotPositive, "price is %.2f (%.2f USD)", item.Price, item.PriceUSD))
+	}
+
+	if item.Float != nil && *item.Float > 0 {
+		issues = append(issues, v.checkFloat(*item.Float, skin)...)
+	}
+
+	if item.SteamPriceUSD > 0 && v.db != nil && v.MaxSteamPriceRatio > 1 {
+		reference, err := v.db.GetSteamReferencePrice(item.SkinID)
+		if err == nil && reference > 0 {
+			ratio := item.SteamPriceUSD / reference
+			if ratio > v.MaxSteamPriceRatio || ratio < 1/v.MaxSteamPriceRatio {
+				issues = append(issues, Issuef(RuleSteamPriceInconsistent,
+					"Steam price %.2f USD is %.1fx the usual %.2f USD", item.SteamPriceUSD, ratio, reference))
+			}
+		}
+	}
+
+	return issues
+}
+
+// checkFloat checks a float against the skin's paint range and the wear
+// named in its market hash name
+func (v *Validator) checkFloat(floatValue float64, skin *models.Skin) []Issue {
+	var issues []Issue
+
+	minFloat, maxFloat := 0.0, 1.0
+	if skin.MinFloat != nil && skin.MaxFloat != nil {
+		minFloat, maxFloat = *skin.MinFloat, *skin.MaxFloat
+	}
+	if floatValue < minFloat-v.FloatTolerance || floatValue > maxFloat+v.FloatTolerance {
+		issues = append(issues, Issuef(RuleFloatOutOfRange,
+			"float %.6f is outside %.2f-%.2f", floatValue, minFloat, maxFloat))
+	}
+
+	// The name is authoritative for the wear
+	if wear := catalog.ParseMarketHashName(skin.MarketHashName).Wear; wear != "" {
+		if actual := models.GetWearCategory(floatValue); actual != wear {
+			issues = append(issues, Issuef(RuleWearMismatch,
+				"float %.6f is %s but the item is listed as %s", floatValue, actual, wear))
+		}
+	}
+
+	return issues
+}
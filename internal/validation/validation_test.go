@@ -0,0 +1,137 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+// referencePrices knows the Steam reference price of some skins
+type referencePrices map[string]float64
+
+func (p referencePrices) GetSteamReferencePrice(skinID string) (float64, error) {
+	return p[skinID], nil
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+// redline returns the AK-47 | Redline in the given wear, whose paint range is
+// 0.10-0.70
+func redline(wear string) *models.Skin {
+	return &models.Skin{
+		ID:             "redline",
+		MarketHashName: "AK-47 | Redline (" + wear + ")",
+		MinFloat:       floatPtr(0.10),
+		MaxFloat:       floatPtr(0.70),
+	}
+}
+
+func newTestValidator() *Validator {
+	return &Validator{
+		db:                 referencePrices{"redline": 10},
+		FloatTolerance:     0.000001,
+		MaxSteamPriceRatio: 3,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		wear  string
+		item  models.Item
+		rules []string
+	}{
+		{
+			name: "valid listing",
+			wear: "Field-Tested",
+			item: models.Item{Price: 100, PriceUSD: 10, Float: floatPtr(0.20)},
+		},
+		{
+			name:  "float above the paint range",
+			wear:  "Battle-Scarred",
+			item:  models.Item{Price: 100, PriceUSD: 10, Float: floatPtr(0.75)},
+			rules: []string{RuleFloatOutOfRange},
+		},
+		{
+			name: "float within the tolerance of the paint range",
+			wear: "Battle-Scarred",
+			item: models.Item{Price: 100, PriceUSD: 10, Float: floatPtr(0.7000005)},
+		},
+		{
+			name:  "float just past the tolerance",
+			wear:  "Battle-Scarred",
+			item:  models.Item{Price: 100, PriceUSD: 10, Float: floatPtr(0.70001)},
+			rules: []string{RuleFloatOutOfRange},
+		},
+		{
+			name: "float on the lower bound of its wear",
+			wear: "Field-Tested",
+			item: models.Item{Price: 100, PriceUSD: 10, Float: floatPtr(0.15)},
+		},
+		{
+			name:  "float just below its wear",
+			wear:  "Field-Tested",
+			item:  models.Item{Price: 100, PriceUSD: 10, Float: floatPtr(0.1499)},
+			rules: []string{RuleWearMismatch},
+		},
+		{
+			name: "zero float is unknown",
+			wear: "Field-Tested",
+			item: models.Item{Price: 100, PriceUSD: 10, Float: floatPtr(0)},
+		},
+		{
+			name: "missing float",
+			wear: "Field-Tested",
+			item: models.Item{Price: 100, PriceUSD: 10},
+		},
+		{
+			name:  "zero price",
+			wear:  "Field-Tested",
+			item:  models.Item{Price: 0, PriceUSD: 0, Float: floatPtr(0.20)},
+			rules: []string{RulePriceNotPositive},
+		},
+		{
+			name: "Steam price within the ratio",
+			wear: "Field-Tested",
+			item: models.Item{Price: 100, PriceUSD: 10, SteamPriceUSD: 29},
+		},
+		{
+			name:  "Steam price far above its history",
+			wear:  "Field-Tested",
+			item:  models.Item{Price: 100, PriceUSD: 10, SteamPriceUSD: 31},
+			rules: []string{RuleSteamPriceInconsistent},
+		},
+		{
+			name:  "Steam price far below its history",
+			wear:  "Field-Tested",
+			item:  models.Item{Price: 100, PriceUSD: 10, SteamPriceUSD: 3},
+			rules: []string{RuleSteamPriceInconsistent},
+		},
+	}
+
+	v := newTestValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := tt.item
+			item.SkinID = "redline"
+			issues := v.Validate(&item, redline(tt.wear))
+
+			if len(issues) != len(tt.rules) {
+				t.Fatalf("issues = %+v, want rules %v", issues, tt.rules)
+			}
+			for i, issue := range issues {
+				if issue.Rule != tt.rules[i] {
+					t.Errorf("issue %d rule = %s, want %s", i, issue.Rule, tt.rules[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateWithoutSteamHistory(t *testing.T) {
+	v := newTestValidator()
+	item := &models.Item{SkinID: "unknown", Price: 100, PriceUSD: 10, SteamPriceUSD: 1000}
+	if issues := v.Validate(item, redline("Field-Tested")); len(issues) != 0 {
+		t.Errorf("issues = %+v, want none without a reference price", issues)
+	}
+}
@@ -0,0 +1,68 @@
+package valuation
+
+import (
+	"math"
+
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+)
+
+// CapitalModel prices the time money is tied up in a flip: the trade hold
+// before the item can be listed on Steam plus the expected time to sell it
+type CapitalModel struct {
+	AnnualCostPercent float64 // Yearly cost of capital, e.g. the return forgone elsewhere
+	DefaultSaleDays   float64 // Expected days to sell when the Steam volume is unknown
+	MaxSaleDays       float64 // Cap on the expected days to sell thinly traded items
+}
+
+// CapitalModelFromEnv builds a capital model from CAPITAL_COST_ANNUAL_PERCENT,
+// DEFAULT_SALE_DAYS and MAX_SALE_DAYS
+func CapitalModelFromEnv() CapitalModel {
+	return CapitalModel{
+		AnnualCostPercent: config.Float("CAPITAL_COST_ANNUAL_PERCENT", 30),
+		DefaultSaleDays:   config.Float("DEFAULT_SALE_DAYS", 1),
+		MaxSaleDays:       config.Float("MAX_SALE_DAYS", 30),
+	}
+}
+
+// CapitalValuation is the cost of the capital locked up by a flip
+type CapitalValuation struct {
+	SaleDays                float64 // Expected days to sell once listed
+	TotalDays               float64 // Trade hold plus sale days
+	CostUSD                 float64
+	AnnualizedReturnPercent float64
+}
+
+// Value computes the capital cost and annualized return of buying at
+// buyPriceUSD for netProfitUSD after holdDays of trade lock. With a known
+// daily volume a new listing is expected to sell once the buyers have worked
+// through the listings already up, when their count is known, and then it.
+// The annualized return is simple, not compounded, so short flips stay
+// comparable instead of exploding.
+func (m CapitalModel) Value(buyPriceUSD, netProfitUSD, holdDays float64, dailyVolume, listingCount *int) CapitalValuation {
+	saleDays := m.DefaultSaleDays
+	if dailyVolume != nil {
+		if *dailyVolume > 0 {
+			backlog := 0
+			if listingCount != nil && *listingCount > 0 {
+				backlog = *listingCount
+			}
+			saleDays = float64(backlog+1) / float64(*dailyVolume)
+		} else {
+			saleDays = m.MaxSaleDays
+		}
+	}
+	saleDays = math.Min(saleDays, m.MaxSaleDays)
+
+	// Count at least a day so same-day flips don't annualize to absurd figures
+	totalDays := math.Max(1, holdDays+saleDays)
+
+	v := CapitalValuation{
+		SaleDays:  saleDays,
+		TotalDays: totalDays,
+		CostUSD:   buyPriceUSD * m.AnnualCostPercent / 100 * totalDays / 365,
+	}
+	if buyPriceUSD > 0 {
+		v.AnnualizedReturnPercent = netProfitUSD / buyPriceUSD * 100 * 365 / totalDays
+	}
+	return v
+}
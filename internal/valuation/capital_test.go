@@ -0,0 +1,38 @@
+package valuation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCapitalModelValue(t *testing.T) {
+	m := CapitalModel{AnnualCostPercent: 36.5, DefaultSaleDays: 1, MaxSaleDays: 30}
+	intPtr := func(n int) *int { return &n }
+
+	tests := []struct {
+		name          string
+		holdDays      float64
+		dailyVolume   *int
+		listingCount  *int
+		wantSaleDays  float64
+		wantTotalDays float64
+	}{
+		{"unknown volume", 0, nil, nil, 1, 1},
+		{"never sells", 0, intPtr(0), nil, 30, 30},
+		{"unknown backlog", 7, intPtr(10), nil, 0.1, 7.1},
+		{"backlog", 7, intPtr(10), intPtr(49), 5, 12},
+		{"capped backlog", 0, intPtr(1), intPtr(100), 30, 30},
+		{"same day counts a day", 0, intPtr(100), intPtr(0), 0.01, 1},
+	}
+
+	for _, tt := range tests {
+		v := m.Value(100, 10, tt.holdDays, tt.dailyVolume, tt.listingCount)
+		if math.Abs(v.SaleDays-tt.wantSaleDays) > 1e-9 || math.Abs(v.TotalDays-tt.wantTotalDays) > 1e-9 {
+			t.Errorf("%s: sale, total days = %v, %v, want %v, %v", tt.name, v.SaleDays, v.TotalDays, tt.wantSaleDays, tt.wantTotalDays)
+		}
+		// 0.1% of the price per day locked up
+		if wantCost := 0.1 * tt.wantTotalDays; math.Abs(v.CostUSD-wantCost) > 1e-9 {
+			t.Errorf("%s: cost = %v, want %v", tt.name, v.CostUSD, wantCost)
+		}
+	}
+}
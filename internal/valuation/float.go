@@ -0,0 +1,88 @@
+package valuation
+
+import (
+	"math"
+
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+)
+
+// wearRanges holds the float interval of each wear bucket
+var wearRanges = map[string][2]float64{
+	"Factory New":    {0.00, 0.07},
+	"Minimal Wear":   {0.07, 0.15},
+	"Field-Tested":   {0.15, 0.38},
+	"Well-Worn":      {0.38, 0.45},
+	"Battle-Scarred": {0.45, 1.00},
+}
+
+// WearRange returns the float interval of a wear bucket
+func WearRange(wear string) (float64, float64, bool) {
+	r, ok := wearRanges[catalog.NormalizeWear(wear)]
+	return r[0], r[1], ok
+}
+
+// FloatModel prices an item relative to its wear bucket. A reference price
+// (such as the Steam price) is assumed to reflect an average float, so the
+// lowest float achievable in the bucket earns MaxPremiumPercent and the
+// highest loses MaxDiscountPercent, interpolated linearly in between.
+type FloatModel struct {
+	MaxPremiumPercent  float64
+	MaxDiscountPercent float64
+}
+
+// FloatModelFromEnv builds a float model from FLOAT_MAX_PREMIUM_PERCENT and
+// FLOAT_MAX_DISCOUNT_PERCENT
+func FloatModelFromEnv() FloatModel {
+	return FloatModel{
+		MaxPremiumPercent:  config.Float("FLOAT_MAX_PREMIUM_PERCENT", 15),
+		MaxDiscountPercent: config.Float("FLOAT_MAX_DISCOUNT_PERCENT", 5),
+	}
+}
+
+// FloatValuation is the result of pricing an item by its float
+type FloatValuation struct {
+	Known        bool    // False when the float or wear bucket is unknown
+	Percentile   float64 // 0 is the best float achievable in the bucket, 1 the worst
+	Multiplier   float64 // Applied to the reference price
+	FairValueUSD float64
+}
+
+// Value prices an item with the given float against a reference price for
+// its wear bucket. minFloat and maxFloat are the skin's paint range; the
+// bucket is narrowed to the part of it the skin can actually reach. Items
+// without a float or wear are valued at the reference price but not Known;
+// a float of 0 is a missing one, as no skin reaches exactly 0.
+func (m FloatModel) Value(referencePriceUSD float64, floatValue, minFloat, maxFloat *float64, wear string) FloatValuation {
+	unknown := FloatValuation{Multiplier: 1, FairValueUSD: referencePriceUSD}
+
+	low, high, ok := WearRange(wear)
+	if !ok || floatValue == nil || *floatValue <= 0 {
+		return unknown
+	}
+
+	if minFloat != nil && maxFloat != nil && *maxFloat > *minFloat {
+		low = math.Max(low, *minFloat)
+		high = math.Min(high, *maxFloat)
+	}
+	if high <= low {
+		return unknown
+	}
+
+	percentile := (*floatValue - low) / (high - low)
+	percentile = math.Max(0, math.Min(1, percentile))
+
+	var multiplier float64
+	if percentile < 0.5 {
+		multiplier = 1 + m.MaxPremiumPercent/100*(1-2*percentile)
+	} else {
+		multiplier = 1 - m.MaxDiscountPercent/100*(2*percentile-1)
+	}
+
+	return FloatValuation{
+		Known:        true,
+		Percentile:   percentile,
+		Multiplier:   multiplier,
+		FairValueUSD: referencePriceUSD * multiplier,
+	}
+}
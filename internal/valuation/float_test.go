@@ -0,0 +1,75 @@
+package valuation
+
+import (
+	"math"
+	"testing"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestWearRange(t *testing.T) {
+	tests := []struct {
+		wear      string
+		low, high float64
+		ok        bool
+	}{
+		{"Factory New", 0, 0.07, true},
+		{"field-tested", 0.15, 0.38, true},
+		{"Battle Scarred", 0.45, 1, true},
+		{"", 0, 0, false},
+		{"Pristine", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		low, high, ok := WearRange(tt.wear)
+		if ok != tt.ok || low != tt.low || high != tt.high {
+			t.Errorf("WearRange(%q) = %v, %v, %v, want %v, %v, %v", tt.wear, low, high, ok, tt.low, tt.high, tt.ok)
+		}
+	}
+}
+
+func TestFloatModelValue(t *testing.T) {
+	m := FloatModel{MaxPremiumPercent: 20, MaxDiscountPercent: 10}
+
+	tests := []struct {
+		name               string
+		float              *float64
+		minFloat, maxFloat *float64
+		wear               string
+		known              bool
+		percentile         float64
+		multiplier         float64
+	}{
+		{"best in bucket", float(0.15), nil, nil, "Field-Tested", true, 0, 1.2},
+		{"worst in bucket", float(0.38), nil, nil, "Field-Tested", true, 1, 0.9},
+		{"middle of bucket", float(0.265), nil, nil, "Field-Tested", true, 0.5, 1},
+		{"quarter of bucket", float(0.2075), nil, nil, "Field-Tested", true, 0.25, 1.1},
+		{"outside bucket clamps", float(0.5), nil, nil, "Field-Tested", true, 1, 0.9},
+		// A 0.00-0.08 finish only reaches 0.07-0.08 in Minimal Wear
+		{"narrowed by paint range", float(0.075), float(0), float(0.08), "Minimal Wear", true, 0.5, 1},
+		{"paint range outside bucket", float(0.1), float(0), float(0.06), "Minimal Wear", false, 0, 1},
+		{"missing float", nil, nil, nil, "Field-Tested", false, 0, 1},
+		{"zero float", float(0), nil, nil, "Factory New", false, 0, 1},
+		{"no wear", float(0.2), nil, nil, "", false, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := m.Value(100, tt.float, tt.minFloat, tt.maxFloat, tt.wear)
+			if v.Known != tt.known {
+				t.Errorf("known = %v, want %v", v.Known, tt.known)
+			}
+			if math.Abs(v.Percentile-tt.percentile) > 1e-9 {
+				t.Errorf("percentile = %v, want %v", v.Percentile, tt.percentile)
+			}
+			if math.Abs(v.Multiplier-tt.multiplier) > 1e-9 {
+				t.Errorf("multiplier = %v, want %v", v.Multiplier, tt.multiplier)
+			}
+			if math.Abs(v.FairValueUSD-100*tt.multiplier) > 1e-9 {
+				t.Errorf("fair value = %v, want %v", v.FairValueUSD, 100*tt.multiplier)
+			}
+		})
+	}
+}
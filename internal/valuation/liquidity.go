@@ -0,0 +1,50 @@
+package valuation
+
+import (
+	"math"
+
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+)
+
+// LiquidityModel scores how readily an item sells on Steam from 0 (never
+// trades) to 100 (sells constantly at a stable price). Volume dominates the
+// score; a wide gap between the lowest listing and the median sale price
+// and a deep backlog of listings relative to sales pull it down.
+type LiquidityModel struct {
+	TargetDailyVolume float64 // Daily volume that earns the full volume score
+	MaxSupplyDays     float64 // Days of listings at which the supply score reaches 0
+}
+
+// LiquidityModelFromEnv builds a liquidity model from
+// LIQUIDITY_TARGET_DAILY_VOLUME and LIQUIDITY_MAX_SUPPLY_DAYS
+func LiquidityModelFromEnv() LiquidityModel {
+	return LiquidityModel{
+		TargetDailyVolume: config.Float("LIQUIDITY_TARGET_DAILY_VOLUME", 50),
+		MaxSupplyDays:     config.Float("LIQUIDITY_MAX_SUPPLY_DAYS", 30),
+	}
+}
+
+// Score computes the liquidity score. listingCount is negative when unknown,
+// in which case the supply component is neutral.
+func (m LiquidityModel) Score(dailyVolume, listingCount int, lowestPriceUSD, medianPriceUSD float64) float64 {
+	if dailyVolume <= 0 {
+		return 0
+	}
+
+	// Logarithmic so going from 1 to 10 sales a day matters more than 40 to 50
+	volumeScore := math.Min(1, math.Log1p(float64(dailyVolume))/math.Log1p(m.TargetDailyVolume))
+
+	spreadScore := 0.5
+	if lowestPriceUSD > 0 && medianPriceUSD > 0 {
+		spread := math.Abs(lowestPriceUSD-medianPriceUSD) / medianPriceUSD
+		spreadScore = math.Max(0, 1-2*spread)
+	}
+
+	supplyScore := 0.5
+	if listingCount >= 0 && m.MaxSupplyDays > 0 {
+		supplyDays := float64(listingCount) / float64(dailyVolume)
+		supplyScore = math.Max(0, 1-supplyDays/m.MaxSupplyDays)
+	}
+
+	return 100 * (0.6*volumeScore + 0.2*spreadScore + 0.2*supplyScore)
+}
@@ -0,0 +1,33 @@
+package valuation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLiquidityModelScore(t *testing.T) {
+	m := LiquidityModel{TargetDailyVolume: 50, MaxSupplyDays: 30}
+
+	tests := []struct {
+		name           string
+		dailyVolume    int
+		listingCount   int
+		lowest, median float64
+		want           float64
+	}{
+		{"never trades", 0, 10, 1, 1, 0},
+		{"ideal", 50, 0, 1, 1, 100},
+		{"above target volume", 500, 0, 1, 1, 100},
+		{"unknown listings and prices", 50, -1, 0, 0, 80},
+		{"wide spread", 50, 0, 1.5, 1, 80},
+		{"deep backlog", 50, 1500, 1, 1, 80},
+		{"half the backlog", 50, 750, 1, 1, 90},
+		{"low volume", 1, -1, 0, 0, 60*math.Log1p(1)/math.Log1p(50) + 20},
+	}
+
+	for _, tt := range tests {
+		if got := m.Score(tt.dailyVolume, tt.listingCount, tt.lowest, tt.median); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: Score = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
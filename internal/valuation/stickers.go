@@ -0,0 +1,110 @@
+package valuation
+
+import (
+	"strings"
+
+	"github.com/mswatii/cs2-arbitrage/internal/catalog"
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+	"github.com/mswatii/cs2-arbitrage/pkg/config"
+)
+
+// StickerIndex looks up sticker reference prices by name or image
+type StickerIndex struct {
+	byName      map[string]models.StickerPrice
+	byImage     map[string]models.StickerPrice
+	maxPriceUSD float64
+}
+
+// NewStickerIndex builds an index over the sticker catalogue
+func NewStickerIndex(prices []models.StickerPrice) *StickerIndex {
+	idx := &StickerIndex{
+		byName:  make(map[string]models.StickerPrice, len(prices)),
+		byImage: make(map[string]models.StickerPrice, len(prices)),
+	}
+	for _, p := range prices {
+		idx.byName[strings.ToLower(p.Name)] = p
+		if k := catalog.StickerImageKey(p.ImageURL); k != "" {
+			idx.byImage[strings.ToLower(k)] = p
+		}
+		idx.maxPriceUSD = max(idx.maxPriceUSD, p.PriceUSD)
+	}
+	return idx
+}
+
+// Lookup finds the catalogue entry for a parsed sticker
+func (idx *StickerIndex) Lookup(sticker models.Sticker) (models.StickerPrice, bool) {
+	if p, ok := idx.byName[strings.ToLower(sticker.Name)]; ok {
+		return p, true
+	}
+	if sticker.ImageURL != "" {
+		if p, ok := idx.byImage[strings.ToLower(catalog.StickerImageKey(sticker.ImageURL))]; ok {
+			return p, true
+		}
+	}
+	return models.StickerPrice{}, false
+}
+
+// StickerModel estimates how much buyers overpay for applied stickers.
+// Buyers pay a small fraction of a sticker's own price, a larger fraction
+// for expensive stickers, and scraped stickers lose value with their wear.
+type StickerModel struct {
+	PremiumPercent          float64 // Share of the sticker price added for ordinary stickers
+	HighValueUSD            float64 // Stickers at or above this price use HighValuePremiumPercent
+	HighValuePremiumPercent float64
+	MinPriceUSD             float64 // Stickers below this price are ignored
+}
+
+// StickerModelFromEnv builds a sticker model from STICKER_PREMIUM_PERCENT,
+// STICKER_HIGH_VALUE_USD, STICKER_HIGH_VALUE_PREMIUM_PERCENT and STICKER_MIN_PRICE_USD
+func StickerModelFromEnv() StickerModel {
+	return StickerModel{
+		PremiumPercent:          config.Float("STICKER_PREMIUM_PERCENT", 5),
+		HighValueUSD:            config.Float("STICKER_HIGH_VALUE_USD", 100),
+		HighValuePremiumPercent: config.Float("STICKER_HIGH_VALUE_PREMIUM_PERCENT", 12),
+		MinPriceUSD:             config.Float("STICKER_MIN_PRICE_USD", 1),
+	}
+}
+
+// StickerValuation is the result of pricing an item's stickers
+type StickerValuation struct {
+	Stickers   []models.Sticker
+	ValueUSD   float64 // Combined reference price of the stickers, adjusted for wear
+	PremiumUSD float64 // Estimated overpay a buyer makes for the stickers
+}
+
+// MaxPremiumUSD returns the most any one sticker in the index can add to an
+// item's price, a bound for filtering listings before valuing their stickers
+func (m StickerModel) MaxPremiumUSD(idx *StickerIndex) float64 {
+	return idx.maxPriceUSD * max(m.PremiumPercent, m.HighValuePremiumPercent) / 100
+}
+
+// Value parses raw stickers and estimates their premium using the index
+func (m StickerModel) Value(raw []string, idx *StickerIndex) StickerValuation {
+	var result StickerValuation
+	result.Stickers = catalog.ParseStickers(raw)
+
+	for i := range result.Stickers {
+		sticker := &result.Stickers[i]
+		price, ok := idx.Lookup(*sticker)
+		if !ok {
+			continue
+		}
+		sticker.Name = price.Name
+		sticker.PriceUSD = price.PriceUSD
+
+		if price.PriceUSD < m.MinPriceUSD {
+			continue
+		}
+
+		value := price.PriceUSD * (1 - sticker.Wear)
+		premiumPercent := m.PremiumPercent
+		if price.PriceUSD >= m.HighValueUSD {
+			premiumPercent = m.HighValuePremiumPercent
+		}
+
+		result.ValueUSD += value
+		result.PremiumUSD += value * premiumPercent / 100
+	}
+
+	return result
+}
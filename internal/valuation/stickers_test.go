@@ -0,0 +1,88 @@
+package valuation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mswatii/cs2-arbitrage/internal/models"
+)
+
+func testStickerIndex() *StickerIndex {
+	return NewStickerIndex([]models.StickerPrice{
+		{Name: "Titan (Holo) | Katowice 2014", ImageURL: "https://example.com/stickers/titan_holo.png", PriceUSD: 1000},
+		{Name: "Natus Vincere | Stockholm 2021", PriceUSD: 2},
+		{Name: "Cheap Sticker", PriceUSD: 0.5},
+	})
+}
+
+func TestStickerIndexLookup(t *testing.T) {
+	idx := testStickerIndex()
+
+	tests := []struct {
+		sticker models.Sticker
+		want    string
+	}{
+		{models.Sticker{Name: "titan (holo) | katowice 2014"}, "Titan (Holo) | Katowice 2014"},
+		{models.Sticker{Name: "titan_holo", ImageURL: "https://cdn.example.net/x/Titan_Holo.png?wear=0.1"}, "Titan (Holo) | Katowice 2014"},
+		{models.Sticker{Name: "Unknown"}, ""},
+	}
+
+	for _, tt := range tests {
+		price, ok := idx.Lookup(tt.sticker)
+		if ok != (tt.want != "") || price.Name != tt.want {
+			t.Errorf("Lookup(%+v) = %q, %v, want %q", tt.sticker, price.Name, ok, tt.want)
+		}
+	}
+}
+
+func TestStickerModelValue(t *testing.T) {
+	m := StickerModel{PremiumPercent: 5, HighValueUSD: 100, HighValuePremiumPercent: 10, MinPriceUSD: 1}
+	idx := testStickerIndex()
+
+	tests := []struct {
+		name               string
+		raw                []string
+		valueUSD, premium  float64
+		wantParsedStickers int
+	}{
+		{"none", nil, 0, 0, 0},
+		{"ordinary sticker", []string{"Natus Vincere | Stockholm 2021"}, 2, 0.1, 1},
+		{"high value sticker", []string{"Titan (Holo) | Katowice 2014"}, 1000, 100, 1},
+		{"scraped sticker", []string{"Titan (Holo) | Katowice 2014 (50% scraped)"}, 500, 50, 1},
+		{"below minimum price", []string{"Cheap Sticker"}, 0, 0, 1},
+		{"unknown and empty slots", []string{"", "Unknown", ""}, 0, 0, 1},
+		{"several", []string{"Natus Vincere | Stockholm 2021", "Natus Vincere | Stockholm 2021"}, 4, 0.2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := m.Value(tt.raw, idx)
+			if len(v.Stickers) != tt.wantParsedStickers {
+				t.Errorf("parsed %d stickers, want %d", len(v.Stickers), tt.wantParsedStickers)
+			}
+			if math.Abs(v.ValueUSD-tt.valueUSD) > 1e-9 || math.Abs(v.PremiumUSD-tt.premium) > 1e-9 {
+				t.Errorf("value, premium = %v, %v, want %v, %v", v.ValueUSD, v.PremiumUSD, tt.valueUSD, tt.premium)
+			}
+		})
+	}
+}
+
+func TestStickerModelMaxPremium(t *testing.T) {
+	m := StickerModel{PremiumPercent: 5, HighValueUSD: 100, HighValuePremiumPercent: 10, MinPriceUSD: 1}
+	idx := testStickerIndex()
+
+	maxPremium := m.MaxPremiumUSD(idx)
+	if maxPremium != 100 {
+		t.Errorf("MaxPremiumUSD = %v, want 100", maxPremium)
+	}
+	// No sticker can add more than the bound
+	for _, raw := range []string{"Titan (Holo) | Katowice 2014", "Natus Vincere | Stockholm 2021", "Cheap Sticker"} {
+		if v := m.Value([]string{raw}, idx); v.PremiumUSD > maxPremium {
+			t.Errorf("premium of %s = %v, above the bound %v", raw, v.PremiumUSD, maxPremium)
+		}
+	}
+
+	if got := m.MaxPremiumUSD(NewStickerIndex(nil)); got != 0 {
+		t.Errorf("MaxPremiumUSD of an empty catalogue = %v, want 0", got)
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String returns the environment variable for key or fallback when it is unset
+func String(key, fallback string) string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Float returns the environment variable for key parsed as a float
+func Float(key string, fallback float64) float64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: Invalid value %q for %s, using default %v", value, key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// Int returns the environment variable for key parsed as an integer
+func Int(key string, fallback int) int {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: Invalid value %q for %s, using default %v", value, key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// Bool returns the environment variable for key parsed as a boolean
+func Bool(key string, fallback bool) bool {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: Invalid value %q for %s, using default %v", value, key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// Duration returns the environment variable for key parsed as a duration (e.g. "30s", "1h")
+func Duration(key string, fallback time.Duration) time.Duration {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: Invalid value %q for %s, using default %v", value, key, fallback)
+		return fallback
+	}
+	return parsed
+}
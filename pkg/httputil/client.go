@@ -0,0 +1,89 @@
+package httputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Doer sends HTTP requests. *fasthttp.Client satisfies it, and tests or
+// callers pointing at local fakes can supply their own.
+type Doer interface {
+	DoTimeout(req *fasthttp.Request, resp *fasthttp.Response, timeout time.Duration) error
+}
+
+// DefaultTimeout bounds outgoing requests made through this package
+const DefaultTimeout = 15 * time.Second
+
+// DefaultClient is the shared client for outgoing requests
+var DefaultClient Doer = &fasthttp.Client{
+	Name:                "cs2-arbitrage",
+	MaxIdleConnDuration: time.Minute,
+	ReadTimeout:         DefaultTimeout,
+	WriteTimeout:        DefaultTimeout,
+}
+
+// PostJSON marshals payload and POSTs it to url with the given extra headers,
+// returning the response status code and body
+func PostJSON(client Doer, url string, payload interface{}, headers map[string]string) (int, []byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to encode request body: %v", err)
+	}
+	return PostBody(client, url, "application/json", body, headers)
+}
+
+// PostBody POSTs a raw body to url, returning the response status code and body
+func PostBody(client Doer, url, contentType string, body []byte, headers map[string]string) (int, []byte, error) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType(contentType)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.SetBody(body)
+
+	if err := client.DoTimeout(req, resp, DefaultTimeout); err != nil {
+		return 0, nil, fmt.Errorf("request to %s failed: %v", url, err)
+	}
+
+	// Copy the body since the response is released on return
+	respBody := append([]byte(nil), resp.Body()...)
+	return resp.StatusCode(), respBody, nil
+}
+
+// GetJSON fetches url and decodes a JSON response into target
+func GetJSON(client Doer, url string, headers map[string]string, target interface{}) (int, error) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod(fasthttp.MethodGet)
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	if err := client.DoTimeout(req, resp, DefaultTimeout); err != nil {
+		return 0, fmt.Errorf("request to %s failed: %v", url, err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return resp.StatusCode(), fmt.Errorf("%s returned non-200 status code: %d", url, resp.StatusCode())
+	}
+
+	if err := json.Unmarshal(resp.Body(), target); err != nil {
+		return resp.StatusCode(), fmt.Errorf("failed to parse response from %s: %v", url, err)
+	}
+
+	return resp.StatusCode(), nil
+}
@@ -0,0 +1,135 @@
+package httputil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// dialTimeout bounds connecting to a host allowed by a HostPolicy
+const dialTimeout = 10 * time.Second
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), not routable
+// on the internet but not covered by net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP reports whether ip is a routable internet address, rejecting
+// loopback, private, link-local, unspecified and multicast addresses
+func IsPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// HostPolicy decides which hosts requests to user-supplied URLs may reach.
+// The zero value allows public addresses only.
+type HostPolicy struct {
+	AllowPrivate bool            // Allow every address, including loopback and private ranges
+	Hosts        map[string]bool // Host names allowed whatever they resolve to
+	Networks     []*net.IPNet    // Non-public ranges allowed besides public addresses
+}
+
+// ParseHostPolicy builds a policy from a comma separated allowlist of host
+// names, IP addresses and CIDR ranges
+func ParseHostPolicy(allowPrivate bool, allowlist string) (HostPolicy, error) {
+	policy := HostPolicy{AllowPrivate: allowPrivate, Hosts: make(map[string]bool)}
+	for _, entry := range strings.Split(allowlist, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return HostPolicy{}, fmt.Errorf("invalid CIDR range %q: %v", entry, err)
+			}
+			policy.Networks = append(policy.Networks, network)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			policy.Networks = append(policy.Networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		default:
+			policy.Hosts[entry] = true
+		}
+	}
+	return policy, nil
+}
+
+// allowsIP reports whether requests may reach ip
+func (p HostPolicy) allowsIP(ip net.IP) bool {
+	if p.AllowPrivate || IsPublicIP(ip) {
+		return true
+	}
+	for _, network := range p.Networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve resolves host and returns its addresses, failing when any of them
+// is not allowed
+func (p HostPolicy) Resolve(host string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(context.Background(), host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %v", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("%s has no addresses", host)
+	}
+
+	allowedHost := p.Hosts[strings.ToLower(host)]
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if !allowedHost && !p.allowsIP(addr.IP) {
+			return nil, fmt.Errorf("%s resolves to non-public address %s", host, addr.IP)
+		}
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
+
+// dial connects to addr ("host:port") only when the host resolves to allowed
+// addresses, dialling the checked address so a second lookup can't swap in
+// another one
+func (p HostPolicy) dial(addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := p.Resolve(host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := fasthttp.DialDualStackTimeout(net.JoinHostPort(ip.String(), port), dialTimeout)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// NewClient creates a client that only connects to hosts the policy allows,
+// for requests to URLs supplied by users such as alert webhooks. The check
+// runs on every connection, so a host later pointed at an internal address
+// is refused too. Like every fasthttp client it doesn't follow redirects.
+func (p HostPolicy) NewClient() Doer {
+	return &fasthttp.Client{
+		Name:                "cs2-arbitrage",
+		MaxIdleConnDuration: time.Minute,
+		ReadTimeout:         DefaultTimeout,
+		WriteTimeout:        DefaultTimeout,
+		Dial:                p.dial,
+	}
+}
@@ -0,0 +1,41 @@
+package httputil
+
+import "testing"
+
+func TestHostPolicyResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		private   bool
+		allowlist string
+		host      string
+		allowed   bool
+	}{
+		{"public address", false, "", "1.1.1.1", true},
+		{"loopback refused by default", false, "", "127.0.0.1", false},
+		{"private refused by default", false, "", "10.1.2.3", false},
+		{"private allowed by setting", true, "", "10.1.2.3", true},
+		{"address on the allowlist", false, "127.0.0.1", "127.0.0.1", true},
+		{"range on the allowlist", false, "10.0.0.0/8", "10.1.2.3", true},
+		{"address outside the allowed range", false, "10.0.0.0/8", "192.168.1.1", false},
+		{"host on the allowlist", false, "localhost", "localhost", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseHostPolicy(tt.private, tt.allowlist)
+			if err != nil {
+				t.Fatalf("ParseHostPolicy: %v", err)
+			}
+			_, err = policy.Resolve(tt.host)
+			if allowed := err == nil; allowed != tt.allowed {
+				t.Errorf("Resolve(%q) allowed = %v, want %v (err %v)", tt.host, allowed, tt.allowed, err)
+			}
+		})
+	}
+}
+
+func TestParseHostPolicyRejectsInvalidRange(t *testing.T) {
+	if _, err := ParseHostPolicy(false, "10.0.0.0/99"); err == nil {
+		t.Error("expected an error for an invalid CIDR range")
+	}
+}
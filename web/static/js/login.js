@@ -0,0 +1,57 @@
+// Whether the form signs in with an API key rather than a password
+let useAPIKey = false;
+
+// Switch between signing in with a username and password or an API key
+function toggleMode(event) {
+    event.preventDefault();
+    useAPIKey = !useAPIKey;
+    document.getElementById('password-fields').style.display = useAPIKey ? 'none' : '';
+    document.getElementById('api-key-fields').style.display = useAPIKey ? '' : 'none';
+    document.getElementById('login-mode').textContent = useAPIKey
+        ? 'Sign in with a username and password instead'
+        : 'Sign in with an API key instead';
+    document.getElementById('login-error').textContent = '';
+}
+
+// Exchange a username and password, or an API key, for a session cookie and
+// go to the dashboard
+async function login(event) {
+    event.preventDefault();
+    const errorEl = document.getElementById('login-error');
+    errorEl.textContent = '';
+
+    const credentials = useAPIKey
+        ? { api_key: document.getElementById('api-key').value.trim() }
+        : {
+            username: document.getElementById('username').value.trim(),
+            password: document.getElementById('password').value
+        };
+    if (useAPIKey ? !credentials.api_key : !credentials.username || !credentials.password) {
+        errorEl.textContent = useAPIKey ? 'Enter an API key' : 'Enter your username and password';
+        return;
+    }
+
+    try {
+        const response = await fetch('/api/auth/login', {
+            method: 'POST',
+            credentials: 'same-origin',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(credentials)
+        });
+        if (!response.ok) {
+            errorEl.textContent = response.status === 401
+                ? (useAPIKey ? 'Invalid API key' : 'Invalid username or password')
+                : 'Sign in failed, please try again';
+            return;
+        }
+        window.location.href = '/';
+    } catch (error) {
+        console.error('Error signing in:', error);
+        errorEl.textContent = 'Sign in failed, please try again';
+    }
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    document.getElementById('login-form').addEventListener('submit', login);
+    document.getElementById('login-mode').addEventListener('click', toggleMode);
+});
@@ -4,6 +4,7 @@ const state = {
     filteredItems: [],
     filters: {
         minProfit: 10,
+        direction: '',
         category: '',
         quality: '',
         minPrice: '',
@@ -13,7 +14,9 @@ const state = {
     },
     sort: 'profit_desc',
     exchangeRate: 0,
-    lastUpdated: null
+    displayCurrency: 'USD',
+    lastUpdated: null,
+    streamConnectedBefore: false
 };
 
 // DOM Elements
@@ -21,6 +24,7 @@ const elements = {
     exchangeRate: document.getElementById('exchange-rate'),
     lastUpdated: document.getElementById('last-updated'),
     refreshBtn: document.getElementById('refresh-btn'),
+    logoutBtn: document.getElementById('logout-btn'),
     itemsGrid: document.getElementById('items-grid'),
     itemsCount: document.getElementById('items-count'),
     noItems: document.getElementById('no-items'),
@@ -28,6 +32,7 @@ const elements = {
     // Filters
     profitSlider: document.getElementById('profit-slider'),
     profitValue: document.getElementById('profit-value'),
+    directionFilter: document.getElementById('direction-filter'),
     categoryFilter: document.getElementById('category-filter'),
     qualityFilter: document.getElementById('quality-filter'),
     minPriceFilter: document.getElementById('min-price'),
@@ -45,13 +50,46 @@ const elements = {
 const API = {
     exchangeRate: '/api/exchange-rate',
     arbitrage: '/api/arbitrage',
-    refresh: '/api/refresh'
+    refresh: '/api/refresh',
+    stream: '/api/stream',
+    me: '/api/auth/me',
+    logout: '/api/auth/logout'
 };
 
+// Fetch from the API, sending the session cookie and going to the sign in
+// page when the session is missing or expired
+async function apiFetch(url, options = {}) {
+    const response = await fetch(url, { credentials: 'same-origin', ...options });
+    if (response.status === 401) {
+        window.location.href = '/login';
+        throw new Error('Not signed in');
+    }
+    return response;
+}
+
+// Load who is signed in, hiding controls their scopes don't allow
+async function fetchPrincipal() {
+    const response = await apiFetch(API.me);
+    const principal = await response.json();
+    const scopes = principal.scopes || [];
+    if (!scopes.includes('admin') && !scopes.includes('refresh')) {
+        elements.refreshBtn.style.display = 'none';
+    }
+    if (!principal.session) {
+        elements.logoutBtn.style.display = 'none';
+    }
+}
+
+// Sign out and return to the sign in page
+async function logout() {
+    await fetch(API.logout, { method: 'POST', credentials: 'same-origin' });
+    window.location.href = '/login';
+}
+
 // Fetch Exchange Rate
 async function fetchExchangeRate() {
     try {
-        const response = await fetch(API.exchangeRate);
+        const response = await apiFetch(API.exchangeRate);
         const data = await response.json();
 
         state.exchangeRate = data.usdt_to_irr;
@@ -70,10 +108,14 @@ async function fetchExchangeRate() {
 async function fetchArbitrageItems() {
     try {
         const minProfit = state.filters.minProfit;
-        const response = await fetch(`${API.arbitrage}?min_profit=${minProfit}`);
+        const response = await apiFetch(`${API.arbitrage}?min_profit=${minProfit}&direction=both`);
         const data = await response.json();
 
         state.items = data.opportunities || [];
+        state.displayCurrency = data.display_currency || 'USD';
+        if (data.usd_to_irr) {
+            state.exchangeRate = data.usd_to_irr;
+        }
         applyFiltersAndSort();
     } catch (error) {
         console.error('Error fetching arbitrage items:', error);
@@ -81,21 +123,63 @@ async function fetchArbitrageItems() {
     }
 }
 
+// Subscribe to live opportunity changes pushed by the server
+function connectStream() {
+    if (!window.EventSource) return;
+
+    const source = new EventSource(API.stream);
+
+    const upsert = (event) => {
+        const { item_id: itemId, opportunity } = JSON.parse(event.data);
+        const minProfit = state.filters.minProfit;
+        state.items = state.items.filter(item => item.item_id !== itemId);
+        if (opportunity.profit_percent >= minProfit || opportunity.profit_with_stickers_percent >= minProfit) {
+            state.items.push(opportunity);
+        }
+        applyFiltersAndSort();
+    };
+
+    source.addEventListener('opportunity-added', upsert);
+    source.addEventListener('opportunity-updated', upsert);
+    source.addEventListener('opportunity-removed', (event) => {
+        const { item_id: itemId } = JSON.parse(event.data);
+        state.items = state.items.filter(item => item.item_id !== itemId);
+        applyFiltersAndSort();
+    });
+
+    // EventSource reconnects on its own; reload to catch up on missed events
+    source.addEventListener('open', () => {
+        if (state.streamConnectedBefore) fetchArbitrageItems();
+        state.streamConnectedBefore = true;
+    });
+}
+
 // Refresh Data
 async function refreshData() {
     try {
         elements.refreshBtn.disabled = true;
         elements.refreshBtn.innerHTML = '<i class="fas fa-spinner fa-spin"></i> Refreshing...';
 
-        const response = await fetch(API.refresh);
+        const response = await apiFetch(API.refresh, { method: 'POST' });
+        if (response.status === 429) {
+            const retryAfter = response.headers.get('Retry-After');
+            showNotification(`Refreshed too recently, try again in ${retryAfter} seconds.`, 'error');
+            return;
+        }
+        if (response.status === 409) {
+            showNotification('A refresh is already running.', 'info');
+            return;
+        }
         if (!response.ok) {
-            throw new Error('Refresh failed');
+            // Errors come as {code, message, request_id, details}
+            const error = await response.json().catch(() => ({}));
+            throw new Error(`${error.message || 'Refresh failed'} (request ${error.request_id || 'unknown'})`);
         }
 
+        // The scrape runs in the background and its results stream in
         await fetchExchangeRate();
-        await fetchArbitrageItems();
 
-        showNotification('Data refreshed successfully!', 'success');
+        showNotification('Refresh started, new listings will appear as they are scraped.', 'success');
     } catch (error) {
         console.error('Error refreshing data:', error);
         showNotification('Failed to refresh data. Please try again.', 'error');
@@ -114,6 +198,11 @@ function applyFiltersAndSort() {
             return false;
         }
 
+        // Direction filter
+        if (state.filters.direction && item.direction !== state.filters.direction) {
+            return false;
+        }
+
         // Category filter
         if (state.filters.category && item.category !== state.filters.category) {
             return false;
@@ -134,7 +223,7 @@ function applyFiltersAndSort() {
         }
 
         // StatTrak filter
-        if (state.filters.statTrak && !item.market_hash_name.includes('StatTrak™')) {
+        if (state.filters.statTrak && !item.is_stattrak) {
             return false;
         }
 
@@ -161,6 +250,8 @@ function sortItems() {
                 return b.profit_percent - a.profit_percent;
             case 'profit_asc':
                 return a.profit_percent - b.profit_percent;
+            case 'annualized_desc':
+                return b.annualized_return_percent - a.annualized_return_percent;
             case 'price_desc':
                 return b.buy_price_usd - a.buy_price_usd;
             case 'price_asc':
@@ -199,7 +290,7 @@ function renderItems() {
 
         // Set category and quality
         card.querySelector('.item-category').textContent = item.category || 'Unknown';
-        card.querySelector('.item-quality').textContent = item.quality || 'Unknown';
+        card.querySelector('.item-quality').textContent = item.quality || '-';
 
         // Set image
         const img = card.querySelector('.item-image img');
@@ -207,21 +298,63 @@ function renderItems() {
         img.alt = item.market_hash_name;
 
         // Set name
-        card.querySelector('.item-name').textContent = item.market_hash_name;
+        let name = item.market_hash_name;
+        if (item.phase) {
+            name += ` (${item.phase})`;
+        } else if (item.phase_unknown) {
+            name += ' (phase unknown)';
+        }
+        if (item.fade_percent > 0) {
+            name += ` ${item.fade_percent}% Fade`;
+        }
+        card.querySelector('.item-name').textContent = name;
 
         // Set float
-        card.querySelector('.item-float').textContent = `Float: ${item.float.toFixed(6)}`;
+        // Set float and float-adjusted fair value (items without wear have neither)
+        if (item.float !== null && item.float !== undefined) {
+            card.querySelector('.item-float').textContent = `Float: ${item.float.toFixed(6)}`;
+            card.querySelector('.item-fair-value').textContent =
+                `Fair value: $${item.fair_value_usd.toFixed(2)} (x${item.float_multiplier.toFixed(3)})`;
+        } else {
+            card.querySelector('.item-float').style.display = 'none';
+            card.querySelector('.item-fair-value').style.display = 'none';
+        }
 
         // Set prices
-        card.querySelector('.buy-price .price-value').textContent = `$${item.buy_price_usd.toFixed(2)}`;
-        card.querySelector('.sell-price .price-value').textContent = `$${item.sell_price_usd.toFixed(2)}`;
+        card.querySelector('.buy-price .price-value').textContent = formatPrice(item.buy_price_usd);
+        card.querySelector('.sell-price .price-value').textContent = formatPrice(item.sell_price_usd);
 
         // Set profit
-        card.querySelector('.profit-amount').textContent = `+$${item.profit_usd.toFixed(2)}`;
+        card.querySelector('.profit-amount').textContent = `+${formatPrice(item.profit_usd)}`;
         card.querySelector('.profit-percentage').textContent = `+${item.profit_percent.toFixed(2)}%`;
 
         // Set marketplace
-        card.querySelector('.item-marketplace').textContent = item.marketplace;
+        card.querySelector('.item-marketplace').textContent = `Buy on ${item.buy_market} → Sell on ${item.sell_market}`;
+
+        // Set sticker premium
+        const stickerPremium = card.querySelector('.item-sticker-premium');
+        if (item.sticker_premium_usd > 0) {
+            stickerPremium.textContent =
+                `Stickers: $${item.sticker_value_usd.toFixed(2)} value, +$${item.sticker_premium_usd.toFixed(2)} premium`;
+        } else {
+            stickerPremium.style.display = 'none';
+        }
+
+        // Set Steam liquidity (unknown until the Steam statistics are fetched)
+        const liquidity = card.querySelector('.item-liquidity');
+        if (item.liquidity_score !== null && item.liquidity_score !== undefined) {
+            liquidity.textContent =
+                `Liquidity: ${item.liquidity_score.toFixed(0)}/100 · ${item.daily_volume} sold/day`;
+        } else {
+            liquidity.textContent = 'Liquidity: unknown';
+        }
+
+        // Set trade hold and annualized return
+        const lockup = card.querySelector('.item-lockup');
+        const holdText = item.days_until_sellable > 0
+            ? `Trade hold: ${item.days_until_sellable.toFixed(1)} days`
+            : 'Sellable now';
+        lockup.textContent = `${holdText} · ${item.annualized_return_percent.toFixed(0)}% annualized`;
 
         // Add stickers if available
         const stickersContainer = card.querySelector('.item-stickers');
@@ -254,6 +387,12 @@ function setupEventListeners() {
         applyFiltersAndSort();
     });
 
+    // Direction filter
+    elements.directionFilter.addEventListener('change', (e) => {
+        state.filters.direction = e.target.value;
+        applyFiltersAndSort();
+    });
+
     // Category filter
     elements.categoryFilter.addEventListener('change', (e) => {
         state.filters.category = e.target.value;
@@ -302,6 +441,7 @@ function setupEventListeners() {
 
     // Refresh button
     elements.refreshBtn.addEventListener('click', refreshData);
+    elements.logoutBtn.addEventListener('click', logout);
 }
 
 // Clear all filters
@@ -309,6 +449,7 @@ function clearFilters() {
     // Reset filter values
     state.filters = {
         minProfit: 10,
+        direction: '',
         category: '',
         quality: '',
         minPrice: '',
@@ -320,6 +461,7 @@ function clearFilters() {
     // Reset UI elements
     elements.profitSlider.value = 10;
     elements.profitValue.textContent = '10';
+    elements.directionFilter.value = '';
     elements.categoryFilter.value = '';
     elements.qualityFilter.value = '';
     elements.minPriceFilter.value = '';
@@ -370,6 +512,14 @@ function formatNumber(num) {
     return new Intl.NumberFormat().format(num);
 }
 
+// Format a USD amount in the signed in user's display currency
+function formatPrice(usd) {
+    if (state.displayCurrency === 'IRR' && state.exchangeRate > 0) {
+        return `${formatNumber(Math.round(usd * state.exchangeRate))} IRR`;
+    }
+    return `$${usd.toFixed(2)}`;
+}
+
 function formatDate(date) {
     if (!date) return '-';
     return new Intl.DateTimeFormat('en-US', {
@@ -383,6 +533,11 @@ function formatDate(date) {
 
 // Initialize the application
 async function init() {
+    try {
+        await fetchPrincipal();
+    } catch (error) {
+        console.error('Error loading session:', error);
+    }
     setupEventListeners();
 
     // Show loading state
@@ -396,6 +551,7 @@ async function init() {
     // Fetch initial data
     await fetchExchangeRate();
     await fetchArbitrageItems();
+    connectStream();
 }
 
 // Start the app